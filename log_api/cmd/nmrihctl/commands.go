@@ -0,0 +1,278 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/dto"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/enums"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/tools"
+)
+
+const printTimeFormat = "2006-01-02 15:04:05"
+
+// timeFlag is an optional RFC 3339 or YYYY-MM-DD command line time.
+type timeFlag struct {
+	value *time.Time
+	// end makes a plain date the last instant of that day, for inclusive range ends
+	end bool
+}
+
+func (f *timeFlag) String() string {
+	if f.value == nil {
+		return ""
+	}
+	return f.value.Format(time.RFC3339)
+}
+
+func (f *timeFlag) Set(value string) error {
+	parseTime := tools.ParseTimeQuery
+	if f.end {
+		parseTime = tools.ParseTimeQueryEnd
+	}
+	parsed, err := parseTime(value)
+	if err != nil {
+		return err
+	}
+	f.value = &parsed
+	return nil
+}
+
+// importLogs backfills the given logs. It only takes in entries older than the stored ones,
+// so a range that overlaps them has to go through reparse.
+func (a *app) importLogs(ctx context.Context, args []string) error {
+	from, to := timeFlag{}, timeFlag{end: true}
+	flags := flag.NewFlagSet("import", flag.ExitOnError)
+	flags.Var(&from, "from", "skip entries before this time")
+	flags.Var(&to, "to", "skip entries after this time (default: just before the first stored entry)")
+	_ = flags.Parse(args)
+	if flags.NArg() == 0 {
+		return errors.New("import: no log sources given")
+	}
+
+	stats, err := a.csvMaintenance.Stats(ctx)
+	if err != nil {
+		return err
+	}
+	if stats.First != nil {
+		if to.value != nil && !to.value.Before(*stats.First) {
+			return fmt.Errorf(
+				"import: -to overlaps the stored entries starting at %s, use reparse instead",
+				stats.First.Format(printTimeFormat),
+			)
+		}
+		if to.value == nil {
+			to.value = tools.ToPtr(stats.First.Add(-time.Second))
+		}
+	}
+
+	logs, err := a.logRepository.GetLogsFrom(ctx, flags.Args()...)
+	if err != nil {
+		return err
+	}
+	if err := a.parseRange(ctx, logs, from.value, to.value); err != nil {
+		return err
+	}
+	return a.rebuildRollupsIfEnabled(ctx)
+}
+
+// reparse replaces the stored entries of a range with a fresh parse of the logs.
+func (a *app) reparse(ctx context.Context, args []string) error {
+	from, to := timeFlag{}, timeFlag{end: true}
+	flags := flag.NewFlagSet("reparse", flag.ExitOnError)
+	flags.Var(&from, "from", "first time to re-parse (required)")
+	flags.Var(&to, "to", "last time to re-parse (required)")
+	_ = flags.Parse(args)
+	if from.value == nil || to.value == nil {
+		return errors.New("reparse: -from and -to are required")
+	}
+	if to.value.Before(*from.value) {
+		return errors.New("reparse: -to must not be before -from")
+	}
+
+	// Read the logs first, so a missing source does not leave the range empty
+	logs, err := a.logRepository.GetLogs(ctx)
+	if flags.NArg() > 0 {
+		logs, err = a.logRepository.GetLogsFrom(ctx, flags.Args()...)
+	}
+	if err != nil {
+		return err
+	}
+	if len(logs) == 0 {
+		return errors.New("reparse: found no logs")
+	}
+
+	removed, err := a.csvMaintenance.Remove(ctx, *from.value, *to.value)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("removed %d stored entries\n", removed)
+
+	if err := a.parseRange(ctx, logs, from.value, to.value); err != nil {
+		return err
+	}
+	return a.rebuildRollupsIfEnabled(ctx)
+}
+
+func (a *app) parseRange(ctx context.Context, logs map[string][]byte, from *time.Time, to *time.Time) error {
+	var fromValue, toValue time.Time
+	if from != nil {
+		fromValue = *from
+	}
+	if to != nil {
+		toValue = *to
+	}
+
+	report, err := a.logParser.ParseRange(ctx, logs, fromValue, toValue, time.Now())
+	if report != nil {
+		fmt.Printf(
+			"parsed %d of %d lines from %d files (%d diagnostics)\n",
+			report.ParsedCount, report.LinesCount, report.FilesCount, report.DiagnosticsCount,
+		)
+	}
+	return err
+}
+
+func (a *app) stats(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("stats", flag.ExitOnError)
+	asJSON := flags.Bool("json", false, "print as JSON")
+	_ = flags.Parse(args)
+
+	stats, err := a.csvMaintenance.Stats(ctx)
+	if err != nil {
+		return err
+	}
+	if *asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(stats)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "files\t%d\n", stats.FilesCount)
+	fmt.Fprintf(w, "entries\t%d\n", stats.EventsCount)
+	fmt.Fprintf(w, "players\t%d\n", stats.PlayersCount)
+	if stats.First != nil {
+		fmt.Fprintf(w, "first\t%s\n", stats.First.Format(printTimeFormat))
+		fmt.Fprintf(w, "last\t%s\n", stats.Last.Format(printTimeFormat))
+		fmt.Fprintf(w, "checkpoint\t%s\n", stats.Checkpoint.Format(printTimeFormat))
+	}
+	for _, action := range sortedKeys(stats.ActionCounts) {
+		fmt.Fprintf(w, "action %s\t%d\n", action, stats.ActionCounts[action])
+	}
+	for _, month := range sortedKeys(stats.EventsPerMonth) {
+		fmt.Fprintf(w, "month %s\t%d\n", month, stats.EventsPerMonth[month])
+	}
+	return w.Flush()
+}
+
+func (a *app) verify(ctx context.Context, _ []string) error {
+	issues, err := a.csvMaintenance.Verify(ctx)
+	if err != nil {
+		return err
+	}
+	for _, issue := range issues {
+		if issue.Entry == 0 {
+			fmt.Printf("%s: %s\n", issue.File, issue.Reason)
+			continue
+		}
+		fmt.Printf("%s: entry %d: %s\n", issue.File, issue.Entry, issue.Reason)
+	}
+	if len(issues) > 0 {
+		return fmt.Errorf("verify: found %d issues", len(issues))
+	}
+	fmt.Println("no issues found")
+	return nil
+}
+
+func (a *app) compact(ctx context.Context, _ []string) error {
+	report, err := a.csvMaintenance.Compact(ctx, time.Now())
+	if err != nil {
+		return err
+	}
+	fmt.Printf(
+		"compacted %d files into %d: %d duplicates dropped, %d entries expired, %d IPs removed, %d rows archived\n",
+		report.FilesBefore, report.FilesAfter, report.DuplicatesCount,
+		report.ExpiredCount, report.IPsRemovedCount, report.ArchivedCount,
+	)
+	return a.rebuildRollupsIfEnabled(ctx)
+}
+
+func (a *app) migrate(ctx context.Context, _ []string) error {
+	migrated, err := a.csvMaintenance.Migrate(ctx)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("migrated %d files to schema version %d\n", migrated, enums.CSVSchemaVersion)
+	return nil
+}
+
+func (a *app) rebuildRollups(ctx context.Context, _ []string) error {
+	if a.rollups == nil {
+		return errors.New("rebuild-rollups: the state directory is not set: use -state-dir or STATE_STORAGE_DIRECTORY")
+	}
+	count, err := a.rollups.Rebuild(ctx)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("rebuilt the rollups from %d entries\n", count)
+	// The API answers conditional graph requests by the generation
+	return a.generation.Bump()
+}
+
+// rebuildRollupsIfEnabled recomputes the rollups after the stored history changed, as they only follow new entries.
+func (a *app) rebuildRollupsIfEnabled(ctx context.Context) error {
+	if a.rollups == nil {
+		return nil
+	}
+	return a.rebuildRollups(ctx, nil)
+}
+
+func (a *app) erase(ctx context.Context, args []string) error {
+	var request dto.ErasureRequest
+	flags := flag.NewFlagSet("erase", flag.ExitOnError)
+	flags.StringVar(&request.SteamID, "steam-id", "", "SteamID of the player in any notation, e.g. [U:1:42] or STEAM_0:0:21")
+	flags.StringVar(&request.NickName, "nick", "", "nickname of the player")
+	flags.StringVar(
+		(*string)(&request.Mode),
+		"mode",
+		enums.ErasureModes.Delete().String(),
+		"delete the events, or pseudonymise to keep them for the statistics",
+	)
+	_ = flags.Parse(args)
+	if a.erasure == nil {
+		return errors.New("erase: the state directory is not set: use -state-dir or STATE_STORAGE_DIRECTORY")
+	}
+
+	report, err := a.erasure.Erase(ctx, "nmrihctl", request)
+	if err != nil {
+		return err
+	}
+	player := strings.Join(report.NickNames, ", ")
+	if report.SteamID != "" {
+		player = strings.TrimSuffix(report.SteamID+" "+player, " ")
+	}
+	fmt.Printf(
+		"erased %s: %d CSV entries, %d archived entries and %d state entries changed, "+
+			"cached graphs expire with their TTL\n",
+		player, report.CSVEntriesCount, report.ArchiveEntriesCount, report.StateEntriesCount,
+	)
+	return nil
+}
+
+func sortedKeys(m map[string]int) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,25 @@
+package main
+
+import (
+	"context"
+
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/dto"
+)
+
+type ipAPIClient interface {
+	GetCountryByIP(ctx context.Context, ip string) (*dto.IPInfo, error)
+}
+
+// offlineIPAPIClient leaves countries empty when no ipinfo token is configured.
+type offlineIPAPIClient struct{}
+
+func (offlineIPAPIClient) GetCountryByIP(_ context.Context, _ string) (*dto.IPInfo, error) {
+	return &dto.IPInfo{}, nil
+}
+
+// discardReports drops parse reports when there is no state directory to keep them in.
+type discardReports struct{}
+
+func (discardReports) Save(_ *dto.ParseReport) error {
+	return nil
+}
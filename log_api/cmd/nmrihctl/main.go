@@ -0,0 +1,310 @@
+// Command nmrihctl maintains the log store offline: it backfills and re-parses logs and inspects the CSV files,
+// without the HTTP server or Redis. It reads the same environment variables as the API.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"runtime"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/app/ipapiclient"
+	ipapiclientconfig "github.com/dmitriitimoshenko/nmrih/log_api/internal/app/ipapiclient/config"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/enums"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/services/aliases"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/services/audit"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/services/chat"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/services/csvgenerator"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/services/csvmaintenance"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/services/csvparser"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/services/csvrepository"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/services/erasure"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/services/generation"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/services/graph"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/services/logparser"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/services/logrepository"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/services/parsereport"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/services/privacy"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/services/records"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/services/rollups"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/services/rounds"
+)
+
+const usage = `Usage: nmrihctl [flags] <command> [command flags]
+
+Commands:
+  import [-from] [-to] <dir|file|archive>...   backfill logs older than the stored ones
+  reparse -from -to [<dir|file|archive>...]    replace the stored entries of a range, e.g. after a parser fix
+  stats [-json]                               summarize the stored entries
+  verify                                      check the CSV files, exits with 1 on issues
+  compact                                     merge the CSV files into monthly segments and apply the retention
+  migrate                                     rewrite CSV files of an older schema version in the current one
+  rebuild-rollups                             recompute the graph rollups from the CSV store
+  erase [-steam-id] [-nick] [-mode]           remove or pseudonymise a player's stored data, needs -state-dir
+
+import, reparse and compact rebuild the rollups when a state directory is set,
+which also makes the API clients refetch the graphs.
+
+Flags:
+`
+
+// Country lookups default to the same limits as in the API.
+const (
+	defaultIPInfoTimeout     = 3 * time.Second
+	defaultIPInfoConcurrency = 4
+)
+
+type settings struct {
+	csvDirectory       string
+	archiveDirectory   string
+	logsDirectory      string
+	logsPattern        string
+	stateDirectory     string
+	ipRetentionDays    int
+	eventRetentionDays int
+	ipMode             enums.IPMode
+	hashKey            string
+}
+
+type app struct {
+	logRepository  *logrepository.Service
+	logParser      *logparser.Service
+	csvMaintenance *csvmaintenance.Service
+	// rollups, generation and erasure are nil without a state directory
+	rollups    *rollups.Service
+	generation *generation.Service
+	erasure    *erasure.Service
+}
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("nmrihctl: ")
+
+	var st settings
+	flags := flag.NewFlagSet("nmrihctl", flag.ExitOnError)
+	flags.StringVar(&st.csvDirectory, "csv-dir", os.Getenv("CSV_STORAGE_DIRECTORY"), "CSV store directory")
+	flags.StringVar(&st.logsDirectory, "logs-dir", os.Getenv("LOGS_STORAGE_DIRECTORY"), "srcds log directory")
+	flags.StringVar(&st.logsPattern, "logs-pattern", os.Getenv("LOGS_FILE_PATTERN"), "log file name pattern")
+	flags.StringVar(
+		&st.stateDirectory,
+		"state-dir",
+		os.Getenv("STATE_STORAGE_DIRECTORY"),
+		"state directory for nickname history, chat, rounds, records, parse reports and rollups, skipped when empty",
+	)
+	flags.IntVar(
+		&st.ipRetentionDays,
+		"ip-retention-days",
+		envInt("CSV_IP_RETENTION_DAYS"),
+		"compact: strip IP addresses older than this, 0 keeps them",
+	)
+	flags.IntVar(
+		&st.eventRetentionDays,
+		"event-retention-days",
+		envInt("CSV_EVENT_RETENTION_DAYS"),
+		"compact: drop entries older than this, 0 keeps them",
+	)
+	flags.StringVar(
+		&st.archiveDirectory,
+		"archive-dir",
+		os.Getenv("CSV_ARCHIVE_DIRECTORY"),
+		"compact: move data past its retention here instead of deleting it",
+	)
+	flags.StringVar(
+		(*string)(&st.ipMode),
+		"ip-mode",
+		envOr("PRIVACY_IP_MODE", enums.IPModes.Plain().String()),
+		"how parsed IP addresses are stored: plain, hash or truncate",
+	)
+	st.hashKey = os.Getenv("PRIVACY_HASH_KEY")
+	flags.Usage = func() {
+		fmt.Fprint(flags.Output(), usage)
+		flags.PrintDefaults()
+	}
+	_ = flags.Parse(os.Args[1:])
+
+	if flags.NArg() == 0 {
+		flags.Usage()
+		os.Exit(2)
+	}
+	if st.csvDirectory == "" {
+		log.Fatalln("the CSV store directory is not set: use -csv-dir or CSV_STORAGE_DIRECTORY")
+	}
+	if st.logsPattern == "" {
+		st.logsPattern = "*.log"
+	}
+	if !st.ipMode.IsValid() {
+		log.Fatalf("the IP mode must be one of plain, hash or truncate: %q", st.ipMode)
+	}
+	if st.ipMode == enums.IPModes.Hash() && st.hashKey == "" {
+		log.Fatalln("PRIVACY_HASH_KEY is required with the hash IP mode")
+	}
+
+	a := newApp(st)
+
+	// An interrupted command stops reading; one that started writing the store finishes first
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	command, args := flags.Arg(0), flags.Args()[1:]
+	var err error
+	switch command {
+	case "import":
+		err = a.importLogs(ctx, args)
+	case "reparse":
+		err = a.reparse(ctx, args)
+	case "stats":
+		err = a.stats(ctx, args)
+	case "verify":
+		err = a.verify(ctx, args)
+	case "compact":
+		err = a.compact(ctx, args)
+	case "migrate":
+		err = a.migrate(ctx, args)
+	case "rebuild-rollups":
+		err = a.rebuildRollups(ctx, args)
+	case "erase":
+		err = a.erase(ctx, args)
+	default:
+		flags.Usage()
+		os.Exit(2)
+	}
+	stop()
+	if err != nil {
+		log.Fatalln(err)
+	}
+}
+
+func newApp(st settings) *app {
+	logRepository := logrepository.NewService(*logrepository.NewConfig(st.logsDirectory, st.logsPattern))
+	csvGenerator := csvgenerator.NewCSVGenerator()
+	csvRepository := csvrepository.NewService(*csvrepository.NewConfig(st.csvDirectory))
+	csvParser := csvparser.NewService()
+	privacyService := privacy.NewService(*privacy.NewConfig(st.ipMode, st.hashKey))
+
+	bestEffort, _ := strconv.ParseBool(os.Getenv("LOG_PARSER_BEST_EFFORT"))
+	logParserConfig := logparser.NewConfig(
+		bestEffort,
+		time.Duration(envInt("LOG_PARSER_TIMEOUT_SECONDS"))*time.Second,
+		envIntOr("LOG_PARSER_WORKERS", runtime.NumCPU()),
+		envIntOr("IP_INFO_CONCURRENCY", defaultIPInfoConcurrency),
+	)
+
+	var ipAPIClient ipAPIClient = offlineIPAPIClient{}
+	if token := os.Getenv("IP_INFO_API_TOKEN"); token != "" {
+		ipInfoTimeout := defaultIPInfoTimeout
+		if seconds := envInt("IP_INFO_TIMEOUT_SECONDS"); seconds > 0 {
+			ipInfoTimeout = time.Duration(seconds) * time.Second
+		}
+		ipAPIClient = ipapiclient.NewIPAPIClient(ipapiclientconfig.NewIPAPIClientConfig(token, ipInfoTimeout))
+	}
+
+	logParser := logparser.NewService(
+		*logParserConfig,
+		logRepository,
+		csvGenerator,
+		csvRepository,
+		ipAPIClient,
+		privacyService,
+		discardReports{},
+	)
+	var (
+		aliasService   *aliases.Service
+		chatService    *chat.Service
+		recordsService *records.Service
+		roundsService  *rounds.Service
+	)
+	if st.stateDirectory != "" {
+		aliasService = aliases.NewService(*aliases.NewConfig(st.stateDirectory))
+		chatService = chat.NewService(*chat.NewConfig(st.stateDirectory))
+		// Online time needs no A2S client, the graph service is only used offline here
+		recordsService = records.NewService(*records.NewConfig(st.stateDirectory), graph.NewService(nil))
+		roundsService = rounds.NewService(*rounds.NewConfig(st.stateDirectory))
+		// The indexes take in backfilled periods as well; the rollups are rebuilt from the CSV store afterwards
+		logParser = logparser.NewService(
+			*logParserConfig,
+			logRepository,
+			csvGenerator,
+			csvRepository,
+			ipAPIClient,
+			privacyService,
+			parsereport.NewService(*parsereport.NewConfig(st.stateDirectory)),
+			aliasService,
+			chatService,
+			recordsService,
+			roundsService,
+		)
+	}
+
+	csvMaintenanceConfig := csvmaintenance.NewConfig(st.ipRetentionDays, st.eventRetentionDays)
+	csvMaintenance := csvmaintenance.NewService(*csvMaintenanceConfig, csvRepository, nil, csvParser, csvGenerator)
+	if st.archiveDirectory != "" {
+		csvMaintenance = csvmaintenance.NewService(
+			*csvMaintenanceConfig,
+			csvRepository,
+			csvrepository.NewService(*csvrepository.NewConfig(st.archiveDirectory)),
+			csvParser,
+			csvGenerator,
+		)
+	}
+
+	a := &app{
+		logRepository:  logRepository,
+		logParser:      logParser,
+		csvMaintenance: csvMaintenance,
+	}
+	if st.stateDirectory != "" {
+		a.rollups = rollups.NewService(
+			*rollups.NewConfig(st.stateDirectory),
+			graph.NewService(nil),
+			csvRepository,
+			csvParser,
+		)
+		a.generation = generation.NewService(*generation.NewConfig(st.stateDirectory))
+		// Offline there are no cached graphs, they expire with their TTL
+		a.erasure = erasure.NewService(
+			csvMaintenance,
+			aliasService,
+			privacyService,
+			audit.NewService(*audit.NewConfig(st.stateDirectory)),
+			nil,
+			chatService,
+			recordsService,
+			roundsService,
+			parsereport.NewService(*parsereport.NewConfig(st.stateDirectory)),
+			a.rollups,
+			a.generation,
+			aliasService,
+		)
+	}
+	return a
+}
+
+func envOr(name, fallback string) string {
+	if value := os.Getenv(name); value != "" {
+		return value
+	}
+	return fallback
+}
+
+func envIntOr(name string, fallback int) int {
+	if os.Getenv(name) == "" {
+		return fallback
+	}
+	return envInt(name)
+}
+
+func envInt(name string) int {
+	value := os.Getenv(name)
+	if value == "" {
+		return 0
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil || parsed < 0 {
+		log.Fatalf("%s must be a non-negative integer: %q", name, value)
+	}
+	return parsed
+}
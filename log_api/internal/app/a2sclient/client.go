@@ -1,19 +1,71 @@
 package a2sclient
 
 import (
+	"context"
+	"net"
 	"strconv"
 
 	"github.com/dmitriitimoshenko/nmrih/log_api/internal/app/a2sclient/config"
 	"github.com/rumblefrog/go-a2s"
 )
 
-func NewA2SClient(config *config.A2SClientConfig) (*a2s.Client, error) {
-	serverAddress := config.Host + ":" + strconv.Itoa(config.Port)
+// Client queries the game server over A2S. Every query runs on a connection of its own, as a go-a2s client
+// must not run concurrent queries, and gives up once its context is done.
+type Client struct {
+	config *config.A2SClientConfig
+}
+
+func NewA2SClient(config *config.A2SClientConfig) *Client {
+	return &Client{
+		config: config,
+	}
+}
+
+func (c *Client) QueryPlayer(ctx context.Context) (*a2s.PlayerInfo, error) {
+	return query(ctx, c, (*a2s.Client).QueryPlayer)
+}
+
+func (c *Client) QueryInfo(ctx context.Context) (*a2s.ServerInfo, error) {
+	return query(ctx, c, (*a2s.Client).QueryInfo)
+}
 
-	client, err := a2s.NewClient(serverAddress)
+// Ping checks that the server answers info queries.
+func (c *Client) Ping(ctx context.Context) error {
+	_, err := c.QueryInfo(ctx)
+	return err
+}
+
+// query runs fn on a fresh connection. Closing the connection is what interrupts a query once ctx is done.
+func query[T any](ctx context.Context, c *Client, fn func(client *a2s.Client) (T, error)) (T, error) {
+	var zero T
+	if err := ctx.Err(); err != nil {
+		return zero, err
+	}
+
+	var options []func(*a2s.Client) error
+	if c.config.Timeout > 0 {
+		options = append(options, a2s.TimeoutOption(c.config.Timeout))
+	}
+	client, err := a2s.NewClient(net.JoinHostPort(c.config.Host, strconv.Itoa(c.config.Port)), options...)
 	if err != nil {
-		return nil, err
+		return zero, err
+	}
+	defer client.Close()
+
+	type result struct {
+		value T
+		err   error
 	}
+	done := make(chan result, 1)
+	go func() {
+		value, err := fn(client)
+		done <- result{value: value, err: err}
+	}()
 
-	return client, nil
+	select {
+	case r := <-done:
+		return r.value, r.err
+	case <-ctx.Done():
+		return zero, ctx.Err()
+	}
 }
@@ -0,0 +1,152 @@
+package a2sclient_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/app/a2sclient"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/app/a2sclient/config"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/testsupport"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testTimeout = time.Second
+
+//nolint:gochecknoglobals // test fixture
+var (
+	testInfo = testsupport.A2SInfo{
+		Name:       "RU NMRiH",
+		Map:        "nmo_broadway",
+		Folder:     "nmrih",
+		Game:       "No More Room in Hell",
+		MaxPlayers: 8,
+		Version:    "1.13.6",
+	}
+	testPlayers = []testsupport.A2SPlayer{
+		{Name: "Bateman", Score: 42, Duration: 90 * time.Minute},
+		{Name: "Wally", Score: 7, Duration: 5 * time.Minute},
+	}
+)
+
+func newClient(server *testsupport.FakeA2SServer, timeout time.Duration) *a2sclient.Client {
+	return a2sclient.NewA2SClient(config.NewA2SClientConfig(server.Host(), server.Port(), timeout))
+}
+
+func TestClient_Query(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name      string
+		challenge bool
+	}{
+		{
+			name: "success: server answers straight away",
+		},
+		{
+			name:      "success: server asks for a challenge first",
+			challenge: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			server := testsupport.NewFakeA2SServer(t, testsupport.A2SServerConfig{
+				Info:      testInfo,
+				Players:   testPlayers,
+				Challenge: tt.challenge,
+			})
+			client := newClient(server, testTimeout)
+
+			info, err := client.QueryInfo(context.Background())
+			require.NoError(t, err)
+			assert.Equal(t, testInfo.Name, info.Name)
+			assert.Equal(t, testInfo.Map, info.Map)
+			assert.Equal(t, uint8(len(testPlayers)), info.Players)
+			assert.Equal(t, testInfo.MaxPlayers, info.MaxPlayers)
+			assert.Equal(t, testInfo.Version, info.Version)
+
+			players, err := client.QueryPlayer(context.Background())
+			require.NoError(t, err)
+			require.Len(t, players.Players, len(testPlayers))
+			for i, player := range testPlayers {
+				assert.Equal(t, player.Name, players.Players[i].Name)
+				assert.Equal(t, player.Score, players.Players[i].Score)
+				assert.InDelta(t, player.Duration.Seconds(), players.Players[i].Duration, 0.001)
+			}
+
+			require.NoError(t, client.Ping(context.Background()))
+
+			queries := server.Queries()
+			assert.Equal(t, 2, queries.Info)
+			assert.Equal(t, 1, queries.Player)
+			if tt.challenge {
+				assert.Equal(t, 3, queries.Challenges)
+			} else {
+				assert.Zero(t, queries.Challenges)
+			}
+		})
+	}
+}
+
+func TestClient_QueryFollowsServer(t *testing.T) {
+	t.Parallel()
+	server := testsupport.NewFakeA2SServer(t, testsupport.A2SServerConfig{Info: testInfo, Players: testPlayers})
+	client := newClient(server, testTimeout)
+
+	server.SetPlayers(nil)
+	players, err := client.QueryPlayer(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, players.Players)
+
+	changed := testInfo
+	changed.Map = "nmo_chinatown"
+	server.SetInfo(changed)
+	info, err := client.QueryInfo(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "nmo_chinatown", info.Map)
+}
+
+func TestClient_QueryUnanswered(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		timeout time.Duration
+		cancel  time.Duration
+		wantErr error
+	}{
+		{
+			name:    "failure: cancelling the context interrupts the query",
+			timeout: time.Minute,
+			cancel:  50 * time.Millisecond,
+			wantErr: context.Canceled,
+		},
+		{
+			name:    "failure: query times out",
+			timeout: 50 * time.Millisecond,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			server := testsupport.NewFakeA2SServer(t, testsupport.A2SServerConfig{Info: testInfo, Silent: true})
+			client := newClient(server, tt.timeout)
+
+			ctx := context.Background()
+			if tt.cancel > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithCancel(ctx)
+				time.AfterFunc(tt.cancel, cancel)
+			}
+			startedAt := time.Now()
+			err := client.Ping(ctx)
+			require.Error(t, err)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+			}
+			assert.Less(t, time.Since(startedAt), 500*time.Millisecond)
+		})
+	}
+}
@@ -1,13 +1,18 @@
 package config
 
+import "time"
+
 type A2SClientConfig struct {
 	Host string
 	Port int
+	// Timeout bounds each query, zero keeps the go-a2s default
+	Timeout time.Duration
 }
 
-func NewA2SClientConfig(host string, port int) *A2SClientConfig {
+func NewA2SClientConfig(host string, port int, timeout time.Duration) *A2SClientConfig {
 	return &A2SClientConfig{
-		Host: host,
-		Port: port,
+		Host:    host,
+		Port:    port,
+		Timeout: timeout,
 	}
 }
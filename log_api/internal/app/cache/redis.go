@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log/slog"
 	"time"
 
 	"github.com/dmitriitimoshenko/nmrih/log_api/internal/app/cache/config"
@@ -45,7 +46,7 @@ func (r *Redis) GetWithTimeout(
 
 	cached, err := r.Get(timeoutCtx, key)
 	if err != nil {
-		fmt.Println("Error getting from cache:", err)
+		slog.WarnContext(ctx, "Failed to get from cache", "key", key, "error", err)
 		return nil, err
 	}
 	return cached, nil
@@ -73,6 +74,62 @@ func (r *Redis) SetWithTimeout(
 	return r.client.Set(timeoutCtx, key, value, r.ttl).Err()
 }
 
-func (r *Redis) FlushAll(ctx context.Context) error {
-	return r.client.FlushAll(ctx).Err()
+func (r *Redis) Del(ctx context.Context, keys ...string) error {
+	return r.client.Del(ctx, keys...).Err()
+}
+
+// takeTokenScript refills the bucket for the time elapsed since the last take and takes a token if there is one.
+// It returns whether a token was taken, and otherwise how many milliseconds until the next one.
+//
+//nolint:gochecknoglobals // scripts are loaded once
+var takeTokenScript = redis.NewScript(`
+local capacity = tonumber(ARGV[1])
+local refill_ms = tonumber(ARGV[2])
+local now_ms = tonumber(ARGV[3])
+local bucket = redis.call("HMGET", KEYS[1], "tokens", "updated_at")
+local tokens = tonumber(bucket[1]) or capacity
+local updated_at = tonumber(bucket[2]) or now_ms
+tokens = math.min(capacity, tokens + math.max(0, now_ms - updated_at) / refill_ms)
+local taken = 0
+local wait_ms = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	taken = 1
+else
+	wait_ms = math.ceil((1 - tokens) * refill_ms)
+end
+redis.call("HSET", KEYS[1], "tokens", tostring(tokens), "updated_at", now_ms)
+redis.call("PEXPIRE", KEYS[1], math.ceil(capacity * refill_ms))
+return {taken, wait_ms}
+`)
+
+// TakeToken takes a token from the bucket under the key, which holds up to capacity tokens
+// and gets one back every refillInterval. When it is empty, it returns how long until the next token.
+func (r *Redis) TakeToken(
+	ctx context.Context,
+	key string,
+	capacity int,
+	refillInterval time.Duration,
+	now time.Time,
+) (bool, time.Duration, error) {
+	result, err := takeTokenScript.Run(
+		ctx,
+		r.client,
+		[]string{key},
+		capacity,
+		refillInterval.Milliseconds(),
+		now.UnixMilli(),
+	).Int64Slice()
+	if err != nil {
+		return false, 0, fmt.Errorf("failed to take a rate limit token: %w", err)
+	}
+	//nolint:mnd // the script returns a pair
+	if len(result) != 2 {
+		return false, 0, fmt.Errorf("unexpected rate limit script result %v", result)
+	}
+	return result[0] == 1, time.Duration(result[1]) * time.Millisecond, nil
+}
+
+func (r *Redis) Ping(ctx context.Context) error {
+	return r.client.Ping(ctx).Err()
 }
@@ -0,0 +1,10 @@
+package adminhandler
+
+import "context"
+
+type adminService interface {
+	Status(ctx context.Context, actor string) (string, error)
+	Kick(ctx context.Context, actor, player, reason string) (string, error)
+	ChangeLevel(ctx context.Context, actor, mapName string) (string, error)
+	Say(ctx context.Context, actor, message string) (string, error)
+}
@@ -0,0 +1,111 @@
+package adminhandler
+
+import (
+	"crypto/subtle"
+	"errors"
+	"strings"
+
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/app/handlers/response"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/enums"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/services/admin"
+	"github.com/gin-gonic/gin"
+)
+
+const bearerPrefix = "Bearer "
+
+type KickRequest struct {
+	Player string `json:"player" binding:"required"`
+	Reason string `json:"reason"`
+}
+
+type ChangeLevelRequest struct {
+	Map string `json:"map" binding:"required"`
+}
+
+type SayRequest struct {
+	Message string `json:"message" binding:"required"`
+}
+
+// CommandOutput is what the game server answered to an RCON command.
+type CommandOutput struct {
+	Output string `json:"output"`
+}
+
+type Handler struct {
+	adminService adminService
+	apiToken     string
+}
+
+func NewAdminHandler(adminService adminService, apiToken string) *Handler {
+	return &Handler{
+		adminService: adminService,
+		apiToken:     apiToken,
+	}
+}
+
+// Authorize lets through requests bearing the admin API token. Without a configured token the admin API is off.
+func (h *Handler) Authorize(ctx *gin.Context) {
+	if h.apiToken == "" {
+		response.Fail(ctx, enums.ErrorCodes.AdminDisabled(), "admin API is disabled")
+		return
+	}
+
+	token, ok := strings.CutPrefix(ctx.GetHeader("Authorization"), bearerPrefix)
+	if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(h.apiToken)) != 1 {
+		response.Fail(ctx, enums.ErrorCodes.Unauthorized(), "invalid admin token")
+		return
+	}
+
+	ctx.Next()
+}
+
+func (h *Handler) Status(ctx *gin.Context) {
+	output, err := h.adminService.Status(ctx, ctx.ClientIP())
+	h.respond(ctx, output, err)
+}
+
+func (h *Handler) Kick(ctx *gin.Context) {
+	var request KickRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		response.Fail(ctx, enums.ErrorCodes.InvalidRequest(), err.Error())
+		return
+	}
+
+	output, err := h.adminService.Kick(ctx, ctx.ClientIP(), request.Player, request.Reason)
+	h.respond(ctx, output, err)
+}
+
+func (h *Handler) ChangeLevel(ctx *gin.Context) {
+	var request ChangeLevelRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		response.Fail(ctx, enums.ErrorCodes.InvalidRequest(), err.Error())
+		return
+	}
+
+	output, err := h.adminService.ChangeLevel(ctx, ctx.ClientIP(), request.Map)
+	h.respond(ctx, output, err)
+}
+
+func (h *Handler) Say(ctx *gin.Context) {
+	var request SayRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		response.Fail(ctx, enums.ErrorCodes.InvalidRequest(), err.Error())
+		return
+	}
+
+	output, err := h.adminService.Say(ctx, ctx.ClientIP(), request.Message)
+	h.respond(ctx, output, err)
+}
+
+func (h *Handler) respond(ctx *gin.Context, output string, err error) {
+	if err != nil {
+		code := enums.ErrorCodes.UpstreamFailure()
+		if errors.Is(err, admin.ErrInvalidArgument) {
+			code = enums.ErrorCodes.InvalidRequest()
+		}
+		response.Fail(ctx, code, err.Error())
+		return
+	}
+
+	response.OK(ctx, CommandOutput{Output: output})
+}
@@ -0,0 +1,7 @@
+package chathandler
+
+import "github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/dto"
+
+type chatService interface {
+	Search(query dto.ChatQuery) (*dto.ChatPage, error)
+}
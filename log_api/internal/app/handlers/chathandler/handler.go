@@ -0,0 +1,98 @@
+package chathandler
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/app/handlers/response"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/dto"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/enums"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/tools"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultPageSize = 50
+	maxPageSize     = 200
+)
+
+type Handler struct {
+	chatService chatService
+}
+
+func NewChatHandler(chatService chatService) *Handler {
+	return &Handler{
+		chatService: chatService,
+	}
+}
+
+// Chat searches the chat history: GET /chat?q=&nick=&steam_id=&from=&to=&page=&page_size=
+func (h *Handler) Chat(ctx *gin.Context) {
+	query, err := h.getQuery(ctx)
+	if err != nil {
+		response.Fail(ctx, enums.ErrorCodes.InvalidRequest(), err.Error())
+		return
+	}
+
+	page, err := h.chatService.Search(*query)
+	if err != nil {
+		response.Fail(ctx, enums.ErrorCodes.Internal(), err.Error())
+		return
+	}
+
+	response.OK(ctx, page)
+}
+
+func (h *Handler) getQuery(ctx *gin.Context) (*dto.ChatQuery, error) {
+	query := &dto.ChatQuery{
+		Text:     ctx.Query("q"),
+		NickName: ctx.Query("nick"),
+		Page:     1,
+		PageSize: defaultPageSize,
+	}
+
+	if steamIDParam := ctx.Query("steam_id"); steamIDParam != "" {
+		steamID, err := tools.NormalizeSteamID(steamIDParam)
+		if err != nil {
+			return nil, err
+		}
+		query.SteamID = steamID
+	}
+
+	// A plain date as to takes in the whole day
+	for param, bound := range map[string]struct {
+		target    **time.Time
+		parseTime func(value string) (time.Time, error)
+	}{
+		"from": {&query.From, tools.ParseTimeQuery},
+		"to":   {&query.To, tools.ParseTimeQueryEnd},
+	} {
+		value := ctx.Query(param)
+		if value == "" {
+			continue
+		}
+		parsed, err := bound.parseTime(value)
+		if err != nil {
+			return nil, err
+		}
+		*bound.target = &parsed
+	}
+
+	if pageParam := ctx.Query("page"); pageParam != "" {
+		page, err := strconv.Atoi(pageParam)
+		if err != nil || page < 1 {
+			return nil, errors.New("invalid page")
+		}
+		query.Page = page
+	}
+	if pageSizeParam := ctx.Query("page_size"); pageSizeParam != "" {
+		pageSize, err := strconv.Atoi(pageSizeParam)
+		if err != nil || pageSize < 1 || pageSize > maxPageSize {
+			return nil, errors.New("invalid page_size")
+		}
+		query.PageSize = pageSize
+	}
+
+	return query, nil
+}
@@ -0,0 +1,11 @@
+package erasurehandler
+
+import (
+	"context"
+
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/dto"
+)
+
+type erasureService interface {
+	Erase(ctx context.Context, actor string, request dto.ErasureRequest) (*dto.ErasureReport, error)
+}
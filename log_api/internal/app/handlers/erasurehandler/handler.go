@@ -0,0 +1,55 @@
+package erasurehandler
+
+import (
+	"errors"
+
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/app/handlers/response"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/dto"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/enums"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/services/erasure"
+	"github.com/gin-gonic/gin"
+)
+
+type EraseRequest struct {
+	SteamID  string            `json:"steam_id"`
+	NickName string            `json:"nick_name"`
+	Mode     enums.ErasureMode `json:"mode"`
+}
+
+type Handler struct {
+	erasureService erasureService
+}
+
+func NewErasureHandler(erasureService erasureService) *Handler {
+	return &Handler{
+		erasureService: erasureService,
+	}
+}
+
+// Erase removes or pseudonymises a player's stored data. It is meant to sit behind the admin authorization.
+func (h *Handler) Erase(ctx *gin.Context) {
+	var request EraseRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		response.Fail(ctx, enums.ErrorCodes.InvalidRequest(), err.Error())
+		return
+	}
+	if request.Mode == "" {
+		request.Mode = enums.ErasureModes.Delete()
+	}
+
+	report, err := h.erasureService.Erase(ctx, ctx.ClientIP(), dto.ErasureRequest{
+		SteamID:  request.SteamID,
+		NickName: request.NickName,
+		Mode:     request.Mode,
+	})
+	if err != nil {
+		code := enums.ErrorCodes.Internal()
+		if errors.Is(err, erasure.ErrInvalidRequest) {
+			code = enums.ErrorCodes.InvalidRequest()
+		}
+		response.Fail(ctx, code, err.Error())
+		return
+	}
+
+	response.OK(ctx, report)
+}
@@ -0,0 +1,14 @@
+package exporthandler
+
+import (
+	"context"
+	"io"
+
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/dto"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/enums"
+)
+
+type exportService interface {
+	ExportEvents(ctx context.Context, w io.Writer, format enums.ExportFormat, query dto.TimeRange) error
+	ExportSessions(ctx context.Context, w io.Writer, format enums.ExportFormat, query dto.TimeRange) error
+}
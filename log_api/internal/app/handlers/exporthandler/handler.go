@@ -0,0 +1,87 @@
+package exporthandler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/app/handlers/response"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/dto"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/enums"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/tools"
+	"github.com/gin-gonic/gin"
+)
+
+type exportFunc func(ctx context.Context, w io.Writer, format enums.ExportFormat, query dto.TimeRange) error
+
+type Handler struct {
+	exportService exportService
+	serverAddress string
+}
+
+// NewExportHandler serves exports of the single game server the API ingests logs from,
+// which serverAddress ("host:port") identifies in the server filter.
+func NewExportHandler(exportService exportService, serverAddress string) *Handler {
+	return &Handler{
+		exportService: exportService,
+		serverAddress: serverAddress,
+	}
+}
+
+// Events streams the raw connection events: GET /export/events?format=&from=&to=&server=
+func (h *Handler) Events(ctx *gin.Context) {
+	h.export(ctx, "events", h.exportService.ExportEvents)
+}
+
+// Sessions streams the reconstructed play sessions: GET /export/sessions?format=&from=&to=&server=
+func (h *Handler) Sessions(ctx *gin.Context) {
+	h.export(ctx, "sessions", h.exportService.ExportSessions)
+}
+
+func (h *Handler) export(ctx *gin.Context, name string, export exportFunc) {
+	format, query, err := h.getQuery(ctx)
+	if err != nil {
+		response.Fail(ctx, enums.ErrorCodes.InvalidRequest(), err.Error())
+		return
+	}
+
+	ctx.Header("Content-Type", format.ContentType())
+	ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name+"."+format.String()))
+	ctx.Status(http.StatusOK)
+	response.DisableWriteTimeout(ctx)
+
+	if err := export(ctx, ctx.Writer, format, *query); err != nil {
+		// Once rows went out the status line is sent, so the client only sees a truncated body
+		if ctx.Writer.Written() {
+			slog.ErrorContext(ctx, "Export failed mid-stream", "export", name, "error", err)
+			ctx.Abort()
+			return
+		}
+		ctx.Header("Content-Disposition", "")
+		response.Fail(ctx, enums.ErrorCodes.Internal(), err.Error())
+	}
+}
+
+func (h *Handler) getQuery(ctx *gin.Context) (enums.ExportFormat, *dto.TimeRange, error) {
+	format := enums.ExportFormats.CSV()
+	if formatParam := ctx.Query("format"); formatParam != "" {
+		format = enums.ExportFormat(formatParam)
+		if !format.IsValid() {
+			return "", nil, errors.New("invalid format")
+		}
+	}
+
+	if server := ctx.Query("server"); server != "" && server != h.serverAddress {
+		return "", nil, fmt.Errorf("unknown server %q", server)
+	}
+
+	from, to, err := tools.ParseTimeRangeQuery(ctx.Query("from"), ctx.Query("to"))
+	if err != nil {
+		return "", nil, err
+	}
+
+	return format, &dto.TimeRange{From: from, To: to}, nil
+}
@@ -0,0 +1,11 @@
+package healthhandler
+
+import (
+	"context"
+
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/dto"
+)
+
+type healthService interface {
+	Readiness(ctx context.Context) dto.Readiness
+}
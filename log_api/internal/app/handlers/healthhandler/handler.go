@@ -0,0 +1,38 @@
+package healthhandler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type HealthResponse struct {
+	Message string `json:"message"`
+}
+
+type Handler struct {
+	healthService healthService
+}
+
+func NewHealthHandler(healthService healthService) *Handler {
+	return &Handler{
+		healthService: healthService,
+	}
+}
+
+// Livez tells the process is up and serving, without looking at its dependencies.
+func (h *Handler) Livez(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, HealthResponse{Message: "OK"})
+}
+
+// Readyz tells whether the API can serve its data: 200 when ready or degraded, 503 when a critical check failed.
+func (h *Handler) Readyz(ctx *gin.Context) {
+	// The request context, not the pooled gin context, since a timed out check ends on the timer goroutine
+	readiness := h.healthService.Readiness(ctx.Request.Context())
+
+	status := http.StatusOK
+	if !readiness.Status.IsReady() {
+		status = http.StatusServiceUnavailable
+	}
+	ctx.JSON(status, readiness)
+}
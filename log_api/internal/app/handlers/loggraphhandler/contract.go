@@ -2,6 +2,8 @@ package loggraphhandler
 
 import (
 	"context"
+	"io"
+	"iter"
 	"time"
 
 	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/dto"
@@ -13,16 +15,35 @@ type redisCache interface {
 }
 
 type csvRepository interface {
-	GetAllCSVData() ([]byte, error)
+	CSVFiles(ctx context.Context, timeRange dto.TimeRange) iter.Seq2[io.Reader, error]
 }
 
 type csvParser interface {
-	Parse(data []byte) ([]*dto.LogData, error)
+	Entries(readers iter.Seq2[io.Reader, error]) iter.Seq2[*dto.LogData, error]
 }
 
 type graphService interface {
-	TopTimeSpent(logs []*dto.LogData) dto.TopTimeSpentList
-	TopCountries(logs []*dto.LogData) dto.TopCountriesPercentageList
-	PlayersInfo() (*dto.PlayersInfo, error)
-	OnlineStatistics(logs []*dto.LogData) dto.OnlineStatistics
+	TopTimeSpent(logs iter.Seq2[*dto.LogData, error]) (dto.TopTimeSpentList, error)
+	TopCountries(logs iter.Seq2[*dto.LogData, error]) (dto.TopCountriesPercentageList, error)
+	PlayersInfo(ctx context.Context) (*dto.PlayersInfo, error)
+	OnlineStatistics(logs iter.Seq2[*dto.LogData, error], until time.Time) (dto.OnlineStatistics, error)
+	PeakConcurrency(logs iter.Seq2[*dto.LogData, error]) (dto.ConcurrencyPeaks, error)
+	DailyActives(logs iter.Seq2[*dto.LogData, error], until time.Time) (dto.DailyActives, error)
+	TopTimeSpentFromRollups(rollups *dto.Rollups) dto.TopTimeSpentList
+	TopCountriesFromRollups(rollups *dto.Rollups) dto.TopCountriesPercentageList
+	OnlineStatisticsFromRollups(rollups *dto.Rollups, until time.Time) dto.OnlineStatistics
+	DailyActivesFromRollups(rollups *dto.Rollups, until time.Time) dto.DailyActives
+	RoundStatistics(rounds []dto.Round) dto.RoundStatisticsList
+}
+
+type roundsRepository interface {
+	GetRounds() ([]dto.Round, error)
+}
+
+type rollupsRepository interface {
+	Get() (*dto.Rollups, error)
+}
+
+type generationRepository interface {
+	Get() (*dto.Generation, error)
 }
@@ -2,25 +2,45 @@ package loggraphhandler
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"iter"
+	"log/slog"
 	"net/http"
 	"os"
 	"strconv"
 	"time"
 
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/app/handlers/response"
 	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/dto"
 	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/enums"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/tools"
 	"github.com/gin-gonic/gin"
 )
 
+const (
+	jsonContentType = "application/json; charset=utf-8"
+	etagHashLength  = 12
+)
+
+// cacheEntry is the encoded response kept in the cache, along with the data generation it was computed at.
+type cacheEntry struct {
+	Generation uint64          `json:"generation"`
+	Body       json.RawMessage `json:"body"`
+}
+
 type Handler struct {
-	redisCache    redisCache
-	csvRepository csvRepository
-	csvParser     csvParser
-	graphService  graphService
-	defaultTTL    time.Duration
-	cacheTimeout  time.Duration
+	redisCache           redisCache
+	csvRepository        csvRepository
+	csvParser            csvParser
+	graphService         graphService
+	roundsRepository     roundsRepository
+	rollupsRepository    rollupsRepository
+	generationRepository generationRepository
+	defaultTTL           time.Duration
+	cacheTimeout         time.Duration
 }
 
 func NewLogGraphHandler(
@@ -28,112 +48,142 @@ func NewLogGraphHandler(
 	csvRepository csvRepository,
 	csvParser csvParser,
 	graphService graphService,
+	roundsRepository roundsRepository,
+	rollupsRepository rollupsRepository,
+	generationRepository generationRepository,
 ) *Handler {
 	logGraphHandlerCacheTTLMinutes, err := strconv.Atoi(os.Getenv("LOG_GRAPH_HANDLER_CACHE_TTL_MINUTES"))
 	if err != nil || logGraphHandlerCacheTTLMinutes <= 0 {
-		fmt.Println("LOG_GRAPH_HANDLER_CACHE_TTL_MINUTES not set or invalid, using default value of 5: " + err.Error())
+		slog.Warn("LOG_GRAPH_HANDLER_CACHE_TTL_MINUTES not set or invalid, using the default", "default_minutes", 5)
 		logGraphHandlerCacheTTLMinutes = 5
 	}
 	logGraphHandlerCacheTTL := time.Duration(logGraphHandlerCacheTTLMinutes) * time.Minute
 
 	cacheTimeoutSeconds, err := strconv.Atoi(os.Getenv("LOG_GRAPH_HANDLER_CACHE_TIMEOUT_SECONDS"))
 	if err != nil || cacheTimeoutSeconds <= 0 {
-		fmt.Println("LOG_GRAPH_HANDLER_CACHE_TIMEOUT_SECONDS not set or invalid, using default value of 10: " + err.Error())
+		slog.Warn("LOG_GRAPH_HANDLER_CACHE_TIMEOUT_SECONDS not set or invalid, using the default", "default_seconds", 10)
 		cacheTimeoutSeconds = 10
 	}
 	cacheTimeout := time.Duration(cacheTimeoutSeconds) * time.Second
 
 	return &Handler{
-		redisCache:    redisCache,
-		csvRepository: csvRepository,
-		csvParser:     csvParser,
-		graphService:  graphService,
-		defaultTTL:    logGraphHandlerCacheTTL,
-		cacheTimeout:  cacheTimeout,
+		redisCache:           redisCache,
+		csvRepository:        csvRepository,
+		csvParser:            csvParser,
+		graphService:         graphService,
+		roundsRepository:     roundsRepository,
+		rollupsRepository:    rollupsRepository,
+		generationRepository: generationRepository,
+		defaultTTL:           logGraphHandlerCacheTTL,
+		cacheTimeout:         cacheTimeout,
 	}
 }
 
 func (h *Handler) Graph(ctx *gin.Context) {
 	graphTypeParam, ok := ctx.GetQuery("type")
 	if !ok {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid graph type"})
-		ctx.Abort()
+		response.Fail(ctx, enums.ErrorCodes.InvalidRequest(), "invalid graph type")
 		return
 	}
 	graphType := enums.GraphType(graphTypeParam)
 	if !graphType.IsValid() {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid graph type"})
-		ctx.Abort()
+		response.Fail(ctx, enums.ErrorCodes.InvalidRequest(), "invalid graph type")
 		return
 	}
-	data, err := h.csvRepository.GetAllCSVData()
+	from, to, err := tools.ParseTimeRangeQuery(ctx.Query("from"), ctx.Query("to"))
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		ctx.Abort()
+		response.Fail(ctx, enums.ErrorCodes.InvalidRequest(), err.Error())
 		return
 	}
-	logs, err := h.csvParser.Parse(data)
-	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		ctx.Abort()
-		return
+	timeRange := dto.TimeRange{From: from, To: to}
+
+	// Graphs of the stored logs only change with the data generation,
+	// so clients holding the current one are answered before the cache or the CSV store are read
+	var (
+		generation   *dto.Generation
+		etag         string
+		lastModified time.Time
+	)
+	if !graphType.IsLive() {
+		if generation, err = h.generationRepository.Get(); err != nil {
+			response.Fail(ctx, enums.ErrorCodes.Internal(), err.Error())
+			return
+		}
+		etag, lastModified = storedGraphETag(generation, graphType, timeRange), generation.UpdatedAt
+		if response.NotModified(ctx, graphType.MaxAge(), etag, lastModified) {
+			return
+		}
 	}
 
-	cached, err := h.getCacheIfApplicable(ctx, graphType)
+	body, err := h.getBody(ctx, graphType, timeRange, generation)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		ctx.Abort()
+		response.Fail(ctx, enums.ErrorCodes.Internal(), err.Error())
 		return
 	}
-	if cached != nil {
-		var response gin.H
-		if err := json.Unmarshal([]byte(*cached), &response); err != nil {
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			ctx.Abort()
+
+	// Live graphs have no generation, the content itself is their validator
+	if graphType.IsLive() {
+		etag = contentETag(body)
+		if response.NotModified(ctx, graphType.MaxAge(), etag, lastModified) {
 			return
 		}
-		ctx.JSON(http.StatusOK, response)
-		return
 	}
 
-	response, ok := h.getResponseByGraphType(graphType, logs)
-	if !ok {
-		ctx.JSON(http.StatusInternalServerError, response)
-		ctx.Abort()
-		return
+	response.Cacheable(ctx, graphType.MaxAge(), etag, lastModified)
+	ctx.Data(http.StatusOK, jsonContentType, body)
+}
+
+// getBody returns the encoded response, from the cache when it holds the graph of the given generation.
+func (h *Handler) getBody(
+	ctx context.Context,
+	graphType enums.GraphType,
+	timeRange dto.TimeRange,
+	generation *dto.Generation,
+) ([]byte, error) {
+	if canCache(graphType, timeRange) {
+		cached, err := h.redisCache.GetWithTimeout(ctx, graphType.CacheKey(), h.cacheTimeout)
+		if err != nil {
+			return nil, err
+		}
+		if cached != nil {
+			var entry cacheEntry
+			// Entries of another generation or format are recomputed
+			if err := json.Unmarshal([]byte(*cached), &entry); err == nil &&
+				entry.Body != nil && entry.Generation == generation.Number {
+				return entry.Body, nil
+			}
+		}
 	}
 
-	if err := h.saveCacheIfApplicable(ctx, graphType, response); err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		ctx.Abort()
-		return
+	data, err := h.getDataByGraphType(ctx, graphType, timeRange)
+	if err != nil {
+		return nil, err
 	}
 
-	ctx.JSON(http.StatusOK, response)
-}
+	body, err := json.Marshal(response.Envelope[any]{Data: data})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal response: %w", err)
+	}
 
-func (h *Handler) getCacheIfApplicable(ctx context.Context, graphType enums.GraphType) (*string, error) {
-	if !graphType.CanCache() {
-		return nil, nil
+	if canCache(graphType, timeRange) {
+		if err := h.saveCache(ctx, graphType, cacheEntry{Generation: generation.Number, Body: body}); err != nil {
+			return nil, err
+		}
 	}
 
-	return h.redisCache.GetWithTimeout(ctx, "graph_data:"+graphType.String(), h.cacheTimeout)
+	return body, nil
 }
 
-func (h *Handler) saveCacheIfApplicable(ctx context.Context, graphType enums.GraphType, response gin.H) error {
-	if !graphType.CanCache() {
-		return nil
-	}
-
-	responseJSONBytes, err := json.Marshal(response)
+func (h *Handler) saveCache(ctx context.Context, graphType enums.GraphType, entry cacheEntry) error {
+	content, err := json.Marshal(entry)
 	if err != nil {
-		return fmt.Errorf("failed to marshal response: %w", err)
+		return fmt.Errorf("failed to marshal cached graph data: %w", err)
 	}
 
 	if err := h.redisCache.SetWithTimeout(
 		ctx,
-		"graph_data:"+graphType.String(),
-		string(responseJSONBytes),
+		graphType.CacheKey(),
+		string(content),
 		&h.defaultTTL,
 		h.cacheTimeout,
 	); err != nil {
@@ -143,31 +193,103 @@ func (h *Handler) saveCacheIfApplicable(ctx context.Context, graphType enums.Gra
 	return nil
 }
 
-func (h *Handler) getResponseByGraphType(graphType enums.GraphType, logs []*dto.LogData) (gin.H, bool) {
+// storedGraphETag identifies the graph of the time range at the generation. Graphs running until now
+// also move on with the day, and the ETag is weak as equal graphs may list ties in another order.
+func storedGraphETag(generation *dto.Generation, graphType enums.GraphType, timeRange dto.TimeRange) string {
+	until := time.Now().Format(time.DateOnly)
+	if timeRange.To != nil {
+		until = timeRange.To.Format(time.RFC3339Nano)
+	}
+	from := ""
+	if timeRange.From != nil {
+		from = timeRange.From.Format(time.RFC3339Nano)
+	}
+
+	hash := sha256.Sum256([]byte(graphType.String() + "|" + from + "|" + until))
+	return fmt.Sprintf(`W/"%d-%s"`, generation.Number, hex.EncodeToString(hash[:etagHashLength]))
+}
+
+func contentETag(body []byte) string {
+	hash := sha256.Sum256(body)
+	return fmt.Sprintf(`"%s"`, hex.EncodeToString(hash[:etagHashLength]))
+}
+
+// canCache leaves out the time range graphs, as the cache is only invalidated for the whole history ones.
+func canCache(graphType enums.GraphType, timeRange dto.TimeRange) bool {
+	return graphType.CanCache() && timeRange.From == nil && timeRange.To == nil
+}
+
+// entries streams the stored log entries of the time range, reading only the CSV files that can hold them.
+func (h *Handler) entries(ctx context.Context, timeRange dto.TimeRange) iter.Seq2[*dto.LogData, error] {
+	return timeRange.Filter(h.csvParser.Entries(h.csvRepository.CSVFiles(ctx, timeRange)))
+}
+
+// rollups returns the rollups when they can answer the graph of the time range, which is the whole history only.
+// Nil means the graph has to be computed from the stored entries, or does not need either.
+func (h *Handler) rollups(graphType enums.GraphType, timeRange dto.TimeRange) (*dto.Rollups, error) {
+	if !graphType.HasRollups() || timeRange.From != nil || timeRange.To != nil {
+		return nil, nil //nolint:nilnil // no rollups for the graph or a time range
+	}
+	return h.rollupsRepository.Get()
+}
+
+// getDataByGraphType computes the graph, which is one of the types listed in the GraphData schema of the API spec.
+//
+//nolint:cyclop // one case per graph type
+func (h *Handler) getDataByGraphType(
+	ctx context.Context,
+	graphType enums.GraphType,
+	timeRange dto.TimeRange,
+) (any, error) {
+	rollups, err := h.rollups(graphType, timeRange)
+	if err != nil {
+		return nil, err
+	}
+	until := time.Now()
+	if timeRange.To != nil {
+		until = *timeRange.To
+	}
+
+	var data any
 	switch graphType {
 	case enums.GraphTypes.TopTimeSpentGraphType():
-		{
-			return gin.H{"data": h.graphService.TopTimeSpent(logs)}, true
+		if rollups != nil {
+			data = h.graphService.TopTimeSpentFromRollups(rollups)
+			break
 		}
+		data, err = h.graphService.TopTimeSpent(h.entries(ctx, timeRange))
 	case enums.GraphTypes.TopCountriesGraphType():
-		{
-			return gin.H{"data": h.graphService.TopCountries(logs)}, true
+		if rollups != nil {
+			data = h.graphService.TopCountriesFromRollups(rollups)
+			break
 		}
+		data, err = h.graphService.TopCountries(h.entries(ctx, timeRange))
 	case enums.GraphTypes.PlayersInfoGraphType():
-		{
-			result, err := h.graphService.PlayersInfo()
-			if err != nil {
-				return gin.H{"error": err.Error()}, false
-			}
-			return gin.H{"data": result}, true
-		}
+		data, err = h.graphService.PlayersInfo(ctx)
 	case enums.GraphTypes.OnlineStatisticsGraphType():
-		{
-			return gin.H{"data": h.graphService.OnlineStatistics(logs)}, true
+		if rollups != nil {
+			data = h.graphService.OnlineStatisticsFromRollups(rollups, until)
+			break
 		}
-	default:
-		{
-			return gin.H{"data": "none"}, true
+		data, err = h.graphService.OnlineStatistics(h.entries(ctx, timeRange), until)
+	case enums.GraphTypes.PeakConcurrencyGraphType():
+		data, err = h.graphService.PeakConcurrency(h.entries(ctx, timeRange))
+	case enums.GraphTypes.RoundsGraphType():
+		var rounds []dto.Round
+		if rounds, err = h.roundsRepository.GetRounds(); err == nil {
+			data = h.graphService.RoundStatistics(rounds)
+		}
+	case enums.GraphTypes.DailyActivesGraphType():
+		if rollups != nil {
+			data = h.graphService.DailyActivesFromRollups(rollups, until)
+			break
 		}
+		data, err = h.graphService.DailyActives(h.entries(ctx, timeRange), until)
+	default:
+		err = fmt.Errorf("unsupported graph type %q", graphType)
+	}
+	if err != nil {
+		return nil, err
 	}
+	return data, nil
 }
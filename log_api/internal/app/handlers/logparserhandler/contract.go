@@ -3,12 +3,18 @@ package logparserhandler
 import (
 	"context"
 	"time"
+
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/dto"
 )
 
 type redisCache interface {
-	FlushAll(ctx context.Context) error
+	Del(ctx context.Context, keys ...string) error
 }
 
 type service interface {
-	Parse(requestTimeStamp time.Time) error
+	Parse(ctx context.Context, requestTimeStamp time.Time) (*dto.ParseReport, error)
+}
+
+type reportRepository interface {
+	GetLast() (*dto.ParseReport, error)
 }
@@ -1,24 +1,44 @@
 package logparserhandler
 
 import (
+	"errors"
 	"net/http"
 	"time"
 
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/app/handlers/response"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/dto"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/enums"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/services/logparser"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/services/parsereport"
 	"github.com/gin-gonic/gin"
 )
 
+type ParseResponse struct {
+	Message string           `json:"message"`
+	Data    *dto.ParseReport `json:"data"`
+}
+
+// ParseFailure carries the report of the failed run next to the error, as far as the parse got.
+type ParseFailure struct {
+	response.ErrorEnvelope
+	Data *dto.ParseReport `json:"data,omitempty"`
+}
+
 type Handler struct {
-	redisCache redisCache
-	service    service
+	redisCache       redisCache
+	service          service
+	reportRepository reportRepository
 }
 
 func NewLogParserHandler(
 	redisCache redisCache,
 	service service,
+	reportRepository reportRepository,
 ) *Handler {
 	return &Handler{
-		redisCache: redisCache,
-		service:    service,
+		redisCache:       redisCache,
+		service:          service,
+		reportRepository: reportRepository,
 	}
 }
 
@@ -27,22 +47,51 @@ func NewLogParserHandler(
  *   1. get data from *.log files in "../logs/" directory
  *   2. parse into array of LogData type
  *   3. convert into csv files and save them in "../data/" files
- *   4. flush redis cache
+ *   4. drop the cached graphs, leaving the rate limit buckets in the same store alone
+ *   5. respond with the parse report (diagnostics, counts, unrecognised lines)
  */
 func (h *Handler) Parse(ctx *gin.Context) {
 	requestTimeStamp := time.Now()
+	response.DisableWriteTimeout(ctx)
 
-	if err := h.service.Parse(requestTimeStamp); err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	report, err := h.service.Parse(ctx, requestTimeStamp)
+	if errors.Is(err, logparser.ErrShuttingDown) {
+		response.Fail(ctx, enums.ErrorCodes.Unavailable(), err.Error())
+		return
+	}
+	if err != nil {
+		code := enums.ErrorCodes.Internal()
+		ctx.JSON(code.Status(), ParseFailure{
+			ErrorEnvelope: response.ErrorEnvelope{Error: err.Error(), Code: code},
+			Data:          report,
+		})
 		ctx.Abort()
 		return
 	}
 
-	if err := h.redisCache.FlushAll(ctx); err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		ctx.Abort()
+	keys := make([]string, 0, len(enums.GraphTypes.All()))
+	for _, graphType := range enums.GraphTypes.All() {
+		keys = append(keys, graphType.CacheKey())
+	}
+	if err := h.redisCache.Del(ctx, keys...); err != nil {
+		response.Fail(ctx, enums.ErrorCodes.Internal(), err.Error())
+		return
+	}
+
+	ctx.JSON(http.StatusOK, ParseResponse{Message: "Logs have been parsed successfully", Data: report})
+}
+
+// Report returns the report of the last parse run.
+func (h *Handler) Report(ctx *gin.Context) {
+	report, err := h.reportRepository.GetLast()
+	if err != nil {
+		code := enums.ErrorCodes.Internal()
+		if errors.Is(err, parsereport.ErrNoReport) {
+			code = enums.ErrorCodes.NotFound()
+		}
+		response.Fail(ctx, code, err.Error())
 		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{"message": "Logs have been parsed successfully"})
+	response.OK(ctx, report)
 }
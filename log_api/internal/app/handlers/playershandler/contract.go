@@ -0,0 +1,8 @@
+package playershandler
+
+import "github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/dto"
+
+type aliasService interface {
+	GetAliases(steamID string) (*dto.PlayerAliases, error)
+	Search(nickName string, limit int) ([]*dto.PlayerAliases, error)
+}
@@ -0,0 +1,78 @@
+package playershandler
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/app/handlers/response"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/enums"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/services/aliases"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/tools"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	minSearchLength    = 2
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+)
+
+type Handler struct {
+	aliasService aliasService
+}
+
+func NewPlayersHandler(aliasService aliasService) *Handler {
+	return &Handler{
+		aliasService: aliasService,
+	}
+}
+
+// Aliases returns every nickname used by a SteamID, accepted in SteamID2, SteamID3 or SteamID64 notation.
+func (h *Handler) Aliases(ctx *gin.Context) {
+	steamID, err := tools.NormalizeSteamID(ctx.Param("id"))
+	if err != nil {
+		response.Fail(ctx, enums.ErrorCodes.InvalidRequest(), err.Error())
+		return
+	}
+
+	playerAliases, err := h.aliasService.GetAliases(steamID)
+	if err != nil {
+		code := enums.ErrorCodes.Internal()
+		if errors.Is(err, aliases.ErrPlayerNotFound) {
+			code = enums.ErrorCodes.NotFound()
+		}
+		response.Fail(ctx, code, err.Error())
+		return
+	}
+
+	response.OK(ctx, playerAliases)
+}
+
+// Search resolves a partial nickname to candidate players.
+func (h *Handler) Search(ctx *gin.Context) {
+	nickName := strings.TrimSpace(ctx.Query("nick"))
+	if utf8.RuneCountInString(nickName) < minSearchLength {
+		response.Fail(ctx, enums.ErrorCodes.InvalidRequest(), "nick must be at least 2 characters long")
+		return
+	}
+
+	limit := defaultSearchLimit
+	if limitParam, ok := ctx.GetQuery("limit"); ok {
+		parsedLimit, err := strconv.Atoi(limitParam)
+		if err != nil || parsedLimit <= 0 || parsedLimit > maxSearchLimit {
+			response.Fail(ctx, enums.ErrorCodes.InvalidRequest(), "invalid limit")
+			return
+		}
+		limit = parsedLimit
+	}
+
+	players, err := h.aliasService.Search(nickName, limit)
+	if err != nil {
+		response.Fail(ctx, enums.ErrorCodes.Internal(), err.Error())
+		return
+	}
+
+	response.OK(ctx, players)
+}
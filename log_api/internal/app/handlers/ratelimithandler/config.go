@@ -0,0 +1,18 @@
+package ratelimithandler
+
+import "github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/dto"
+
+type config struct {
+	Public      dto.RateLimitPolicy
+	PlayersInfo dto.RateLimitPolicy
+	Admin       dto.RateLimitPolicy
+}
+
+//nolint:revive // no sense in export here
+func NewConfig(public, playersInfo, admin dto.RateLimitPolicy) *config {
+	return &config{
+		Public:      public,
+		PlayersInfo: playersInfo,
+		Admin:       admin,
+	}
+}
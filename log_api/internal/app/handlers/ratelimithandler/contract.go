@@ -0,0 +1,12 @@
+package ratelimithandler
+
+import (
+	"context"
+	"time"
+
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/dto"
+)
+
+type rateLimiter interface {
+	Allow(ctx context.Context, policy dto.RateLimitPolicy, clientIP string, now time.Time) (bool, time.Duration)
+}
@@ -0,0 +1,54 @@
+package ratelimithandler
+
+import (
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/app/handlers/response"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/dto"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/enums"
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	config      config
+	rateLimiter rateLimiter
+}
+
+// NewRateLimitHandler limits the route groups by client IP, which only trusted proxies may set with X-Forwarded-For.
+func NewRateLimitHandler(config config, rateLimiter rateLimiter) *Handler {
+	return &Handler{
+		config:      config,
+		rateLimiter: rateLimiter,
+	}
+}
+
+// Public limits the public API.
+func (h *Handler) Public(ctx *gin.Context) {
+	h.limit(ctx, h.config.Public)
+}
+
+// PlayersInfo additionally limits the players-info graph, as each uncached one queries the game server over UDP.
+func (h *Handler) PlayersInfo(ctx *gin.Context) {
+	if enums.GraphType(ctx.Query("type")) != enums.GraphTypes.PlayersInfoGraphType() {
+		ctx.Next()
+		return
+	}
+	h.limit(ctx, h.config.PlayersInfo)
+}
+
+// Admin limits the admin and export API. It comes before the authorization, to slow down token guessing.
+func (h *Handler) Admin(ctx *gin.Context) {
+	h.limit(ctx, h.config.Admin)
+}
+
+func (h *Handler) limit(ctx *gin.Context, policy dto.RateLimitPolicy) {
+	allowed, retryAfter := h.rateLimiter.Allow(ctx, policy, ctx.ClientIP(), time.Now())
+	if !allowed {
+		ctx.Header("Retry-After", strconv.Itoa(max(1, int(math.Ceil(retryAfter.Seconds())))))
+		response.Fail(ctx, enums.ErrorCodes.RateLimited(), "rate limit exceeded")
+		return
+	}
+	ctx.Next()
+}
@@ -0,0 +1,7 @@
+package recordshandler
+
+import "github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/dto"
+
+type recordsService interface {
+	Get() (*dto.ServerRecords, error)
+}
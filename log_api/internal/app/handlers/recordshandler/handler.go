@@ -0,0 +1,27 @@
+package recordshandler
+
+import (
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/app/handlers/response"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/enums"
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	recordsService recordsService
+}
+
+func NewRecordsHandler(recordsService recordsService) *Handler {
+	return &Handler{
+		recordsService: recordsService,
+	}
+}
+
+func (h *Handler) Records(ctx *gin.Context) {
+	records, err := h.recordsService.Get()
+	if err != nil {
+		response.Fail(ctx, enums.ErrorCodes.Internal(), err.Error())
+		return
+	}
+
+	response.OK(ctx, records)
+}
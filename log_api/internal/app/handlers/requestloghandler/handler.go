@@ -0,0 +1,80 @@
+package requestloghandler
+
+import (
+	"log/slog"
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/app/handlers/response"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/app/logging"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/enums"
+	"github.com/gin-gonic/gin"
+)
+
+const requestIDHeader = "X-Request-ID"
+
+//nolint:gochecknoglobals // compiled once
+var (
+	// requestIDRegex keeps client supplied IDs short and safe to log
+	requestIDRegex = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+	// probePaths are polled by docker and logged at debug level only
+	probePaths = map[string]struct{}{
+		"/health-check": {},
+		"/livez":        {},
+		"/readyz":       {},
+	}
+)
+
+type Handler struct{}
+
+func NewRequestLogHandler() *Handler {
+	return &Handler{}
+}
+
+// Log gives the request an ID, taken from X-Request-ID when the client sent a valid one,
+// puts it in the request context for the services to log with, and logs the request once it is served.
+func (h *Handler) Log(ctx *gin.Context) {
+	startedAt := time.Now()
+
+	requestID := ctx.GetHeader(requestIDHeader)
+	if !requestIDRegex.MatchString(requestID) {
+		requestID = logging.NewID()
+	}
+	ctx.Header(requestIDHeader, requestID)
+	ctx.Request = ctx.Request.WithContext(logging.WithRequestID(ctx.Request.Context(), requestID))
+
+	ctx.Next()
+
+	status := ctx.Writer.Status()
+	level := slog.LevelInfo
+	switch {
+	case status >= http.StatusInternalServerError:
+		level = slog.LevelError
+	case status >= http.StatusBadRequest:
+		level = slog.LevelWarn
+	}
+	if _, ok := probePaths[ctx.Request.URL.Path]; ok && level == slog.LevelInfo {
+		level = slog.LevelDebug
+	}
+
+	attrs := []slog.Attr{
+		slog.String("method", ctx.Request.Method),
+		slog.String("path", ctx.Request.URL.Path),
+		slog.Int("status", status),
+		slog.Int("bytes", ctx.Writer.Size()),
+		slog.Int64("duration_ms", time.Since(startedAt).Milliseconds()),
+		slog.String("client_ip", ctx.ClientIP()),
+	}
+	if errorMessage := ctx.Errors.ByType(gin.ErrorTypePrivate).String(); errorMessage != "" {
+		attrs = append(attrs, slog.String("error", errorMessage))
+	}
+	slog.LogAttrs(ctx.Request.Context(), level, "Request served", attrs...)
+}
+
+// Recover answers a panicking request with an internal error and logs the panic with the request ID.
+// It is meant for gin.CustomRecoveryWithWriter, behind Log.
+func (h *Handler) Recover(ctx *gin.Context, recovered any) {
+	slog.ErrorContext(ctx.Request.Context(), "Request panicked", "panic", recovered)
+	response.Fail(ctx, enums.ErrorCodes.Internal(), http.StatusText(http.StatusInternalServerError))
+}
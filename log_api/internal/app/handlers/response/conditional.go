@@ -0,0 +1,61 @@
+package response
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// NotModified answers 304 with the caching headers and aborts when the client already holds the representation.
+// Otherwise it leaves the response untouched, so only the one that succeeds gets them, through Cacheable.
+// A zero lastModified is left out. As in RFC 9110, If-Modified-Since only counts when the request has no If-None-Match.
+func NotModified(ctx *gin.Context, maxAge time.Duration, etag string, lastModified time.Time) bool {
+	if !isFresh(ctx.Request, etag, lastModified) {
+		return false
+	}
+	Cacheable(ctx, maxAge, etag, lastModified)
+	ctx.Status(http.StatusNotModified)
+	ctx.Abort()
+	return true
+}
+
+// Cacheable lets clients reuse the response for maxAge and revalidate it with the given validators.
+// A zero lastModified is left out.
+func Cacheable(ctx *gin.Context, maxAge time.Duration, etag string, lastModified time.Time) {
+	ctx.Header("Cache-Control", fmt.Sprintf("public, max-age=%d", int(maxAge.Seconds())))
+	ctx.Header("ETag", etag)
+	if !lastModified.IsZero() {
+		ctx.Header("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+	}
+}
+
+func isFresh(request *http.Request, etag string, lastModified time.Time) bool {
+	if ifNoneMatch := request.Header.Get("If-None-Match"); ifNoneMatch != "" {
+		for _, candidate := range strings.Split(ifNoneMatch, ",") {
+			candidate = strings.TrimSpace(candidate)
+			if candidate == "*" || weakETag(candidate) == weakETag(etag) {
+				return true
+			}
+		}
+		return false
+	}
+
+	ifModifiedSince := request.Header.Get("If-Modified-Since")
+	if ifModifiedSince == "" || lastModified.IsZero() {
+		return false
+	}
+	since, err := http.ParseTime(ifModifiedSince)
+	if err != nil {
+		return false
+	}
+	// The header has a one second resolution
+	return !lastModified.Truncate(time.Second).After(since)
+}
+
+// weakETag strips the weakness indicator, as GET requests compare entity tags weakly.
+func weakETag(etag string) string {
+	return strings.TrimPrefix(etag, "W/")
+}
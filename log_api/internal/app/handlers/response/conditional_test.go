@@ -0,0 +1,103 @@
+package response_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/app/handlers/response"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNotModified(t *testing.T) {
+	t.Parallel()
+	lastModified := time.Date(2025, 3, 15, 12, 0, 0, 500, time.UTC)
+	tests := []struct {
+		name         string
+		headers      map[string]string
+		lastModified time.Time
+		want         bool
+	}{
+		{
+			name:         "success: no validators",
+			lastModified: lastModified,
+			want:         false,
+		},
+		{
+			name:         "success: matching etag",
+			headers:      map[string]string{"If-None-Match": `"other", W/"1-abc"`},
+			lastModified: lastModified,
+			want:         true,
+		},
+		{
+			name:         "success: strong etag matches weakly",
+			headers:      map[string]string{"If-None-Match": `"1-abc"`},
+			lastModified: lastModified,
+			want:         true,
+		},
+		{
+			name:         "success: wildcard",
+			headers:      map[string]string{"If-None-Match": "*"},
+			lastModified: lastModified,
+			want:         true,
+		},
+		{
+			name: "success: other etag wins over a fresh date",
+			headers: map[string]string{
+				"If-None-Match":     `W/"2-abc"`,
+				"If-Modified-Since": lastModified.Format(http.TimeFormat),
+			},
+			lastModified: lastModified,
+			want:         false,
+		},
+		{
+			name:         "success: unchanged since",
+			headers:      map[string]string{"If-Modified-Since": lastModified.Format(http.TimeFormat)},
+			lastModified: lastModified,
+			want:         true,
+		},
+		{
+			name:         "success: modified since",
+			headers:      map[string]string{"If-Modified-Since": lastModified.Add(-time.Second).Format(http.TimeFormat)},
+			lastModified: lastModified,
+			want:         false,
+		},
+		{
+			name:    "success: date without last modified",
+			headers: map[string]string{"If-Modified-Since": lastModified.Format(http.TimeFormat)},
+			want:    false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			recorder := httptest.NewRecorder()
+			ctx, _ := gin.CreateTestContext(recorder)
+			ctx.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+			for name, value := range tt.headers {
+				ctx.Request.Header.Set(name, value)
+			}
+
+			notModified := response.NotModified(ctx, time.Minute, `W/"1-abc"`, tt.lastModified)
+			ctx.Writer.WriteHeaderNow()
+
+			assert.Equal(t, tt.want, notModified)
+			if !tt.want {
+				// The response may still fail, it gets its caching headers once it succeeds
+				assert.Empty(t, recorder.Header().Get("Cache-Control"))
+				assert.Empty(t, recorder.Header().Get("ETag"))
+				assert.Empty(t, recorder.Header().Get("Last-Modified"))
+				return
+			}
+			assert.Equal(t, http.StatusNotModified, recorder.Code)
+			assert.Equal(t, "public, max-age=60", recorder.Header().Get("Cache-Control"))
+			assert.Equal(t, `W/"1-abc"`, recorder.Header().Get("ETag"))
+			if !tt.lastModified.IsZero() {
+				assert.Equal(t, tt.lastModified.Format(http.TimeFormat), recorder.Header().Get("Last-Modified"))
+			}
+		})
+	}
+}
@@ -0,0 +1,18 @@
+package response
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DisableWriteTimeout lifts the server write timeout for responses outliving it: streams, exports and parses.
+func DisableWriteTimeout(ctx *gin.Context) {
+	err := http.NewResponseController(ctx.Writer).SetWriteDeadline(time.Time{})
+	if err != nil && !errors.Is(err, http.ErrNotSupported) {
+		slog.WarnContext(ctx, "Failed to lift the write deadline", "error", err)
+	}
+}
@@ -0,0 +1,30 @@
+package response
+
+import (
+	"net/http"
+
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/enums"
+	"github.com/gin-gonic/gin"
+)
+
+// Envelope wraps the payload of every successful JSON response.
+type Envelope[T any] struct {
+	Data T `json:"data"`
+}
+
+// ErrorEnvelope is the body of every failed JSON response.
+type ErrorEnvelope struct {
+	Error string          `json:"error"`
+	Code  enums.ErrorCode `json:"code"`
+}
+
+// OK responds with the data wrapped in the envelope.
+func OK[T any](ctx *gin.Context, data T) {
+	ctx.JSON(http.StatusOK, Envelope[T]{Data: data})
+}
+
+// Fail aborts the request with the error envelope, answered with the status of the error code.
+func Fail(ctx *gin.Context, code enums.ErrorCode, message string) {
+	ctx.JSON(code.Status(), ErrorEnvelope{Error: message, Code: code})
+	ctx.Abort()
+}
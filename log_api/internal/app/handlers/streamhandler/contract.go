@@ -0,0 +1,7 @@
+package streamhandler
+
+import "github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/dto"
+
+type streamService interface {
+	Subscribe() (<-chan dto.StreamEvent, func())
+}
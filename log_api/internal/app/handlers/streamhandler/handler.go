@@ -0,0 +1,57 @@
+package streamhandler
+
+import (
+	"io"
+	"time"
+
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/app/handlers/response"
+	"github.com/gin-gonic/gin"
+)
+
+// heartbeatInterval keeps idle connections from being closed by proxies.
+const heartbeatInterval = 15 * time.Second
+
+type Handler struct {
+	streamService streamService
+}
+
+func NewStreamHandler(streamService streamService) *Handler {
+	return &Handler{
+		streamService: streamService,
+	}
+}
+
+// Stream pushes live server activity as Server-Sent Events until the client goes away.
+// Every event is named after its type: join, leave, kill, map-change or players.
+func (h *Handler) Stream(ctx *gin.Context) {
+	response.DisableWriteTimeout(ctx)
+
+	events, unsubscribe := h.streamService.Subscribe()
+	defer unsubscribe()
+
+	ctx.Header("Content-Type", "text/event-stream")
+	ctx.Header("Cache-Control", "no-cache")
+	ctx.Header("Connection", "keep-alive")
+	ctx.Header("X-Accel-Buffering", "no")
+	ctx.Writer.WriteHeaderNow()
+	ctx.Writer.Flush()
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+
+	ctx.Stream(func(w io.Writer) bool {
+		select {
+		case <-ctx.Request.Context().Done():
+			return false
+		case event, opened := <-events:
+			if !opened {
+				return false
+			}
+			ctx.SSEvent(event.Type.String(), event)
+			return true
+		case <-heartbeat.C:
+			_, err := io.WriteString(w, ": heartbeat\n\n")
+			return err == nil
+		}
+	})
+}
@@ -4,16 +4,13 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
-	"time"
 
 	"github.com/dmitriitimoshenko/nmrih/log_api/internal/app/ipapiclient/config"
 	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/dto"
 )
 
-const timeoutSeconds = 3
-
 type IPAPIClient struct {
 	config *config.IPAPIClientConfig
 }
@@ -24,9 +21,12 @@ func NewIPAPIClient(config *config.IPAPIClientConfig) *IPAPIClient {
 	}
 }
 
-func (c *IPAPIClient) GetCountryByIP(ip string) (*dto.IPInfo, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), timeoutSeconds*time.Second)
-	defer cancel()
+func (c *IPAPIClient) GetCountryByIP(ctx context.Context, ip string) (*dto.IPInfo, error) {
+	if c.config.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.config.Timeout)
+		defer cancel()
+	}
 
 	url := c.getURL(ip)
 
@@ -41,10 +41,8 @@ func (c *IPAPIClient) GetCountryByIP(ip string) (*dto.IPInfo, error) {
 	defer resp.Body.Close()
 
 	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
-		log.Printf(
-			"[IPClient][GetCountryByIP] Failed to get country for IP [%s] with response code [%s]\n",
-			ip, resp.Status,
-		)
+		// The address is left out, the privacy mode may not keep it
+		slog.WarnContext(ctx, "IP info lookup failed", "status", resp.Status)
 	}
 
 	var info *dto.IPInfo
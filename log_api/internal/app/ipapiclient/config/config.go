@@ -1,11 +1,16 @@
 package config
 
+import "time"
+
 type IPAPIClientConfig struct {
 	IPInfoAPIToken string
+	// Timeout bounds each lookup, zero leaves it to the caller's context
+	Timeout time.Duration
 }
 
-func NewIPAPIClientConfig(iPInfoAPIToken string) *IPAPIClientConfig {
+func NewIPAPIClientConfig(iPInfoAPIToken string, timeout time.Duration) *IPAPIClientConfig {
 	return &IPAPIClientConfig{
 		IPInfoAPIToken: iPInfoAPIToken,
+		Timeout:        timeout,
 	}
 }
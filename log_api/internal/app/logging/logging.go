@@ -0,0 +1,91 @@
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+)
+
+const (
+	requestIDKey = "request_id"
+	parseIDKey   = "parse_id"
+	idBytes      = 8
+)
+
+type contextKey int
+
+const (
+	requestIDContextKey contextKey = iota
+	parseIDContextKey
+)
+
+// NewLogger writes JSON lines at or above the level, each with the request and parse IDs of its context.
+func NewLogger(w io.Writer, level slog.Leveler) *slog.Logger {
+	return slog.New(&contextHandler{
+		Handler: slog.NewJSONHandler(w, &slog.HandlerOptions{AddSource: true, Level: level}),
+	})
+}
+
+// ParseLevel reads debug, info, warn or error, info when empty.
+func ParseLevel(value string) (slog.Level, error) {
+	var level slog.Level
+	if value == "" {
+		return slog.LevelInfo, nil
+	}
+	if err := level.UnmarshalText([]byte(strings.TrimSpace(value))); err != nil {
+		return slog.LevelInfo, fmt.Errorf("invalid log level %q: %w", value, err)
+	}
+	return level, nil
+}
+
+// NewID returns a random hex identifier for requests and parse runs.
+func NewID() string {
+	id := make([]byte, idBytes)
+	_, _ = rand.Read(id)
+	return hex.EncodeToString(id)
+}
+
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDContextKey, requestID)
+}
+
+func RequestID(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDContextKey).(string)
+	return requestID
+}
+
+func WithParseID(ctx context.Context, parseID string) context.Context {
+	return context.WithValue(ctx, parseIDContextKey, parseID)
+}
+
+func ParseID(ctx context.Context) string {
+	parseID, _ := ctx.Value(parseIDContextKey).(string)
+	return parseID
+}
+
+// contextHandler adds the IDs carried by the context to the records of the *Context logging calls.
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h *contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if requestID := RequestID(ctx); requestID != "" {
+		record.AddAttrs(slog.String(requestIDKey, requestID))
+	}
+	if parseID := ParseID(ctx); parseID != "" {
+		record.AddAttrs(slog.String(parseIDKey, parseID))
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,104 @@
+package logging_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/app/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewLogger(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		ctx  context.Context
+		log  func(logger *slog.Logger, ctx context.Context)
+		want map[string]any
+	}{
+		"without IDs": {
+			ctx: context.Background(),
+			log: func(logger *slog.Logger, ctx context.Context) {
+				logger.InfoContext(ctx, "Parsed", "lines", 3)
+			},
+			want: map[string]any{"level": "INFO", "msg": "Parsed", "lines": float64(3)},
+		},
+		"with request and parse IDs": {
+			ctx: logging.WithParseID(logging.WithRequestID(context.Background(), "req-1"), "parse-1"),
+			log: func(logger *slog.Logger, ctx context.Context) {
+				logger.With("file", "l0315000.log").WarnContext(ctx, "Failed line")
+			},
+			want: map[string]any{
+				"level":      "WARN",
+				"msg":        "Failed line",
+				"file":       "l0315000.log",
+				"request_id": "req-1",
+				"parse_id":   "parse-1",
+			},
+		},
+		"below the level": {
+			ctx: logging.WithRequestID(context.Background(), "req-1"),
+			log: func(logger *slog.Logger, ctx context.Context) {
+				logger.DebugContext(ctx, "Hidden")
+			},
+			want: nil,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			var buffer bytes.Buffer
+			tt.log(logging.NewLogger(&buffer, slog.LevelInfo), tt.ctx)
+
+			if tt.want == nil {
+				assert.Empty(t, buffer.String())
+				return
+			}
+			var record map[string]any
+			require.NoError(t, json.Unmarshal(buffer.Bytes(), &record))
+			for key, value := range tt.want {
+				assert.Equal(t, value, record[key], key)
+			}
+			if _, ok := tt.want["request_id"]; !ok {
+				assert.NotContains(t, record, "request_id")
+			}
+		})
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		value   string
+		want    slog.Level
+		wantErr bool
+	}{
+		"empty":   {value: "", want: slog.LevelInfo},
+		"debug":   {value: "debug", want: slog.LevelDebug},
+		"warn":    {value: "WARN", want: slog.LevelWarn},
+		"error":   {value: "error", want: slog.LevelError},
+		"invalid": {value: "loud", want: slog.LevelInfo, wantErr: true},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			level, err := logging.ParseLevel(tt.value)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.want, level)
+		})
+	}
+}
+
+func TestNewID(t *testing.T) {
+	t.Parallel()
+	first, second := logging.NewID(), logging.NewID()
+	assert.Len(t, first, 16)
+	assert.NotEqual(t, first, second)
+}
@@ -0,0 +1,194 @@
+package rconclient
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/app/rconclient/config"
+)
+
+var ErrAuthFailed = errors.New("rcon authentication failed")
+
+// RCONClient runs console commands on the game server over the Source RCON protocol.
+// A single connection is kept open and re-established when it breaks.
+type RCONClient struct {
+	config *config.RCONClientConfig
+
+	mu     sync.Mutex
+	conn   net.Conn
+	reader *bufio.Reader
+	lastID int32
+}
+
+func NewRCONClient(config *config.RCONClientConfig) *RCONClient {
+	return &RCONClient{
+		config: config,
+	}
+}
+
+// Execute runs the command and returns its whole output, however many packets it spans.
+// A command failing on a broken connection is retried once on a fresh one, unless ctx is done.
+func (c *RCONClient) Execute(ctx context.Context, command string) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	reused := c.conn != nil
+	output, err := c.execute(ctx, command)
+	if err != nil && reused && !errors.Is(err, ErrAuthFailed) && ctx.Err() == nil {
+		output, err = c.execute(ctx, command)
+	}
+	if err != nil && ctx.Err() != nil {
+		return "", ctx.Err()
+	}
+	return output, err
+}
+
+func (c *RCONClient) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.disconnect()
+}
+
+func (c *RCONClient) execute(ctx context.Context, command string) (string, error) {
+	if err := c.connect(ctx); err != nil {
+		return "", err
+	}
+
+	output, err := c.roundTrip(ctx, command)
+	if err != nil {
+		_ = c.disconnect()
+		return "", fmt.Errorf("failed to execute rcon command: %w", err)
+	}
+	return output, nil
+}
+
+// roundTrip sends the command followed by an empty response packet. srcds answers in order,
+// so the mirror of the empty packet marks the end of a multi-packet response.
+func (c *RCONClient) roundTrip(ctx context.Context, command string) (string, error) {
+	stop, err := c.setDeadline(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer stop()
+
+	commandID := c.nextID()
+	terminatorID := c.nextID()
+	if err := writePacket(c.conn, packet{id: commandID, packetType: packetTypeExecCommand, body: command}); err != nil {
+		return "", err
+	}
+	if err := writePacket(c.conn, packet{id: terminatorID, packetType: packetTypeResponseValue}); err != nil {
+		return "", err
+	}
+
+	var output strings.Builder
+	for {
+		p, err := readPacket(c.reader)
+		if err != nil {
+			return "", err
+		}
+		switch p.id {
+		case commandID:
+			output.WriteString(p.body)
+		case terminatorID:
+			return output.String(), nil
+		}
+		// Anything else is left over from an earlier exchange
+	}
+}
+
+func (c *RCONClient) connect(ctx context.Context) error {
+	if c.conn != nil {
+		return nil
+	}
+
+	address := net.JoinHostPort(c.config.Host, strconv.Itoa(c.config.Port))
+	dialer := net.Dialer{Timeout: c.config.Timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return fmt.Errorf("failed to connect to rcon: %w", err)
+	}
+	c.conn = conn
+	c.reader = bufio.NewReader(conn)
+
+	if err := c.authenticate(ctx); err != nil {
+		_ = c.disconnect()
+		return err
+	}
+	return nil
+}
+
+func (c *RCONClient) authenticate(ctx context.Context) error {
+	stop, err := c.setDeadline(ctx)
+	if err != nil {
+		return err
+	}
+	defer stop()
+
+	authID := c.nextID()
+	if err := writePacket(c.conn, packet{id: authID, packetType: packetTypeAuth, body: c.config.Password}); err != nil {
+		return fmt.Errorf("failed to send rcon auth: %w", err)
+	}
+
+	for {
+		p, err := readPacket(c.reader)
+		if err != nil {
+			return fmt.Errorf("failed to read rcon auth response: %w", err)
+		}
+		// srcds sends an empty response value before the auth response
+		if p.packetType != packetTypeAuthResponse {
+			continue
+		}
+		if p.id != authID {
+			return ErrAuthFailed
+		}
+		return nil
+	}
+}
+
+// setDeadline bounds the next exchange by the timeout or ctx, whichever ends first. Until stop is called,
+// ctx being done interrupts the pending reads and writes by moving the deadline to the past.
+func (c *RCONClient) setDeadline(ctx context.Context) (func() bool, error) {
+	deadline := time.Now().Add(c.config.Timeout)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+	if err := c.conn.SetDeadline(deadline); err != nil {
+		return nil, err
+	}
+
+	conn := c.conn
+	return context.AfterFunc(ctx, func() {
+		_ = conn.SetDeadline(time.Unix(1, 0))
+	}), nil
+}
+
+func (c *RCONClient) disconnect() error {
+	if c.conn == nil {
+		return nil
+	}
+	err := c.conn.Close()
+	c.conn = nil
+	c.reader = nil
+	return err
+}
+
+func (c *RCONClient) nextID() int32 {
+	c.lastID++
+	if c.lastID <= 0 {
+		// -1 means a failed auth, keep ids positive
+		c.lastID = 1
+	}
+	return c.lastID
+}
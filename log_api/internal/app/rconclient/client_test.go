@@ -0,0 +1,201 @@
+package rconclient_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/app/rconclient"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/app/rconclient/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testPassword     = "secret"
+	maxResponseChunk = 100
+)
+
+// fakeServer speaks enough Source RCON to test the client: it splits long outputs over several packets,
+// answers the empty terminator packet like srcds does, and can drop connections after a command.
+type fakeServer struct {
+	listener    net.Listener
+	connections atomic.Int64
+	// dropAfterCommand closes the connection right after reading the next command instead of answering it
+	dropAfterCommand atomic.Bool
+	// ignoreCommands reads the commands without ever answering them
+	ignoreCommands atomic.Bool
+}
+
+func newFakeServer(t *testing.T) *fakeServer {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = listener.Close() })
+
+	server := &fakeServer{listener: listener}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			server.connections.Add(1)
+			go server.serve(conn)
+		}
+	}()
+	return server
+}
+
+func (s *fakeServer) config() *config.RCONClientConfig {
+	address := s.listener.Addr().(*net.TCPAddr)
+	return config.NewRCONClientConfig(address.IP.String(), address.Port, testPassword, time.Second)
+}
+
+func (s *fakeServer) serve(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	for {
+		id, packetType, body, err := readTestPacket(reader)
+		if err != nil {
+			return
+		}
+		switch {
+		case packetType == 3:
+			writeTestPacket(conn, id, 0, "")
+			if body != testPassword {
+				id = -1
+			}
+			writeTestPacket(conn, id, 2, "")
+		case packetType == 2:
+			if s.dropAfterCommand.CompareAndSwap(true, false) {
+				return
+			}
+			if s.ignoreCommands.Load() {
+				continue
+			}
+			output := "output of " + body
+			if body == "long" {
+				output = strings.Repeat("x", 3*maxResponseChunk+10)
+			}
+			for len(output) > maxResponseChunk {
+				writeTestPacket(conn, id, 0, output[:maxResponseChunk])
+				output = output[maxResponseChunk:]
+			}
+			writeTestPacket(conn, id, 0, output)
+		case packetType == 0 && !s.ignoreCommands.Load():
+			writeTestPacket(conn, id, 0, "")
+			writeTestPacket(conn, id, 0, "\x00\x01\x00\x00")
+		}
+	}
+}
+
+func readTestPacket(r *bufio.Reader) (int32, int32, string, error) {
+	var size, id, packetType int32
+	for _, v := range []*int32{&size, &id, &packetType} {
+		if err := binary.Read(r, binary.LittleEndian, v); err != nil {
+			return 0, 0, "", err
+		}
+	}
+	body := make([]byte, size-8)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return 0, 0, "", err
+	}
+	return id, packetType, string(body[:len(body)-2]), nil
+}
+
+func writeTestPacket(w io.Writer, id, packetType int32, body string) {
+	buf := binary.LittleEndian.AppendUint32(nil, uint32(len(body)+10))
+	buf = binary.LittleEndian.AppendUint32(buf, uint32(id))
+	buf = binary.LittleEndian.AppendUint32(buf, uint32(packetType))
+	buf = append(buf, body...)
+	_, _ = w.Write(append(buf, 0, 0))
+}
+
+func TestRCONClient_Execute(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name string
+		run  func(t *testing.T, server *fakeServer, client *rconclient.RCONClient)
+	}{
+		{
+			name: "success: commands share one connection",
+			run: func(t *testing.T, server *fakeServer, client *rconclient.RCONClient) {
+				for _, command := range []string{"status", "users"} {
+					output, err := client.Execute(context.Background(), command)
+					require.NoError(t, err)
+					assert.Equal(t, "output of "+command, output)
+				}
+				assert.Equal(t, int64(1), server.connections.Load())
+			},
+		},
+		{
+			name: "success: multi-packet response is joined",
+			run: func(t *testing.T, _ *fakeServer, client *rconclient.RCONClient) {
+				output, err := client.Execute(context.Background(), "long")
+				require.NoError(t, err)
+				assert.Equal(t, strings.Repeat("x", 3*maxResponseChunk+10), output)
+
+				output, err = client.Execute(context.Background(), "status")
+				require.NoError(t, err)
+				assert.Equal(t, "output of status", output)
+			},
+		},
+		{
+			name: "success: reconnects after the connection drops",
+			run: func(t *testing.T, server *fakeServer, client *rconclient.RCONClient) {
+				_, err := client.Execute(context.Background(), "status")
+				require.NoError(t, err)
+
+				server.dropAfterCommand.Store(true)
+				output, err := client.Execute(context.Background(), "status")
+				require.NoError(t, err)
+				assert.Equal(t, "output of status", output)
+				assert.Equal(t, int64(2), server.connections.Load())
+			},
+		},
+		{
+			name: "failure: cancelling the context interrupts the command",
+			run: func(t *testing.T, server *fakeServer, client *rconclient.RCONClient) {
+				_, err := client.Execute(context.Background(), "status")
+				require.NoError(t, err)
+
+				server.ignoreCommands.Store(true)
+				ctx, cancel := context.WithCancel(context.Background())
+				time.AfterFunc(50*time.Millisecond, cancel)
+				startedAt := time.Now()
+				_, err = client.Execute(ctx, "status")
+				require.ErrorIs(t, err, context.Canceled)
+				assert.Less(t, time.Since(startedAt), 500*time.Millisecond)
+				assert.Equal(t, int64(1), server.connections.Load())
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			server := newFakeServer(t)
+			client := rconclient.NewRCONClient(server.config())
+			t.Cleanup(func() { _ = client.Close() })
+			tt.run(t, server, client)
+		})
+	}
+}
+
+func TestRCONClient_Execute_WrongPassword(t *testing.T) {
+	t.Parallel()
+	server := newFakeServer(t)
+	cfg := server.config()
+	cfg.Password = "wrong"
+	client := rconclient.NewRCONClient(cfg)
+
+	_, err := client.Execute(context.Background(), "status")
+	assert.ErrorIs(t, err, rconclient.ErrAuthFailed)
+}
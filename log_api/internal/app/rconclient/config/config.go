@@ -0,0 +1,19 @@
+package config
+
+import "time"
+
+type RCONClientConfig struct {
+	Host     string
+	Port     int
+	Password string
+	Timeout  time.Duration
+}
+
+func NewRCONClientConfig(host string, port int, password string, timeout time.Duration) *RCONClientConfig {
+	return &RCONClientConfig{
+		Host:     host,
+		Port:     port,
+		Password: password,
+		Timeout:  timeout,
+	}
+}
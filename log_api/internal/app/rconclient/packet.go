@@ -0,0 +1,65 @@
+package rconclient
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Source RCON packet types. EXECCOMMAND and AUTH_RESPONSE share a value, the direction tells them apart.
+const (
+	packetTypeResponseValue = 0
+	packetTypeExecCommand   = 2
+	packetTypeAuthResponse  = 2
+	packetTypeAuth          = 3
+
+	// id and type, plus the body and the empty string terminators
+	packetHeaderSize  = 8
+	packetPaddingSize = 2
+	// maxPacketSize is the largest packet srcds sends, bigger responses are split over several packets
+	maxPacketSize = 4096
+)
+
+var errMalformedPacket = errors.New("malformed rcon packet")
+
+type packet struct {
+	id         int32
+	packetType int32
+	body       string
+}
+
+func writePacket(w io.Writer, p packet) error {
+	size := int32(packetHeaderSize + len(p.body) + packetPaddingSize) //nolint:gosec // bodies are far below 2 GiB
+	buf := make([]byte, 0, 4+size)
+	buf = binary.LittleEndian.AppendUint32(buf, uint32(size))         //nolint:gosec // see above
+	buf = binary.LittleEndian.AppendUint32(buf, uint32(p.id))         //nolint:gosec // ids are reinterpreted as is
+	buf = binary.LittleEndian.AppendUint32(buf, uint32(p.packetType)) //nolint:gosec // see above
+	buf = append(buf, p.body...)
+	buf = append(buf, 0, 0)
+
+	_, err := w.Write(buf)
+	return err
+}
+
+func readPacket(r *bufio.Reader) (packet, error) {
+	var size int32
+	if err := binary.Read(r, binary.LittleEndian, &size); err != nil {
+		return packet{}, err
+	}
+	if size < packetHeaderSize+packetPaddingSize || size > maxPacketSize {
+		return packet{}, fmt.Errorf("%w: size %d", errMalformedPacket, size)
+	}
+
+	buf := make([]byte, size)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return packet{}, err
+	}
+
+	return packet{
+		id:         int32(binary.LittleEndian.Uint32(buf[0:4])), //nolint:gosec // ids are reinterpreted as is
+		packetType: int32(binary.LittleEndian.Uint32(buf[4:8])), //nolint:gosec // see above
+		body:       string(buf[packetHeaderSize : size-packetPaddingSize]),
+	}, nil
+}
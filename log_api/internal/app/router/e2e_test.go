@@ -0,0 +1,386 @@
+package router_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/app/a2sclient"
+	a2sclientconfig "github.com/dmitriitimoshenko/nmrih/log_api/internal/app/a2sclient/config"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/app/handlers/adminhandler"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/app/handlers/chathandler"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/app/handlers/erasurehandler"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/app/handlers/exporthandler"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/app/handlers/healthhandler"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/app/handlers/loggraphhandler"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/app/handlers/logparserhandler"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/app/handlers/playershandler"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/app/handlers/ratelimithandler"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/app/handlers/recordshandler"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/app/handlers/response"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/app/handlers/streamhandler"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/app/rconclient"
+	rconclientconfig "github.com/dmitriitimoshenko/nmrih/log_api/internal/app/rconclient/config"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/app/router"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/dto"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/enums"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/services/admin"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/services/aliases"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/services/audit"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/services/chat"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/services/csvgenerator"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/services/csvmaintenance"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/services/csvparser"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/services/csvrepository"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/services/erasure"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/services/export"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/services/generation"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/services/graph"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/services/health"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/services/logparser"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/services/logrepository"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/services/notifier"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/services/parsereport"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/services/privacy"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/services/ratelimit"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/services/records"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/services/rollups"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/services/rounds"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/services/stream"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/testsupport"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/tools"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	e2eAdminToken = "e2e-token"
+	e2eTimeout    = 200 * time.Millisecond
+)
+
+// e2eServer is the API wired like main does, on temporary storage, simulated logs and the fake game server.
+type e2eServer struct {
+	engine *gin.Engine
+	geoIP  *testsupport.FakeIPAPIClient
+	cache  *testsupport.MemoryCache
+}
+
+//nolint:funlen // mirrors the wiring of main
+func newE2EServer(t *testing.T, logs *testsupport.SimulatedLogs, a2sConfig testsupport.A2SServerConfig) *e2eServer {
+	t.Helper()
+
+	logsDirectory, csvDirectory, stateDirectory := t.TempDir(), t.TempDir(), t.TempDir()
+	for name, file := range logs.Files {
+		require.NoError(t, os.WriteFile(filepath.Join(logsDirectory, name), file, 0o600))
+	}
+
+	a2sServer := testsupport.NewFakeA2SServer(t, a2sConfig)
+	a2sClient := a2sclient.NewA2SClient(
+		a2sclientconfig.NewA2SClientConfig(a2sServer.Host(), a2sServer.Port(), e2eTimeout),
+	)
+	// Nothing listens for RCON, the tests leave the game commands alone
+	rconClient := rconclient.NewRCONClient(
+		rconclientconfig.NewRCONClientConfig(a2sServer.Host(), a2sServer.Port(), "secret", e2eTimeout),
+	)
+	t.Cleanup(func() { _ = rconClient.Close() })
+	geoIP := testsupport.NewFakeIPAPIClient(logs.Countries())
+	cache := testsupport.NewMemoryCache(time.Minute)
+
+	logRepositoryService := logrepository.NewService(*logrepository.NewConfig(logsDirectory, "*.log"))
+	csvGeneratorService := csvgenerator.NewCSVGenerator()
+	csvRepositoryService := csvrepository.NewService(*csvrepository.NewConfig(csvDirectory))
+	csvParserService := csvparser.NewService()
+	privacyService := privacy.NewService(*privacy.NewConfig(enums.IPModes.Plain(), ""))
+	graphService := graph.NewService(a2sClient)
+	recordsService := records.NewService(*records.NewConfig(stateDirectory), graphService)
+	aliasService := aliases.NewService(*aliases.NewConfig(stateDirectory))
+	chatService := chat.NewService(*chat.NewConfig(stateDirectory))
+	roundsService := rounds.NewService(*rounds.NewConfig(stateDirectory))
+	rollupsService := rollups.NewService(
+		*rollups.NewConfig(stateDirectory),
+		graphService,
+		csvRepositoryService,
+		csvParserService,
+	)
+	generationService := generation.NewService(*generation.NewConfig(stateDirectory))
+	streamService := stream.NewService(*stream.NewConfig(time.Minute, 1), graphService)
+	parseReportService := parsereport.NewService(*parsereport.NewConfig(stateDirectory))
+	notifierService := notifier.NewService(
+		*notifier.NewConfig(dto.NotifierSettings{}, stateDirectory, time.Minute, time.Second),
+		recordsService,
+		graphService,
+	)
+	logParserService := logparser.NewService(
+		*logparser.NewConfig(false, time.Minute, 2, 2),
+		logRepositoryService,
+		csvGeneratorService,
+		csvRepositoryService,
+		geoIP,
+		privacyService,
+		parseReportService,
+		recordsService,
+		aliasService,
+		chatService,
+		roundsService,
+		rollupsService,
+		generationService,
+		streamService,
+		notifierService,
+	)
+
+	auditService := audit.NewService(*audit.NewConfig(stateDirectory))
+	adminService := admin.NewService(rconClient, auditService, privacyService)
+	csvMaintenanceService := csvmaintenance.NewService(
+		*csvmaintenance.NewConfig(0, 0),
+		csvRepositoryService,
+		nil,
+		csvParserService,
+		csvGeneratorService,
+	)
+	exportService := export.NewService(
+		csvRepositoryService,
+		csvParserService,
+		csvGeneratorService,
+		graphService,
+		privacyService,
+	)
+	erasureService := erasure.NewService(
+		csvMaintenanceService,
+		aliasService,
+		privacyService,
+		auditService,
+		cache,
+		chatService,
+		recordsService,
+		roundsService,
+		parseReportService,
+		rollupsService,
+		generationService,
+		aliasService,
+	)
+	// Zero rates turn the limits off
+	rateLimitService := ratelimit.NewService(*ratelimit.NewConfig(e2eTimeout, 1), nil)
+	healthService := health.NewService(
+		*health.NewConfig(e2eTimeout),
+		health.Check{Name: "redis", Critical: true, Probe: cache.Ping},
+		health.Check{Name: "a2s", Probe: a2sClient.Ping},
+	)
+
+	engine := gin.New()
+	engine.ContextWithFallback = true
+	router.Register(engine, router.Handlers{
+		Health:    healthhandler.NewHealthHandler(healthService),
+		LogParser: logparserhandler.NewLogParserHandler(cache, logParserService, parseReportService),
+		LogGraph: loggraphhandler.NewLogGraphHandler(
+			cache,
+			csvRepositoryService,
+			csvParserService,
+			graphService,
+			roundsService,
+			rollupsService,
+			generationService,
+		),
+		Records: recordshandler.NewRecordsHandler(recordsService),
+		Players: playershandler.NewPlayersHandler(aliasService),
+		Chat:    chathandler.NewChatHandler(chatService),
+		Stream:  streamhandler.NewStreamHandler(streamService),
+		Admin:   adminhandler.NewAdminHandler(adminService, e2eAdminToken),
+		Erasure: erasurehandler.NewErasureHandler(erasureService),
+		Export: exporthandler.NewExportHandler(
+			exportService,
+			net.JoinHostPort(a2sServer.Host(), strconv.Itoa(a2sServer.Port())),
+		),
+		RateLimit: ratelimithandler.NewRateLimitHandler(
+			*ratelimithandler.NewConfig(
+				dto.RateLimitPolicy{Name: "public"},
+				dto.RateLimitPolicy{Name: "players-info"},
+				dto.RateLimitPolicy{Name: "admin"},
+			),
+			rateLimitService,
+		),
+	})
+
+	return &e2eServer{engine: engine, geoIP: geoIP, cache: cache}
+}
+
+func (s *e2eServer) get(t *testing.T, path string) *httptest.ResponseRecorder {
+	t.Helper()
+	request := httptest.NewRequest(http.MethodGet, path, nil)
+	request.Header.Set("Authorization", "Bearer "+e2eAdminToken)
+	recorder := httptest.NewRecorder()
+	s.engine.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func decodeData[T any](t *testing.T, recorder *httptest.ResponseRecorder) T {
+	t.Helper()
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+	var envelope response.Envelope[T]
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &envelope))
+	return envelope.Data
+}
+
+func simulatedLogs() *testsupport.SimulatedLogs {
+	return testsupport.SimulateLogs(testsupport.LogSimulationConfig{
+		Seed:           2025,
+		Start:          time.Date(2025, time.April, 14, 0, 0, 0, 0, time.UTC),
+		Days:           4,
+		Players:        16,
+		SessionsPerDay: 3,
+	})
+}
+
+// timeSpentOf indexes the graph by player for comparing it with the ground truth.
+func timeSpentOf(topTimeSpent dto.TopTimeSpentList) map[string]time.Duration {
+	timeSpent := make(map[string]time.Duration, len(topTimeSpent))
+	for _, entry := range topTimeSpent {
+		timeSpent[entry.NickName] = entry.TimeSpent
+	}
+	return timeSpent
+}
+
+func TestE2E_ParsedLogsMatchGroundTruth(t *testing.T) {
+	t.Parallel()
+	logs := simulatedLogs()
+	server := newE2EServer(t, logs, testsupport.A2SServerConfig{Challenge: true})
+
+	parseResponse := server.get(t, "/api/v1/parse")
+	require.Equal(t, http.StatusOK, parseResponse.Code, parseResponse.Body.String())
+	var parsed logparserhandler.ParseResponse
+	require.NoError(t, json.Unmarshal(parseResponse.Body.Bytes(), &parsed))
+	report := parsed.Data
+	assert.Equal(t, len(logs.Files), report.FilesCount)
+	assert.Zero(t, report.DiagnosticsCount, report.Diagnostics)
+	assert.Equal(t, len(logs.Sessions), report.ActionCounts[enums.Actions.Connected().String()])
+	assert.Equal(t, len(logs.Sessions), report.ActionCounts[enums.Actions.Disconnected().String()])
+	assert.Equal(t, logs.Suicides, report.ActionCounts[enums.Actions.CommittedSuicide().String()])
+	// Each address is looked up once per parse
+	assert.Equal(t, len(logs.Players), server.geoIP.Lookups())
+
+	t.Run("success: time spent from the rollups", func(t *testing.T) {
+		t.Parallel()
+		topTimeSpent := decodeData[dto.TopTimeSpentList](t, server.get(t, "/api/v1/graph?type=top-time-spent"))
+		assert.Equal(t, logs.TimeSpent(), timeSpentOf(topTimeSpent))
+	})
+
+	t.Run("success: time spent from the stored events", func(t *testing.T) {
+		t.Parallel()
+		// A time range is computed from the CSV store, the rollups only hold the whole history
+		recorder := server.get(t, "/api/v1/graph?type=top-time-spent&from=2025-03-01")
+		topTimeSpent := decodeData[dto.TopTimeSpentList](t, recorder)
+		assert.Equal(t, logs.TimeSpent(), timeSpentOf(topTimeSpent))
+	})
+
+	t.Run("success: exported sessions", func(t *testing.T) {
+		t.Parallel()
+		recorder := server.get(t, "/api/v1/export/sessions?format=ndjson")
+		require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+
+		var sessions []dto.Session
+		scanner := bufio.NewScanner(recorder.Body)
+		for scanner.Scan() {
+			var session dto.Session
+			require.NoError(t, json.Unmarshal(scanner.Bytes(), &session))
+			sessions = append(sessions, session)
+		}
+		require.Len(t, sessions, len(logs.Sessions))
+		for i, want := range logs.Sessions {
+			assert.Equal(t, want.NickName, sessions[i].NickName)
+			assert.True(t, want.Start.Equal(sessions[i].Start), "start of session %d", i)
+			assert.True(t, want.End.Equal(sessions[i].End), "end of session %d", i)
+		}
+	})
+}
+
+func TestE2E_ParseKeepsRateLimitBuckets(t *testing.T) {
+	t.Parallel()
+	server := newE2EServer(t, simulatedLogs(), testsupport.A2SServerConfig{Challenge: true})
+	ctx := context.Background()
+	// In production the buckets share the Redis database with the cached graphs
+	bucketKey := "rate_limit:public:203.0.113.7"
+	graphKey := enums.GraphTypes.TopTimeSpentGraphType().CacheKey()
+	require.NoError(t, server.cache.SetWithTimeout(ctx, bucketKey, "bucket", nil, e2eTimeout))
+	require.NoError(t, server.cache.SetWithTimeout(ctx, graphKey, "stale", nil, e2eTimeout))
+
+	parseResponse := server.get(t, "/api/v1/parse")
+	require.Equal(t, http.StatusOK, parseResponse.Code, parseResponse.Body.String())
+
+	bucket, err := server.cache.GetWithTimeout(ctx, bucketKey, e2eTimeout)
+	require.NoError(t, err)
+	assert.Equal(t, tools.ToPtr("bucket"), bucket)
+	graph, err := server.cache.GetWithTimeout(ctx, graphKey, e2eTimeout)
+	require.NoError(t, err)
+	assert.Nil(t, graph)
+}
+
+func TestE2E_GameServer(t *testing.T) {
+	t.Parallel()
+	players := []testsupport.A2SPlayer{
+		{Name: "Bateman", Score: 42, Duration: 90 * time.Minute},
+		{Name: "Wally", Score: 7, Duration: 5 * time.Minute},
+	}
+	tests := []struct {
+		name           string
+		silent         bool
+		wantReadiness  enums.ReadinessStatus
+		wantPlayers    *dto.PlayersInfo
+		wantGraphCode  int
+		wantReadyzCode int
+	}{
+		{
+			name:           "success: players online",
+			wantReadiness:  enums.ReadinessStatuses.Ready(),
+			wantReadyzCode: http.StatusOK,
+			wantGraphCode:  http.StatusOK,
+			wantPlayers: &dto.PlayersInfo{Count: 2, PlayerInfo: []*dto.PlayerInfo{
+				{Name: "Bateman", Score: 42, Duration: 5400},
+				{Name: "Wally", Score: 7, Duration: 300},
+			}},
+		},
+		{
+			name:           "failure: server down degrades the API",
+			silent:         true,
+			wantReadiness:  enums.ReadinessStatuses.Degraded(),
+			wantReadyzCode: http.StatusOK,
+			wantGraphCode:  http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			server := newE2EServer(t, &testsupport.SimulatedLogs{}, testsupport.A2SServerConfig{
+				Players:   players,
+				Challenge: true,
+				Silent:    tt.silent,
+			})
+
+			readyz := server.get(t, "/readyz")
+			require.Equal(t, tt.wantReadyzCode, readyz.Code, readyz.Body.String())
+			var readiness dto.Readiness
+			require.NoError(t, json.Unmarshal(readyz.Body.Bytes(), &readiness))
+			assert.Equal(t, tt.wantReadiness, readiness.Status)
+
+			graph := server.get(t, "/api/v1/graph?type=players-info")
+			require.Equal(t, tt.wantGraphCode, graph.Code, graph.Body.String())
+			if tt.wantPlayers == nil {
+				// A failure must not be reused by clients or proxies
+				assert.Empty(t, graph.Header().Get("Cache-Control"))
+				assert.Empty(t, graph.Header().Get("ETag"))
+				return
+			}
+			assert.Equal(t, tt.wantPlayers, decodeData[*dto.PlayersInfo](t, graph))
+			assert.Equal(t, "public, max-age=10", graph.Header().Get("Cache-Control"))
+			assert.NotEmpty(t, graph.Header().Get("ETag"))
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package router
+
+import (
+	_ "embed"
+	"net/http"
+
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/app/handlers/adminhandler"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/app/handlers/chathandler"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/app/handlers/erasurehandler"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/app/handlers/exporthandler"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/app/handlers/healthhandler"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/app/handlers/loggraphhandler"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/app/handlers/logparserhandler"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/app/handlers/playershandler"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/app/handlers/ratelimithandler"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/app/handlers/recordshandler"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/app/handlers/streamhandler"
+	"github.com/gin-gonic/gin"
+)
+
+// openAPISpec documents every route registered below, which the router test keeps in sync.
+//
+//go:embed openapi.json
+var openAPISpec []byte
+
+type Handlers struct {
+	Health    *healthhandler.Handler
+	LogParser *logparserhandler.Handler
+	LogGraph  *loggraphhandler.Handler
+	Records   *recordshandler.Handler
+	Players   *playershandler.Handler
+	Chat      *chathandler.Handler
+	Stream    *streamhandler.Handler
+	Admin     *adminhandler.Handler
+	Erasure   *erasurehandler.Handler
+	Export    *exporthandler.Handler
+	RateLimit *ratelimithandler.Handler
+}
+
+// Register mounts the API routes on the server.
+func Register(server gin.IRouter, handlers Handlers) {
+	server.GET("/health-check", handlers.Health.Livez)
+	server.GET("/livez", handlers.Health.Livez)
+	server.GET("/readyz", handlers.Health.Readyz)
+
+	apiv1 := server.Group("/api/v1")
+	apiv1.GET("/openapi.json", func(ctx *gin.Context) {
+		ctx.Data(http.StatusOK, "application/json; charset=utf-8", openAPISpec)
+	})
+
+	publicv1 := apiv1.Group("", handlers.RateLimit.Public)
+	publicv1.GET("/parse", handlers.LogParser.Parse)
+	publicv1.GET("/parse/report", handlers.LogParser.Report)
+	publicv1.GET("/graph", handlers.RateLimit.PlayersInfo, handlers.LogGraph.Graph)
+	publicv1.GET("/records", handlers.Records.Records)
+	publicv1.GET("/players/search", handlers.Players.Search)
+	publicv1.GET("/players/:id/aliases", handlers.Players.Aliases)
+	publicv1.GET("/chat", handlers.Chat.Chat)
+	publicv1.GET("/stream", handlers.Stream.Stream)
+
+	adminv1 := apiv1.Group("/admin", handlers.RateLimit.Admin, handlers.Admin.Authorize)
+	adminv1.GET("/status", handlers.Admin.Status)
+	adminv1.POST("/kick", handlers.Admin.Kick)
+	adminv1.POST("/changelevel", handlers.Admin.ChangeLevel)
+	adminv1.POST("/say", handlers.Admin.Say)
+	adminv1.POST("/erase", handlers.Erasure.Erase)
+
+	exportv1 := apiv1.Group("/export", handlers.RateLimit.Admin, handlers.Admin.Authorize)
+	exportv1.GET("/events", handlers.Export.Events)
+	exportv1.GET("/sessions", handlers.Export.Sessions)
+}
@@ -0,0 +1,250 @@
+package router_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/app/handlers/adminhandler"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/app/handlers/chathandler"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/app/handlers/erasurehandler"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/app/handlers/exporthandler"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/app/handlers/healthhandler"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/app/handlers/loggraphhandler"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/app/handlers/logparserhandler"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/app/handlers/playershandler"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/app/handlers/ratelimithandler"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/app/handlers/recordshandler"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/app/handlers/response"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/app/handlers/streamhandler"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/app/router"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/dto"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/enums"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type specSchema struct {
+	Required   []string                   `json:"required"`
+	Properties map[string]json.RawMessage `json:"properties"`
+	Enum       []string                   `json:"enum"`
+}
+
+type specParameter struct {
+	Name   string     `json:"name"`
+	Schema specSchema `json:"schema"`
+}
+
+type specOperation struct {
+	Parameters []specParameter `json:"parameters"`
+}
+
+type spec struct {
+	OpenAPI    string                              `json:"openapi"`
+	Paths      map[string]map[string]specOperation `json:"paths"`
+	Components struct {
+		Schemas map[string]specSchema `json:"schemas"`
+	} `json:"components"`
+}
+
+//nolint:gochecknoglobals // test fixture
+var (
+	// responseSchemas are the spec schemas of the response bodies, by the Go type encoding them
+	responseSchemas = map[string]any{
+		"ErrorEnvelope":            response.ErrorEnvelope{},
+		"HealthResponse":           healthhandler.HealthResponse{},
+		"Readiness":                dto.Readiness{},
+		"HealthCheck":              dto.HealthCheck{},
+		"ParseResponse":            logparserhandler.ParseResponse{},
+		"ParseFailure":             logparserhandler.ParseFailure{},
+		"ParseReport":              dto.ParseReport{},
+		"ParseDiagnostic":          dto.ParseDiagnostic{},
+		"TopTimeSpent":             dto.TopTimeSpent{},
+		"TopCountriesPercentage":   dto.TopCountriesPercentage{},
+		"PlayersInfo":              dto.PlayersInfo{},
+		"PlayerInfo":               dto.PlayerInfo{},
+		"OnlineStatisticsHourUnit": dto.OnlineStatisticsHourUnit{},
+		"ConcurrencyPeak":          dto.ConcurrencyPeak{},
+		"ConcurrencyPeaks":         dto.ConcurrencyPeaks{},
+		"RoundStatistics":          dto.RoundStatistics{},
+		"DailyActive":              dto.DailyActive{},
+		"Session":                  dto.Session{},
+		"ServerRecords":            dto.ServerRecords{},
+		"Alias":                    dto.Alias{},
+		"PlayerAliases":            dto.PlayerAliases{},
+		"ChatMessage":              dto.ChatMessage{},
+		"ChatPage":                 dto.ChatPage{},
+		"Kill":                     dto.Kill{},
+		"StreamEvent":              dto.StreamEvent{},
+		"CommandOutput":            adminhandler.CommandOutput{},
+		"ErasureReport":            dto.ErasureReport{},
+	}
+	// requestSchemas are the spec schemas of the request bodies, by the Go type decoding them
+	requestSchemas = map[string]any{
+		"KickRequest":        adminhandler.KickRequest{},
+		"ChangeLevelRequest": adminhandler.ChangeLevelRequest{},
+		"SayRequest":         adminhandler.SayRequest{},
+		"EraseRequest":       erasurehandler.EraseRequest{},
+	}
+	pathParamRegex = regexp.MustCompile(`:(\w+)`)
+)
+
+func TestRegister_RoutesMatchSpec(t *testing.T) {
+	t.Parallel()
+	engine, document := newEngine(t)
+
+	var registered []string
+	for _, route := range engine.Routes() {
+		registered = append(registered, route.Method+" "+pathParamRegex.ReplaceAllString(route.Path, "{$1}"))
+	}
+	var documented []string
+	for path, operations := range document.Paths {
+		for method := range operations {
+			documented = append(documented, strings.ToUpper(method)+" "+path)
+		}
+	}
+	sort.Strings(registered)
+	sort.Strings(documented)
+
+	assert.Equal(t, "3.0.3", document.OpenAPI)
+	assert.Equal(t, registered, documented)
+}
+
+func TestRegister_SchemasMatchTypes(t *testing.T) {
+	t.Parallel()
+	_, document := newEngine(t)
+
+	for name, schema := range document.Components.Schemas {
+		if schema.Properties == nil {
+			continue
+		}
+		_, isResponse := responseSchemas[name]
+		_, isRequest := requestSchemas[name]
+		assert.True(t, isResponse || isRequest, "schema %s is not mapped to a Go type", name)
+	}
+
+	tests := map[string]struct {
+		types        map[string]any
+		wantRequired func(field reflect.StructField) bool
+	}{
+		"responses": {
+			types: responseSchemas,
+			wantRequired: func(field reflect.StructField) bool {
+				return !strings.Contains(field.Tag.Get("json"), ",omitempty")
+			},
+		},
+		"requests": {
+			types: requestSchemas,
+			wantRequired: func(field reflect.StructField) bool {
+				return field.Tag.Get("binding") == "required"
+			},
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			for schemaName, value := range tt.types {
+				schema, ok := document.Components.Schemas[schemaName]
+				if !assert.True(t, ok, "schema %s is missing", schemaName) {
+					continue
+				}
+
+				var properties, required []string
+				for _, field := range jsonFields(reflect.TypeOf(value)) {
+					jsonName, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+					properties = append(properties, jsonName)
+					if tt.wantRequired(field) {
+						required = append(required, jsonName)
+					}
+				}
+				var documented []string
+				for property := range schema.Properties {
+					documented = append(documented, property)
+				}
+
+				assert.ElementsMatch(t, properties, documented, "properties of %s", schemaName)
+				assert.ElementsMatch(t, required, schema.Required, "required properties of %s", schemaName)
+			}
+		})
+	}
+}
+
+func TestRegister_EnumsMatchSpec(t *testing.T) {
+	t.Parallel()
+	_, document := newEngine(t)
+
+	var errorCodes []string
+	for _, code := range enums.ErrorCodes.All() {
+		errorCodes = append(errorCodes, code.String())
+	}
+	assert.ElementsMatch(t, errorCodes, document.Components.Schemas["ErrorCode"].Enum)
+
+	var readinessStatuses []string
+	for _, status := range enums.ReadinessStatuses.All() {
+		readinessStatuses = append(readinessStatuses, status.String())
+	}
+	assert.ElementsMatch(t, readinessStatuses, document.Components.Schemas["ReadinessStatus"].Enum)
+
+	var graphTypes []string
+	for _, graphType := range enums.GraphTypes.All() {
+		graphTypes = append(graphTypes, graphType.String())
+	}
+	var documented []string
+	for _, parameter := range document.Paths["/api/v1/graph"]["get"].Parameters {
+		if parameter.Name == "type" {
+			documented = parameter.Schema.Enum
+		}
+	}
+	assert.ElementsMatch(t, graphTypes, documented)
+}
+
+// newEngine registers the routes with zero-value handlers, which are never called, and fetches the served spec.
+func newEngine(t *testing.T) (*gin.Engine, *spec) {
+	t.Helper()
+
+	engine := gin.New()
+	router.Register(engine, router.Handlers{
+		Health:    &healthhandler.Handler{},
+		LogParser: &logparserhandler.Handler{},
+		LogGraph:  &loggraphhandler.Handler{},
+		Records:   &recordshandler.Handler{},
+		Players:   &playershandler.Handler{},
+		Chat:      &chathandler.Handler{},
+		Stream:    &streamhandler.Handler{},
+		Admin:     &adminhandler.Handler{},
+		Erasure:   &erasurehandler.Handler{},
+		Export:    &exporthandler.Handler{},
+		RateLimit: &ratelimithandler.Handler{},
+	})
+
+	recorder := httptest.NewRecorder()
+	engine.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/v1/openapi.json", nil))
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var document spec
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &document))
+	return engine, &document
+}
+
+// jsonFields lists the fields encoding/json writes, with the embedded structs flattened.
+func jsonFields(structType reflect.Type) []reflect.StructField {
+	var fields []reflect.StructField
+	for i := range structType.NumField() {
+		field := structType.Field(i)
+		if field.Anonymous && field.Tag.Get("json") == "" {
+			fields = append(fields, jsonFields(field.Type)...)
+			continue
+		}
+		if !field.IsExported() || field.Tag.Get("json") == "-" {
+			continue
+		}
+		fields = append(fields, field)
+	}
+	return fields
+}
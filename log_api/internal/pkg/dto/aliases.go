@@ -0,0 +1,15 @@
+package dto
+
+import "time"
+
+type Alias struct {
+	NickName  string    `json:"nick_name"`
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+}
+
+type PlayerAliases struct {
+	SteamID  string    `json:"steam_id"`
+	LastSeen time.Time `json:"last_seen"`
+	Aliases  []Alias   `json:"aliases"`
+}
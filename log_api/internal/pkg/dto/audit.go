@@ -0,0 +1,12 @@
+package dto
+
+import "time"
+
+// AuditEntry records an admin command run on the game server.
+type AuditEntry struct {
+	TimeStamp time.Time `json:"time_stamp"`
+	Actor     string    `json:"actor"`
+	Command   string    `json:"command"`
+	Success   bool      `json:"success"`
+	Error     string    `json:"error,omitempty"`
+}
@@ -0,0 +1,28 @@
+package dto
+
+import "time"
+
+type ChatMessage struct {
+	TimeStamp time.Time `json:"time_stamp"`
+	NickName  string    `json:"nick_name"`
+	SteamID   string    `json:"steam_id"`
+	TeamOnly  bool      `json:"team_only"`
+	Message   string    `json:"message"`
+}
+
+type ChatQuery struct {
+	Text     string
+	NickName string
+	SteamID  string
+	From     *time.Time
+	To       *time.Time
+	Page     int
+	PageSize int
+}
+
+type ChatPage struct {
+	Messages []ChatMessage `json:"messages"`
+	Page     int           `json:"page"`
+	PageSize int           `json:"page_size"`
+	Total    int           `json:"total"`
+}
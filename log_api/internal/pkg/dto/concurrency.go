@@ -0,0 +1,24 @@
+package dto
+
+import "time"
+
+// ConcurrencyStep is one point of the concurrent-players step function:
+// starting at TimeStamp and until the next step, exactly Count players are online.
+type ConcurrencyStep struct {
+	TimeStamp time.Time `json:"time_stamp"`
+	Count     int       `json:"count"`
+	Players   []string  `json:"players"`
+}
+
+type ConcurrencyPeak struct {
+	PeriodStart time.Time `json:"period_start"`
+	TimeStamp   time.Time `json:"time_stamp"`
+	Count       int       `json:"count"`
+	Players     []string  `json:"players"`
+}
+
+type ConcurrencyPeaks struct {
+	Daily   []ConcurrencyPeak `json:"daily"`
+	Weekly  []ConcurrencyPeak `json:"weekly"`
+	AllTime *ConcurrencyPeak  `json:"all_time"`
+}
@@ -0,0 +1,32 @@
+package dto
+
+import "time"
+
+// CSVStoreStats summarizes the stored log events.
+type CSVStoreStats struct {
+	FilesCount     int            `json:"files_count"`
+	EventsCount    int            `json:"events_count"`
+	PlayersCount   int            `json:"players_count"`
+	First          *time.Time     `json:"first,omitempty"`
+	Last           *time.Time     `json:"last,omitempty"`
+	Checkpoint     *time.Time     `json:"checkpoint,omitempty"`
+	ActionCounts   map[string]int `json:"action_counts"`
+	EventsPerMonth map[string]int `json:"events_per_month"`
+}
+
+// CSVStoreIssue is an inconsistency found in a stored CSV file; Entry is 1-based, 0 means the whole file.
+type CSVStoreIssue struct {
+	File   string `json:"file"`
+	Entry  int    `json:"entry,omitempty"`
+	Reason string `json:"reason"`
+}
+
+// CSVCompactionReport sums up a compaction of the CSV store.
+type CSVCompactionReport struct {
+	FilesBefore     int `json:"files_before"`
+	FilesAfter      int `json:"files_after"`
+	DuplicatesCount int `json:"duplicates_count"`
+	ExpiredCount    int `json:"expired_count"`
+	IPsRemovedCount int `json:"ips_removed_count"`
+	ArchivedCount   int `json:"archived_count"`
+}
@@ -0,0 +1,61 @@
+package dto
+
+import (
+	"slices"
+
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/enums"
+)
+
+// ErasureRequest names the player to erase by SteamID, nickname or both.
+type ErasureRequest struct {
+	SteamID  string
+	NickName string
+	Mode     enums.ErasureMode
+}
+
+// ErasureTarget is the player as every store sees it: the SteamID if known, and every nickname they used.
+type ErasureTarget struct {
+	SteamID   string
+	NickNames []string
+	Mode      enums.ErasureMode
+	// Pseudonym replaces the player where their data is kept
+	Pseudonym string
+}
+
+// Matches tells whether an entry belongs to the player. Entries with a SteamID are matched by it alone,
+// so another player using one of the nicknames is left alone; entries without one are matched by nickname.
+func (t ErasureTarget) Matches(nickName, steamID string) bool {
+	if t.SteamID != "" && steamID != "" {
+		return steamID == t.SteamID
+	}
+	return t.HasNickName(nickName)
+}
+
+func (t ErasureTarget) HasNickName(nickName string) bool {
+	return slices.Contains(t.NickNames, nickName)
+}
+
+// PseudonymiseNickNames replaces the player's nicknames in the list with the pseudonym,
+// and returns how many it replaced.
+func (t ErasureTarget) PseudonymiseNickNames(nickNames []string) int {
+	var replaced int
+	for i, nickName := range nickNames {
+		if t.HasNickName(nickName) {
+			nickNames[i] = t.Pseudonym
+			replaced++
+		}
+	}
+	return replaced
+}
+
+// ErasureReport counts what an erasure changed.
+type ErasureReport struct {
+	SteamID             string            `json:"steam_id,omitempty"`
+	NickNames           []string          `json:"nick_names"`
+	Mode                enums.ErasureMode `json:"mode"`
+	Pseudonym           string            `json:"pseudonym,omitempty"`
+	CSVEntriesCount     int               `json:"csv_entries_count"`
+	ArchiveEntriesCount int               `json:"archive_entries_count"`
+	StateEntriesCount   int               `json:"state_entries_count"`
+	CachesCleared       bool              `json:"caches_cleared"`
+}
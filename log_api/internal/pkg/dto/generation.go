@@ -0,0 +1,9 @@
+package dto
+
+import "time"
+
+// Generation identifies a version of the stored data, it moves on whenever a parse, compaction or erasure changes it.
+type Generation struct {
+	Number    uint64    `json:"number"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
@@ -0,0 +1,16 @@
+package dto
+
+import "github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/enums"
+
+type HealthCheck struct {
+	Name string `json:"name"`
+	// Critical checks make the API not ready when they fail, the others only degraded
+	Critical bool   `json:"critical"`
+	OK       bool   `json:"ok"`
+	Error    string `json:"error,omitempty"`
+}
+
+type Readiness struct {
+	Status enums.ReadinessStatus `json:"status"`
+	Checks []HealthCheck         `json:"checks"`
+}
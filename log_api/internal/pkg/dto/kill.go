@@ -0,0 +1,12 @@
+package dto
+
+import "time"
+
+type Kill struct {
+	TimeStamp     time.Time `json:"time_stamp"`
+	Killer        string    `json:"killer"`
+	KillerSteamID string    `json:"killer_steam_id"`
+	Victim        string    `json:"victim"`
+	VictimSteamID string    `json:"victim_steam_id"`
+	Weapon        string    `json:"weapon"`
+}
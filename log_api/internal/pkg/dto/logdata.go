@@ -2,7 +2,6 @@ package dto
 
 import (
 	"errors"
-	"log"
 	"time"
 
 	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/enums"
@@ -11,6 +10,7 @@ import (
 type LogData struct {
 	TimeStamp time.Time    `csv:"timeStamp"`
 	NickName  string       `csv:"nickName"`
+	SteamID   string       `csv:"steamID"`
 	Action    enums.Action `csv:"action"`
 	IPAddress string       `csv:"ipAddress"`
 	Country   string       `csv:"country"`
@@ -18,7 +18,6 @@ type LogData struct {
 
 func (l *LogData) Validate() error {
 	if l.TimeStamp.IsZero() {
-		log.Println("invalid timestamp: ", l.TimeStamp)
 		return errors.New("invalid timestamp")
 	}
 	if l.NickName == "" {
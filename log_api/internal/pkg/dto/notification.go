@@ -0,0 +1,47 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/enums"
+)
+
+// NotifierSettings is the notifier configuration file: where to post and on what.
+type NotifierSettings struct {
+	Webhooks []WebhookSettings  `json:"webhooks"`
+	Rules    []NotificationRule `json:"rules"`
+}
+
+type WebhookSettings struct {
+	Name   string              `json:"name"`
+	URL    string              `json:"url"`
+	Format enums.WebhookFormat `json:"format"`
+}
+
+type NotificationRule struct {
+	Name     string                    `json:"name"`
+	Trigger  enums.NotificationTrigger `json:"trigger"`
+	Webhooks []string                  `json:"webhooks"`
+	// MinIntervalSeconds rate limits the rule: it does not fire again sooner than that
+	MinIntervalSeconds int `json:"min_interval_seconds"`
+	// Threshold is the player count of a player-count rule
+	Threshold int `json:"threshold,omitempty"`
+	// FailedPolls is how many A2S polls in a row must fail for a server-down rule
+	FailedPolls int `json:"failed_polls,omitempty"`
+	// SteamIDs are the players of a player-connected rule, in any SteamID notation
+	SteamIDs []string `json:"steam_ids,omitempty"`
+}
+
+type Notification struct {
+	Rule      string                    `json:"rule"`
+	Trigger   enums.NotificationTrigger `json:"trigger"`
+	Title     string                    `json:"title"`
+	Message   string                    `json:"message"`
+	TimeStamp time.Time                 `json:"time_stamp"`
+	Fields    []NotificationField       `json:"fields,omitempty"`
+}
+
+type NotificationField struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
@@ -10,7 +10,7 @@ type OnlineStatisticsHourUnit struct {
 }
 
 type Session struct {
-	NickName string
-	Start    time.Time
-	End      time.Time
+	NickName string    `json:"nick_name"`
+	Start    time.Time `json:"start"`
+	End      time.Time `json:"end"`
 }
@@ -0,0 +1,10 @@
+package dto
+
+// ParseBatch is everything a single parse run extracted from the logs.
+type ParseBatch struct {
+	Logs []LogData
+	Chat []ChatMessage
+	// RoundEvents are ordered as they appear in each log file
+	RoundEvents []RoundEvent
+	Kills       []Kill
+}
@@ -0,0 +1,32 @@
+package dto
+
+import "time"
+
+// ParseDiagnostic describes a log line that could not be ingested.
+type ParseDiagnostic struct {
+	File   string `json:"file"`
+	Line   int    `json:"line"`
+	Reason string `json:"reason"`
+	Raw    string `json:"raw"`
+}
+
+type ParseReport struct {
+	// ID is the parse ID its log lines carry
+	ID         string    `json:"id"`
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+	BestEffort bool      `json:"best_effort"`
+	Error      string    `json:"error,omitempty"`
+
+	FilesCount  int `json:"files_count"`
+	LinesCount  int `json:"lines_count"`
+	ParsedCount int `json:"parsed_count"`
+
+	// ActionCounts is keyed by action, "chat" or round event type
+	ActionCounts map[string]int `json:"action_counts"`
+	// UnrecognisedPatterns counts lines no parser rule matched, keyed by the line with its variable parts masked
+	UnrecognisedPatterns map[string]int `json:"unrecognised_patterns"`
+
+	DiagnosticsCount int               `json:"diagnostics_count"`
+	Diagnostics      []ParseDiagnostic `json:"diagnostics"`
+}
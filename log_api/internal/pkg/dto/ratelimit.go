@@ -0,0 +1,20 @@
+package dto
+
+import "time"
+
+// RateLimitPolicy is a token bucket per client IP: up to Burst requests at once, refilled at PerMinute a minute.
+type RateLimitPolicy struct {
+	Name      string
+	PerMinute int
+	Burst     int
+}
+
+// Enabled tells whether the policy limits anything, a zero rate or burst turns it off.
+func (p RateLimitPolicy) Enabled() bool {
+	return p.PerMinute > 0 && p.Burst > 0
+}
+
+// RefillInterval is the time it takes to refill a single token.
+func (p RateLimitPolicy) RefillInterval() time.Duration {
+	return time.Minute / time.Duration(p.PerMinute)
+}
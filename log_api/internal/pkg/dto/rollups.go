@@ -0,0 +1,42 @@
+package dto
+
+import "time"
+
+// Rollups are the graph aggregates kept up to date at parse time, so the graphs do not replay the raw events.
+type Rollups struct {
+	Players map[string]PlayerRollup `json:"players"`
+	// OpenSessions are the sessions without a disconnect yet, by nickname
+	OpenSessions map[string]OpenSession `json:"open_sessions"`
+	// HourlyOnlineSeconds sums up the time players spent online per CET hour of the day
+	HourlyOnlineSeconds []float64 `json:"hourly_online_seconds"`
+	// FirstDay is the day of the first connection, where the online statistics timeline starts
+	FirstDay           *time.Time     `json:"first_day,omitempty"`
+	ConnectionsCount   int            `json:"connections_count"`
+	CountryConnections map[string]int `json:"country_connections"`
+	// DailyActivePlayers are the sets of nicknames seen per day, by YYYY-MM-DD. They are saved per month on their own
+	DailyActivePlayers map[string]map[string]struct{} `json:"-"`
+	// Checkpoint is the time of the newest entry folded in
+	Checkpoint time.Time `json:"checkpoint"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+type PlayerRollup struct {
+	TimeSpent        time.Duration `json:"time_spent"`
+	SessionsCount    int           `json:"sessions_count"`
+	ConnectionsCount int           `json:"connections_count"`
+	LastSeen         time.Time     `json:"last_seen"`
+}
+
+type OpenSession struct {
+	Start        time.Time `json:"start"`
+	LastActivity time.Time `json:"last_activity"`
+	// Reconnected sessions were opened by a reconnect without a disconnect and are left out of the online time
+	Reconnected bool `json:"reconnected,omitempty"`
+}
+
+type DailyActives []DailyActive
+
+type DailyActive struct {
+	Day          string `json:"day"`
+	PlayersCount int    `json:"players_count"`
+}
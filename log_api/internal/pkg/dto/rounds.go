@@ -0,0 +1,37 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/enums"
+)
+
+type RoundEvent struct {
+	TimeStamp time.Time
+	Type      enums.RoundEventType
+	Map       string
+	NickName  string
+	SteamID   string
+}
+
+type Round struct {
+	Map          string             `json:"map"`
+	Mode         enums.GameMode     `json:"mode"`
+	Start        time.Time          `json:"start"`
+	End          time.Time          `json:"end"`
+	Outcome      enums.RoundOutcome `json:"outcome"`
+	Participants []string           `json:"participants"`
+	Extracted    []string           `json:"extracted"`
+}
+
+type RoundStatistics struct {
+	Map                  string         `json:"map"`
+	Mode                 enums.GameMode `json:"mode"`
+	RoundsCount          int            `json:"rounds_count"`
+	Wins                 int            `json:"wins"`
+	WinRate              float64        `json:"win_rate"`
+	ExtractionRate       float64        `json:"extraction_rate"`
+	AverageRoundDuration time.Duration  `json:"average_round_duration"`
+}
+
+type RoundStatisticsList []RoundStatistics
@@ -0,0 +1,9 @@
+package dto
+
+import "time"
+
+type ServerRecords struct {
+	PeakConcurrency *ConcurrencyPeak `json:"peak_concurrency"`
+	LongestSession  *Session         `json:"longest_session"`
+	UpdatedAt       time.Time        `json:"updated_at"`
+}
@@ -0,0 +1,19 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/enums"
+)
+
+// StreamEvent is a piece of live server activity pushed to the stream subscribers.
+type StreamEvent struct {
+	Type      enums.StreamEventType `json:"type"`
+	TimeStamp time.Time             `json:"time_stamp"`
+	NickName  string                `json:"nick_name,omitempty"`
+	SteamID   string                `json:"steam_id,omitempty"`
+	Country   string                `json:"country,omitempty"`
+	Map       string                `json:"map,omitempty"`
+	Kill      *Kill                 `json:"kill,omitempty"`
+	Players   *PlayersInfo          `json:"players,omitempty"`
+}
@@ -0,0 +1,30 @@
+package dto
+
+import (
+	"iter"
+	"time"
+)
+
+// TimeRange bounds a query in time; nil bounds are open.
+type TimeRange struct {
+	From *time.Time
+	To   *time.Time
+}
+
+func (r TimeRange) Contains(timeStamp time.Time) bool {
+	return (r.From == nil || !timeStamp.Before(*r.From)) && (r.To == nil || !timeStamp.After(*r.To))
+}
+
+// Filter streams the log entries within the range, passing errors through.
+func (r TimeRange) Filter(logs iter.Seq2[*LogData, error]) iter.Seq2[*LogData, error] {
+	return func(yield func(*LogData, error) bool) {
+		for logData, err := range logs {
+			if err == nil && !r.Contains(logData.TimeStamp) {
+				continue
+			}
+			if !yield(logData, err) {
+				return
+			}
+		}
+	}
+}
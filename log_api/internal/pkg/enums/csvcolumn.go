@@ -0,0 +1,59 @@
+package enums
+
+// CSVSchemaVersion is the schema the CSV store is written in. Version 1 files predate the version line
+// and have no SteamID column.
+const CSVSchemaVersion = 2
+
+// CSVSchemaVersionPrefix starts the line above the header that carries the schema version, e.g. "#schema_version:2".
+const CSVSchemaVersionPrefix = "#schema_version:"
+
+const (
+	timeStampCSVColumn = "TimeStamp"
+	nickNameCSVColumn  = "NickName"
+	steamIDCSVColumn   = "SteamID"
+	actionCSVColumn    = "Action"
+	ipAddressCSVColumn = "IPAddress"
+	countryCSVColumn   = "Country"
+)
+
+//nolint:gochecknoglobals // enum can ignore it
+var CSVColumns csvColumns
+
+type CSVColumn string
+
+func (c CSVColumn) IsValid() bool {
+	switch c {
+	case timeStampCSVColumn, nickNameCSVColumn, steamIDCSVColumn, actionCSVColumn, ipAddressCSVColumn, countryCSVColumn:
+		return true
+	default:
+		return false
+	}
+}
+
+// IsRequired reports whether a row can not be read without the column.
+func (c CSVColumn) IsRequired() bool {
+	switch c {
+	case timeStampCSVColumn, nickNameCSVColumn, actionCSVColumn:
+		return true
+	default:
+		return false
+	}
+}
+
+func (c CSVColumn) String() string {
+	return string(c)
+}
+
+type csvColumns struct{}
+
+func (csvColumns) TimeStamp() CSVColumn { return timeStampCSVColumn }
+func (csvColumns) NickName() CSVColumn  { return nickNameCSVColumn }
+func (csvColumns) SteamID() CSVColumn   { return steamIDCSVColumn }
+func (csvColumns) Action() CSVColumn    { return actionCSVColumn }
+func (csvColumns) IPAddress() CSVColumn { return ipAddressCSVColumn }
+func (csvColumns) Country() CSVColumn   { return countryCSVColumn }
+
+// All lists the columns of the current schema in the order they are written.
+func (c csvColumns) All() []CSVColumn {
+	return []CSVColumn{c.TimeStamp(), c.NickName(), c.SteamID(), c.Action(), c.IPAddress(), c.Country()}
+}
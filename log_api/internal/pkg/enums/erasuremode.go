@@ -0,0 +1,31 @@
+package enums
+
+const (
+	deleteErasureMode       = "delete"
+	pseudonymiseErasureMode = "pseudonymise"
+)
+
+//nolint:gochecknoglobals // enum can ignore it
+var ErasureModes erasureModes
+
+// ErasureMode is what happens to the events of an erased player: they are either dropped,
+// or kept for the statistics under a pseudonym without the SteamID and IP address.
+type ErasureMode string
+
+func (m ErasureMode) IsValid() bool {
+	switch m {
+	case deleteErasureMode, pseudonymiseErasureMode:
+		return true
+	default:
+		return false
+	}
+}
+
+func (m ErasureMode) String() string {
+	return string(m)
+}
+
+type erasureModes struct{}
+
+func (erasureModes) Delete() ErasureMode       { return deleteErasureMode }
+func (erasureModes) Pseudonymise() ErasureMode { return pseudonymiseErasureMode }
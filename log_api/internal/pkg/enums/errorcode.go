@@ -0,0 +1,70 @@
+package enums
+
+import "net/http"
+
+const (
+	invalidRequestErrorCode  = "invalid_request"
+	unauthorizedErrorCode    = "unauthorized"
+	adminDisabledErrorCode   = "admin_disabled"
+	notFoundErrorCode        = "not_found"
+	rateLimitedErrorCode     = "rate_limited"
+	upstreamFailureErrorCode = "upstream_failure"
+	unavailableErrorCode     = "unavailable"
+	internalErrorCode        = "internal_error"
+)
+
+//nolint:gochecknoglobals // enum can ignore it
+var ErrorCodes errorCodes
+
+// ErrorCode is the machine-readable reason of a failed API response.
+type ErrorCode string
+
+func (c ErrorCode) String() string {
+	return string(c)
+}
+
+// Status is the HTTP status the error code is answered with.
+func (c ErrorCode) Status() int {
+	switch c {
+	case invalidRequestErrorCode:
+		return http.StatusBadRequest
+	case unauthorizedErrorCode:
+		return http.StatusUnauthorized
+	case adminDisabledErrorCode:
+		return http.StatusForbidden
+	case notFoundErrorCode:
+		return http.StatusNotFound
+	case rateLimitedErrorCode:
+		return http.StatusTooManyRequests
+	case upstreamFailureErrorCode:
+		return http.StatusBadGateway
+	case unavailableErrorCode:
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+type errorCodes struct{}
+
+func (errorCodes) InvalidRequest() ErrorCode  { return invalidRequestErrorCode }
+func (errorCodes) Unauthorized() ErrorCode    { return unauthorizedErrorCode }
+func (errorCodes) AdminDisabled() ErrorCode   { return adminDisabledErrorCode }
+func (errorCodes) NotFound() ErrorCode        { return notFoundErrorCode }
+func (errorCodes) RateLimited() ErrorCode     { return rateLimitedErrorCode }
+func (errorCodes) UpstreamFailure() ErrorCode { return upstreamFailureErrorCode }
+func (errorCodes) Unavailable() ErrorCode     { return unavailableErrorCode }
+func (errorCodes) Internal() ErrorCode        { return internalErrorCode }
+
+func (c errorCodes) All() []ErrorCode {
+	return []ErrorCode{
+		c.InvalidRequest(),
+		c.Unauthorized(),
+		c.AdminDisabled(),
+		c.NotFound(),
+		c.RateLimited(),
+		c.UpstreamFailure(),
+		c.Unavailable(),
+		c.Internal(),
+	}
+}
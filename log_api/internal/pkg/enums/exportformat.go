@@ -0,0 +1,42 @@
+package enums
+
+const (
+	csvExportFormat     = "csv"
+	ndjsonExportFormat  = "ndjson"
+	parquetExportFormat = "parquet"
+)
+
+//nolint:gochecknoglobals // enum can ignore it
+var ExportFormats exportFormats
+
+type ExportFormat string
+
+func (f ExportFormat) IsValid() bool {
+	switch f {
+	case csvExportFormat, ndjsonExportFormat, parquetExportFormat:
+		return true
+	default:
+		return false
+	}
+}
+
+func (f ExportFormat) String() string {
+	return string(f)
+}
+
+func (f ExportFormat) ContentType() string {
+	switch f {
+	case csvExportFormat:
+		return "text/csv"
+	case ndjsonExportFormat:
+		return "application/x-ndjson"
+	default:
+		return "application/vnd.apache.parquet"
+	}
+}
+
+type exportFormats struct{}
+
+func (exportFormats) CSV() ExportFormat     { return csvExportFormat }
+func (exportFormats) NDJSON() ExportFormat  { return ndjsonExportFormat }
+func (exportFormats) Parquet() ExportFormat { return parquetExportFormat }
@@ -1,10 +1,20 @@
 package enums
 
+import "time"
+
 const (
 	topTimeSpentGraphType     = "top-time-spent"
 	topCountriesGraphType     = "top-country"
 	playersInfoGraphType      = "players-info"
 	onlineStatisticsGraphType = "online-statistics"
+	peakConcurrencyGraphType  = "peak-concurrency"
+	roundsGraphType           = "rounds"
+	dailyActivesGraphType     = "daily-actives"
+
+	graphCacheKeyPrefix = "graph_data:"
+
+	liveGraphMaxAge   = 10 * time.Second
+	storedGraphMaxAge = time.Minute
 )
 
 //nolint:gochecknoglobals // enum can ignore it
@@ -14,7 +24,13 @@ type GraphType string
 
 func (gt GraphType) IsValid() bool {
 	switch gt {
-	case topTimeSpentGraphType, topCountriesGraphType, playersInfoGraphType, onlineStatisticsGraphType:
+	case topTimeSpentGraphType,
+		topCountriesGraphType,
+		playersInfoGraphType,
+		onlineStatisticsGraphType,
+		peakConcurrencyGraphType,
+		roundsGraphType,
+		dailyActivesGraphType:
 		return true
 	default:
 		return false
@@ -26,7 +42,34 @@ func (gt GraphType) String() string {
 }
 
 func (gt GraphType) CanCache() bool {
-	return gt != playersInfoGraphType
+	return !gt.IsLive()
+}
+
+// IsLive tells the graphs queried from the game server, rather than built from the stored logs.
+func (gt GraphType) IsLive() bool {
+	return gt == playersInfoGraphType
+}
+
+// HasRollups tells the graphs the rollups can answer, for the whole history.
+func (gt GraphType) HasRollups() bool {
+	switch gt {
+	case topTimeSpentGraphType, topCountriesGraphType, onlineStatisticsGraphType, dailyActivesGraphType:
+		return true
+	default:
+		return false
+	}
+}
+
+// MaxAge is how long clients may reuse the graph without asking whether it changed.
+func (gt GraphType) MaxAge() time.Duration {
+	if gt.IsLive() {
+		return liveGraphMaxAge
+	}
+	return storedGraphMaxAge
+}
+
+func (gt GraphType) CacheKey() string {
+	return graphCacheKeyPrefix + string(gt)
 }
 
 type graphTypes struct{}
@@ -35,3 +78,18 @@ func (graphTypes) TopTimeSpentGraphType() GraphType     { return topTimeSpentGra
 func (graphTypes) TopCountriesGraphType() GraphType     { return topCountriesGraphType }
 func (graphTypes) PlayersInfoGraphType() GraphType      { return playersInfoGraphType }
 func (graphTypes) OnlineStatisticsGraphType() GraphType { return onlineStatisticsGraphType }
+func (graphTypes) PeakConcurrencyGraphType() GraphType  { return peakConcurrencyGraphType }
+func (graphTypes) RoundsGraphType() GraphType           { return roundsGraphType }
+func (graphTypes) DailyActivesGraphType() GraphType     { return dailyActivesGraphType }
+
+func (gt graphTypes) All() []GraphType {
+	return []GraphType{
+		gt.TopTimeSpentGraphType(),
+		gt.TopCountriesGraphType(),
+		gt.PlayersInfoGraphType(),
+		gt.OnlineStatisticsGraphType(),
+		gt.PeakConcurrencyGraphType(),
+		gt.RoundsGraphType(),
+		gt.DailyActivesGraphType(),
+	}
+}
@@ -0,0 +1,32 @@
+package enums
+
+const (
+	plainIPMode    = "plain"
+	hashIPMode     = "hash"
+	truncateIPMode = "truncate"
+)
+
+//nolint:gochecknoglobals // enum can ignore it
+var IPModes ipModes
+
+// IPMode is how IP addresses are kept once the country lookup is done.
+type IPMode string
+
+func (m IPMode) IsValid() bool {
+	switch m {
+	case plainIPMode, hashIPMode, truncateIPMode:
+		return true
+	default:
+		return false
+	}
+}
+
+func (m IPMode) String() string {
+	return string(m)
+}
+
+type ipModes struct{}
+
+func (ipModes) Plain() IPMode    { return plainIPMode }
+func (ipModes) Hash() IPMode     { return hashIPMode }
+func (ipModes) Truncate() IPMode { return truncateIPMode }
@@ -0,0 +1,71 @@
+package enums
+
+const (
+	firstJoinNotificationTrigger       = "first-join"
+	playerCountNotificationTrigger     = "player-count"
+	allTimePeakNotificationTrigger     = "all-time-peak"
+	serverDownNotificationTrigger      = "server-down"
+	playerConnectedNotificationTrigger = "player-connected"
+
+	discordWebhookFormat = "discord"
+	genericWebhookFormat = "generic"
+)
+
+//nolint:gochecknoglobals // enum can ignore it
+var (
+	NotificationTriggers notificationTriggers
+	WebhookFormats       webhookFormats
+)
+
+type NotificationTrigger string
+
+func (t NotificationTrigger) IsValid() bool {
+	switch t {
+	case firstJoinNotificationTrigger,
+		playerCountNotificationTrigger,
+		allTimePeakNotificationTrigger,
+		serverDownNotificationTrigger,
+		playerConnectedNotificationTrigger:
+		return true
+	default:
+		return false
+	}
+}
+
+func (t NotificationTrigger) String() string {
+	return string(t)
+}
+
+type notificationTriggers struct{}
+
+// FirstJoin fires when a player joins an empty server
+func (notificationTriggers) FirstJoin() NotificationTrigger { return firstJoinNotificationTrigger }
+
+// PlayerCount fires when the number of players online rises to the rule threshold
+func (notificationTriggers) PlayerCount() NotificationTrigger { return playerCountNotificationTrigger }
+
+// AllTimePeak fires when the all-time concurrency record is beaten
+func (notificationTriggers) AllTimePeak() NotificationTrigger { return allTimePeakNotificationTrigger }
+
+// ServerDown fires when A2S has been unreachable for the rule number of polls in a row
+func (notificationTriggers) ServerDown() NotificationTrigger { return serverDownNotificationTrigger }
+
+// PlayerConnected fires when one of the rule players connects
+func (notificationTriggers) PlayerConnected() NotificationTrigger {
+	return playerConnectedNotificationTrigger
+}
+
+type WebhookFormat string
+
+func (f WebhookFormat) IsValid() bool {
+	return f == discordWebhookFormat || f == genericWebhookFormat
+}
+
+func (f WebhookFormat) String() string {
+	return string(f)
+}
+
+type webhookFormats struct{}
+
+func (webhookFormats) Discord() WebhookFormat { return discordWebhookFormat }
+func (webhookFormats) Generic() WebhookFormat { return genericWebhookFormat }
@@ -0,0 +1,32 @@
+package enums
+
+const (
+	readyReadinessStatus    = "ready"
+	degradedReadinessStatus = "degraded"
+	notReadyReadinessStatus = "not_ready"
+)
+
+//nolint:gochecknoglobals // enum can ignore it
+var ReadinessStatuses readinessStatuses
+
+// ReadinessStatus sums up the health checks: degraded means only non-critical ones failed.
+type ReadinessStatus string
+
+func (s ReadinessStatus) String() string {
+	return string(s)
+}
+
+// IsReady tells whether the API should receive traffic.
+func (s ReadinessStatus) IsReady() bool {
+	return s != notReadyReadinessStatus
+}
+
+type readinessStatuses struct{}
+
+func (readinessStatuses) Ready() ReadinessStatus    { return readyReadinessStatus }
+func (readinessStatuses) Degraded() ReadinessStatus { return degradedReadinessStatus }
+func (readinessStatuses) NotReady() ReadinessStatus { return notReadyReadinessStatus }
+
+func (s readinessStatuses) All() []ReadinessStatus {
+	return []ReadinessStatus{s.Ready(), s.Degraded(), s.NotReady()}
+}
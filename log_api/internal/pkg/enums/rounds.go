@@ -0,0 +1,94 @@
+package enums
+
+import "strings"
+
+const (
+	mapStartedRoundEvent      = "map-started"
+	roundStartedRoundEvent    = "round-started"
+	playerExtractedRoundEvent = "player-extracted"
+	roundWonRoundEvent        = "round-won"
+	roundLostRoundEvent       = "round-lost"
+
+	successRoundOutcome = "success"
+	failureRoundOutcome = "failure"
+	abortedRoundOutcome = "aborted"
+
+	objectiveGameMode = "objective"
+	survivalGameMode  = "survival"
+	unknownGameMode   = "unknown"
+
+	objectiveMapPrefix = "nmo_"
+	survivalMapPrefix  = "nms_"
+)
+
+//nolint:gochecknoglobals // enum can ignore it
+var (
+	RoundEventTypes roundEventTypes
+	RoundOutcomes   roundOutcomes
+	GameModes       gameModes
+)
+
+type RoundEventType string
+
+func (t RoundEventType) IsValid() bool {
+	switch t {
+	case mapStartedRoundEvent,
+		roundStartedRoundEvent,
+		playerExtractedRoundEvent,
+		roundWonRoundEvent,
+		roundLostRoundEvent:
+		return true
+	default:
+		return false
+	}
+}
+
+func (t RoundEventType) String() string {
+	return string(t)
+}
+
+type roundEventTypes struct{}
+
+func (roundEventTypes) MapStarted() RoundEventType      { return mapStartedRoundEvent }
+func (roundEventTypes) RoundStarted() RoundEventType    { return roundStartedRoundEvent }
+func (roundEventTypes) PlayerExtracted() RoundEventType { return playerExtractedRoundEvent }
+func (roundEventTypes) RoundWon() RoundEventType        { return roundWonRoundEvent }
+func (roundEventTypes) RoundLost() RoundEventType       { return roundLostRoundEvent }
+
+type RoundOutcome string
+
+func (o RoundOutcome) String() string {
+	return string(o)
+}
+
+type roundOutcomes struct{}
+
+func (roundOutcomes) Success() RoundOutcome { return successRoundOutcome }
+func (roundOutcomes) Failure() RoundOutcome { return failureRoundOutcome }
+
+// Aborted is a round cut short by a map change or a server restart.
+func (roundOutcomes) Aborted() RoundOutcome { return abortedRoundOutcome }
+
+type GameMode string
+
+func (m GameMode) String() string {
+	return string(m)
+}
+
+type gameModes struct{}
+
+func (gameModes) Objective() GameMode { return objectiveGameMode }
+func (gameModes) Survival() GameMode  { return survivalGameMode }
+func (gameModes) Unknown() GameMode   { return unknownGameMode }
+
+// ByMap derives the game mode from the NMRiH map naming convention (nmo_* / nms_*).
+func (gameModes) ByMap(mapName string) GameMode {
+	switch {
+	case strings.HasPrefix(mapName, objectiveMapPrefix):
+		return objectiveGameMode
+	case strings.HasPrefix(mapName, survivalMapPrefix):
+		return survivalGameMode
+	default:
+		return unknownGameMode
+	}
+}
@@ -0,0 +1,28 @@
+package enums
+
+const (
+	joinStreamEvent      = "join"
+	leaveStreamEvent     = "leave"
+	killStreamEvent      = "kill"
+	mapChangeStreamEvent = "map-change"
+	playersStreamEvent   = "players"
+)
+
+//nolint:gochecknoglobals // enum can ignore it
+var StreamEventTypes streamEventTypes
+
+type StreamEventType string
+
+func (t StreamEventType) String() string {
+	return string(t)
+}
+
+type streamEventTypes struct{}
+
+func (streamEventTypes) Join() StreamEventType      { return joinStreamEvent }
+func (streamEventTypes) Leave() StreamEventType     { return leaveStreamEvent }
+func (streamEventTypes) Kill() StreamEventType      { return killStreamEvent }
+func (streamEventTypes) MapChange() StreamEventType { return mapChangeStreamEvent }
+
+// Players is a periodic snapshot of who is online
+func (streamEventTypes) Players() StreamEventType { return playersStreamEvent }
@@ -0,0 +1,19 @@
+package admin
+
+import (
+	"context"
+
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/dto"
+)
+
+type rconClient interface {
+	Execute(ctx context.Context, command string) (string, error)
+}
+
+type auditLog interface {
+	Record(entry dto.AuditEntry) error
+}
+
+type ipAnonymiser interface {
+	AnonymiseText(text string) string
+}
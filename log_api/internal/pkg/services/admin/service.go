@@ -0,0 +1,111 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/dto"
+)
+
+const maxArgumentLength = 128
+
+var ErrInvalidArgument = errors.New("invalid argument")
+
+//nolint:gochecknoglobals // compiled once
+var mapNameRegex = regexp.MustCompile(`^[A-Za-z0-9_\-]+$`)
+
+// Service runs admin commands on the game server. Every command is written to the audit log, run or not.
+// The output is passed through the privacy configuration, as status lists the players' addresses.
+type Service struct {
+	rconClient   rconClient
+	auditLog     auditLog
+	ipAnonymiser ipAnonymiser
+}
+
+func NewService(rconClient rconClient, auditLog auditLog, ipAnonymiser ipAnonymiser) *Service {
+	return &Service{
+		rconClient:   rconClient,
+		auditLog:     auditLog,
+		ipAnonymiser: ipAnonymiser,
+	}
+}
+
+func (s *Service) Status(ctx context.Context, actor string) (string, error) {
+	return s.execute(ctx, actor, "status")
+}
+
+func (s *Service) Kick(ctx context.Context, actor, player, reason string) (string, error) {
+	if err := validateArgument("player", player, true); err != nil {
+		return "", s.reject(ctx, actor, "kick", err)
+	}
+	if err := validateArgument("reason", reason, false); err != nil {
+		return "", s.reject(ctx, actor, "kick", err)
+	}
+
+	command := fmt.Sprintf(`kick "%s"`, player)
+	if reason != "" {
+		command = fmt.Sprintf(`%s "%s"`, command, reason)
+	}
+	return s.execute(ctx, actor, command)
+}
+
+func (s *Service) ChangeLevel(ctx context.Context, actor, mapName string) (string, error) {
+	if !mapNameRegex.MatchString(mapName) || len(mapName) > maxArgumentLength {
+		return "", s.reject(ctx, actor, "changelevel", fmt.Errorf("%w: map name [%s]", ErrInvalidArgument, mapName))
+	}
+	return s.execute(ctx, actor, "changelevel "+mapName)
+}
+
+func (s *Service) Say(ctx context.Context, actor, message string) (string, error) {
+	if err := validateArgument("message", message, true); err != nil {
+		return "", s.reject(ctx, actor, "say", err)
+	}
+	return s.execute(ctx, actor, fmt.Sprintf(`say "%s"`, message))
+}
+
+func (s *Service) execute(ctx context.Context, actor, command string) (string, error) {
+	output, err := s.rconClient.Execute(ctx, command)
+	s.audit(ctx, actor, command, err)
+	return s.ipAnonymiser.AnonymiseText(output), err
+}
+
+// reject audits a command refused before reaching the server.
+func (s *Service) reject(ctx context.Context, actor, command string, err error) error {
+	s.audit(ctx, actor, command, err)
+	return err
+}
+
+func (s *Service) audit(ctx context.Context, actor, command string, err error) {
+	entry := dto.AuditEntry{
+		TimeStamp: time.Now(),
+		Actor:     actor,
+		Command:   command,
+		Success:   err == nil,
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	if auditErr := s.auditLog.Record(entry); auditErr != nil {
+		slog.ErrorContext(ctx, "Failed to audit an admin command", "command", command, "error", auditErr)
+	}
+}
+
+// validateArgument keeps an argument to a single quoted console token:
+// quotes, command separators and line breaks could smuggle in other commands.
+func validateArgument(name, value string, required bool) error {
+	if required && strings.TrimSpace(value) == "" {
+		return fmt.Errorf("%w: %s is required", ErrInvalidArgument, name)
+	}
+	if len(value) > maxArgumentLength {
+		return fmt.Errorf("%w: %s is longer than %d characters", ErrInvalidArgument, name, maxArgumentLength)
+	}
+	if strings.ContainsAny(value, "\";\r\n\x00") {
+		return fmt.Errorf("%w: %s contains forbidden characters", ErrInvalidArgument, name)
+	}
+	return nil
+}
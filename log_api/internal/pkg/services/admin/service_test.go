@@ -0,0 +1,134 @@
+package admin_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/dto"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/enums"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/services/admin"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/services/privacy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type rconClientStub struct {
+	commands []string
+	output   string
+	err      error
+}
+
+func (r *rconClientStub) Execute(_ context.Context, command string) (string, error) {
+	r.commands = append(r.commands, command)
+	if r.output != "" {
+		return r.output, r.err
+	}
+	return "ok", r.err
+}
+
+type auditLogStub struct {
+	entries []dto.AuditEntry
+}
+
+func (a *auditLogStub) Record(entry dto.AuditEntry) error {
+	a.entries = append(a.entries, entry)
+	return nil
+}
+
+func TestService_Commands(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		rconErr  error
+		run      func(service *admin.Service) (string, error)
+		command  string
+		auditErr string
+		assert   func(t *testing.T, err error)
+	}{
+		{
+			name: "success: kick with a reason",
+			run: func(service *admin.Service) (string, error) {
+				return service.Kick(context.Background(), "1.2.3.4", "Big Zeeb", "griefing")
+			},
+			command: `kick "Big Zeeb" "griefing"`,
+			assert:  func(t *testing.T, err error) { assert.NoError(t, err) },
+		},
+		{
+			name: "success: changelevel",
+			run: func(service *admin.Service) (string, error) {
+				return service.ChangeLevel(context.Background(), "1.2.3.4", "nmo_broadway")
+			},
+			command: "changelevel nmo_broadway",
+			assert:  func(t *testing.T, err error) { assert.NoError(t, err) },
+		},
+		{
+			name: "success: say",
+			run: func(service *admin.Service) (string, error) {
+				return service.Say(context.Background(), "1.2.3.4", "restart in 5 minutes")
+			},
+			command: `say "restart in 5 minutes"`,
+			assert:  func(t *testing.T, err error) { assert.NoError(t, err) },
+		},
+		{
+			name: "error: command smuggled into say is rejected",
+			run: func(service *admin.Service) (string, error) {
+				return service.Say(context.Background(), "1.2.3.4", `hi"; quit; say "`)
+			},
+			auditErr: "invalid argument: message contains forbidden characters",
+			assert:   func(t *testing.T, err error) { assert.ErrorIs(t, err, admin.ErrInvalidArgument) },
+		},
+		{
+			name: "error: invalid map name is rejected",
+			run: func(service *admin.Service) (string, error) {
+				return service.ChangeLevel(context.Background(), "1.2.3.4", "nmo_x;quit")
+			},
+			auditErr: "invalid argument: map name [nmo_x;quit]",
+			assert:   func(t *testing.T, err error) { assert.ErrorIs(t, err, admin.ErrInvalidArgument) },
+		},
+		{
+			name:     "error: rcon failure is audited",
+			rconErr:  errors.New("connection refused"),
+			run:      func(service *admin.Service) (string, error) { return service.Status(context.Background(), "1.2.3.4") },
+			command:  "status",
+			auditErr: "connection refused",
+			assert:   func(t *testing.T, err error) { assert.Error(t, err) },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			rconClient := &rconClientStub{err: tt.rconErr}
+			auditLog := &auditLogStub{}
+			service := admin.NewService(rconClient, auditLog, privacy.NewService(*privacy.NewConfig(enums.IPModes.Plain(), "")))
+
+			_, err := tt.run(service)
+			tt.assert(t, err)
+
+			if tt.command != "" {
+				assert.Equal(t, []string{tt.command}, rconClient.commands)
+			} else {
+				assert.Empty(t, rconClient.commands)
+			}
+			require.Len(t, auditLog.entries, 1)
+			assert.Equal(t, "1.2.3.4", auditLog.entries[0].Actor)
+			assert.Equal(t, tt.auditErr == "", auditLog.entries[0].Success)
+			assert.Equal(t, tt.auditErr, auditLog.entries[0].Error)
+		})
+	}
+}
+
+func TestService_StatusAnonymisesIPs(t *testing.T) {
+	t.Parallel()
+	rconClient := &rconClientStub{output: `# 2 "Big Zeeb" [U:1:42] 05:12 35 0 active 1.2.3.4:27005`}
+	service := admin.NewService(
+		rconClient,
+		&auditLogStub{},
+		privacy.NewService(*privacy.NewConfig(enums.IPModes.Truncate(), "")),
+	)
+
+	output, err := service.Status(context.Background(), "127.0.0.1")
+	require.NoError(t, err)
+	assert.Equal(t, `# 2 "Big Zeeb" [U:1:42] 05:12 35 0 active 1.2.3.0:27005`, output)
+}
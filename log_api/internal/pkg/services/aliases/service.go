@@ -0,0 +1,234 @@
+package aliases
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/dto"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/tools"
+)
+
+const aliasesFileName = "aliases.json"
+
+var ErrPlayerNotFound = errors.New("player not found")
+
+// index maps SteamID -> nickname -> alias.
+type index map[string]map[string]*dto.Alias
+
+type Service struct {
+	config config
+	mu     sync.Mutex
+}
+
+func NewService(config config) *Service {
+	return &Service{config: config}
+}
+
+// Index adds every SteamID/nickname pair of the batch to the alias index.
+func (s *Service) Index(_ context.Context, batch *dto.ParseBatch) error {
+	if len(batch.Logs) == 0 {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	idx, err := s.load()
+	if err != nil {
+		return err
+	}
+
+	for _, logEntry := range batch.Logs {
+		if logEntry.SteamID == "" || logEntry.NickName == "" {
+			continue
+		}
+		nickNames, ok := idx[logEntry.SteamID]
+		if !ok {
+			nickNames = make(map[string]*dto.Alias)
+			idx[logEntry.SteamID] = nickNames
+		}
+		alias, ok := nickNames[logEntry.NickName]
+		if !ok {
+			nickNames[logEntry.NickName] = &dto.Alias{
+				NickName:  logEntry.NickName,
+				FirstSeen: logEntry.TimeStamp,
+				LastSeen:  logEntry.TimeStamp,
+			}
+			continue
+		}
+		if logEntry.TimeStamp.Before(alias.FirstSeen) {
+			alias.FirstSeen = logEntry.TimeStamp
+		}
+		if logEntry.TimeStamp.After(alias.LastSeen) {
+			alias.LastSeen = logEntry.TimeStamp
+		}
+	}
+
+	return s.save(idx)
+}
+
+func (s *Service) GetAliases(steamID string) (*dto.PlayerAliases, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	idx, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+
+	nickNames, ok := idx[steamID]
+	if !ok {
+		return nil, ErrPlayerNotFound
+	}
+	return toPlayerAliases(steamID, nickNames), nil
+}
+
+// Search resolves a partial nickname (case-insensitive) to candidate players.
+// Exact matches go first, then prefix matches, then the rest; ties are broken by the most recently seen player.
+func (s *Service) Search(nickName string, limit int) ([]*dto.PlayerAliases, error) {
+	query := strings.ToLower(strings.TrimSpace(nickName))
+	if query == "" {
+		return nil, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	idx, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+
+	type candidate struct {
+		player *dto.PlayerAliases
+		rank   int
+	}
+	var candidates []candidate
+	for steamID, nickNames := range idx {
+		rank := -1
+		for alias := range nickNames {
+			alias = strings.ToLower(alias)
+			switch {
+			case alias == query:
+				rank = max(rank, 2) //nolint:mnd // exact match
+			case strings.HasPrefix(alias, query):
+				rank = max(rank, 1)
+			case strings.Contains(alias, query):
+				rank = max(rank, 0)
+			}
+		}
+		if rank < 0 {
+			continue
+		}
+		candidates = append(candidates, candidate{player: toPlayerAliases(steamID, nickNames), rank: rank})
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].rank != candidates[j].rank {
+			return candidates[i].rank > candidates[j].rank
+		}
+		return candidates[i].player.LastSeen.After(candidates[j].player.LastSeen)
+	})
+
+	if limit > 0 && len(candidates) > limit {
+		candidates = candidates[:limit]
+	}
+
+	players := make([]*dto.PlayerAliases, 0, len(candidates))
+	for _, c := range candidates {
+		players = append(players, c.player)
+	}
+	return players, nil
+}
+
+// Erase drops the player from the nickname history: the SteamID with every nickname it used, or the nicknames
+// alone when the player is erased by nickname. It returns how many aliases were dropped.
+func (s *Service) Erase(_ context.Context, target dto.ErasureTarget) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	idx, err := s.load()
+	if err != nil {
+		return 0, err
+	}
+
+	var erased int
+	for steamID, nickNames := range idx {
+		if target.SteamID != "" {
+			if steamID == target.SteamID {
+				erased += len(nickNames)
+				delete(idx, steamID)
+			}
+			continue
+		}
+		for nickName := range nickNames {
+			if target.HasNickName(nickName) {
+				delete(nickNames, nickName)
+				erased++
+			}
+		}
+		if len(nickNames) == 0 {
+			delete(idx, steamID)
+		}
+	}
+	if erased == 0 {
+		return 0, nil
+	}
+
+	return erased, s.save(idx)
+}
+
+func toPlayerAliases(steamID string, nickNames map[string]*dto.Alias) *dto.PlayerAliases {
+	player := &dto.PlayerAliases{
+		SteamID: steamID,
+		Aliases: make([]dto.Alias, 0, len(nickNames)),
+	}
+	for _, alias := range nickNames {
+		player.Aliases = append(player.Aliases, *alias)
+		if alias.LastSeen.After(player.LastSeen) {
+			player.LastSeen = alias.LastSeen
+		}
+	}
+	sort.Slice(player.Aliases, func(i, j int) bool {
+		return player.Aliases[i].LastSeen.After(player.Aliases[j].LastSeen)
+	})
+	return player
+}
+
+func (s *Service) load() (index, error) {
+	idx := make(index)
+
+	content, err := os.ReadFile(s.filePath())
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return idx, nil
+		}
+		return nil, fmt.Errorf("failed to read alias index: %w", err)
+	}
+	if err := json.Unmarshal(content, &idx); err != nil {
+		return nil, fmt.Errorf("failed to decode alias index: %w", err)
+	}
+	return idx, nil
+}
+
+func (s *Service) save(idx index) error {
+	content, err := json.Marshal(idx)
+	if err != nil {
+		return fmt.Errorf("failed to encode alias index: %w", err)
+	}
+	if err := tools.WriteFileAtomic(s.filePath(), content, 0o600); err != nil {
+		return fmt.Errorf("failed to save alias index: %w", err)
+	}
+	return nil
+}
+
+func (s *Service) filePath() string {
+	return filepath.Join(s.config.StorageDirectory, aliasesFileName)
+}
@@ -0,0 +1,114 @@
+package aliases_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/dto"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/enums"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/services/aliases"
+	"github.com/stretchr/testify/assert"
+)
+
+func newIndexedService(t *testing.T) *aliases.Service {
+	base := time.Date(2025, 3, 15, 12, 0, 0, 0, time.UTC)
+	service := aliases.NewService(*aliases.NewConfig(t.TempDir()))
+	assert.NoError(t, service.Index(context.Background(), &dto.ParseBatch{Logs: []dto.LogData{
+		{TimeStamp: base, NickName: "Griefer", SteamID: "[U:1:1]", Action: enums.Actions.Connected()},
+		{TimeStamp: base.Add(time.Hour), NickName: "Griefer", SteamID: "[U:1:1]", Action: enums.Actions.Disconnected()},
+		{TimeStamp: base.Add(2 * time.Hour), NickName: "Angel", SteamID: "[U:1:1]", Action: enums.Actions.Connected()},
+		{TimeStamp: base.Add(3 * time.Hour), NickName: "grief", SteamID: "[U:1:2]", Action: enums.Actions.Connected()},
+		{TimeStamp: base.Add(4 * time.Hour), NickName: "NoID", Action: enums.Actions.Connected()},
+	}}))
+	assert.NoError(t, service.Index(context.Background(), &dto.ParseBatch{Logs: []dto.LogData{
+		{TimeStamp: base.Add(5 * time.Hour), NickName: "Griefer", SteamID: "[U:1:1]", Action: enums.Actions.Entered()},
+	}}))
+	return service
+}
+
+func TestService_GetAliases(t *testing.T) {
+	t.Parallel()
+	base := time.Date(2025, 3, 15, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		steamID string
+		assert  func(t *testing.T, playerAliases *dto.PlayerAliases, err error)
+	}{
+		{
+			name:    "success: aliases with first and last seen",
+			steamID: "[U:1:1]",
+			assert: func(t *testing.T, playerAliases *dto.PlayerAliases, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, base.Add(5*time.Hour), playerAliases.LastSeen)
+				assert.Equal(t, []dto.Alias{
+					{NickName: "Griefer", FirstSeen: base, LastSeen: base.Add(5 * time.Hour)},
+					{NickName: "Angel", FirstSeen: base.Add(2 * time.Hour), LastSeen: base.Add(2 * time.Hour)},
+				}, playerAliases.Aliases)
+			},
+		},
+		{
+			name:    "failed: unknown player",
+			steamID: "[U:1:404]",
+			assert: func(t *testing.T, playerAliases *dto.PlayerAliases, err error) {
+				assert.ErrorIs(t, err, aliases.ErrPlayerNotFound)
+				assert.Nil(t, playerAliases)
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			service := newIndexedService(t)
+			playerAliases, err := service.GetAliases(test.steamID)
+			test.assert(t, playerAliases, err)
+		})
+	}
+}
+
+func TestService_Search(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name   string
+		query  string
+		assert func(t *testing.T, players []*dto.PlayerAliases, err error)
+	}{
+		{
+			name:  "success: exact match ranks first",
+			query: "GRIEF",
+			assert: func(t *testing.T, players []*dto.PlayerAliases, err error) {
+				assert.NoError(t, err)
+				assert.Len(t, players, 2)
+				assert.Equal(t, "[U:1:2]", players[0].SteamID)
+				assert.Equal(t, "[U:1:1]", players[1].SteamID)
+			},
+		},
+		{
+			name:  "success: substring of an old alias",
+			query: "nge",
+			assert: func(t *testing.T, players []*dto.PlayerAliases, err error) {
+				assert.NoError(t, err)
+				assert.Len(t, players, 1)
+				assert.Equal(t, "[U:1:1]", players[0].SteamID)
+			},
+		},
+		{
+			name:  "success: nothing found",
+			query: "nobody",
+			assert: func(t *testing.T, players []*dto.PlayerAliases, err error) {
+				assert.NoError(t, err)
+				assert.Empty(t, players)
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			service := newIndexedService(t)
+			players, err := service.Search(test.query, 10)
+			test.assert(t, players, err)
+		})
+	}
+}
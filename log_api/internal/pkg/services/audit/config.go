@@ -0,0 +1,12 @@
+package audit
+
+type config struct {
+	StorageDirectory string
+}
+
+//nolint:revive // no sense in export here
+func NewConfig(storageDirectory string) *config {
+	return &config{
+		StorageDirectory: storageDirectory,
+	}
+}
@@ -0,0 +1,340 @@
+package chat
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/dto"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/enums"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/tools"
+)
+
+const (
+	chatFilePrefix     = "chat_"
+	chatFileSuffix     = ".jsonl"
+	chatFileTimeFormat = "2006-01"
+	maxLineSize        = 1024 * 1024
+)
+
+// messageKey identifies a chat message regardless of the location its time stamp was decoded in.
+type messageKey struct {
+	unixNano int64
+	nickName string
+	steamID  string
+	teamOnly bool
+	message  string
+}
+
+type Service struct {
+	config config
+	mu     sync.RWMutex
+}
+
+func NewService(config config) *Service {
+	return &Service{config: config}
+}
+
+// Index adds the chat messages of the batch to per-month JSON Lines files.
+// Messages already stored are skipped, so re-parsing the same logs does not duplicate them,
+// and the ones of a backfilled earlier period are merged in order.
+func (s *Service) Index(_ context.Context, batch *dto.ParseBatch) error {
+	if len(batch.Chat) == 0 {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	messages := slices.Clone(batch.Chat)
+	sort.SliceStable(messages, func(i, j int) bool {
+		return messages[i].TimeStamp.Before(messages[j].TimeStamp)
+	})
+
+	byFile := make(map[string][]dto.ChatMessage)
+	for _, message := range messages {
+		fileName := chatFilePrefix + message.TimeStamp.Format(chatFileTimeFormat) + chatFileSuffix
+		byFile[fileName] = append(byFile[fileName], message)
+	}
+
+	for fileName, fileMessages := range byFile {
+		if err := s.mergeMessages(fileName, fileMessages); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Search returns chat messages matching the query, newest first.
+func (s *Service) Search(query dto.ChatQuery) (*dto.ChatPage, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	fileNames, err := s.listFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	text := strings.ToLower(query.Text)
+	nickName := strings.ToLower(query.NickName)
+
+	var matches []dto.ChatMessage
+	for _, fileName := range fileNames {
+		if !s.fileInRange(fileName, query.From, query.To) {
+			continue
+		}
+		err := s.readMessages(fileName, func(message dto.ChatMessage) {
+			if query.From != nil && message.TimeStamp.Before(*query.From) {
+				return
+			}
+			if query.To != nil && message.TimeStamp.After(*query.To) {
+				return
+			}
+			if query.SteamID != "" && message.SteamID != query.SteamID {
+				return
+			}
+			if nickName != "" && !strings.Contains(strings.ToLower(message.NickName), nickName) {
+				return
+			}
+			if text != "" && !strings.Contains(strings.ToLower(message.Message), text) {
+				return
+			}
+			matches = append(matches, message)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].TimeStamp.After(matches[j].TimeStamp)
+	})
+
+	page := &dto.ChatPage{
+		Messages: []dto.ChatMessage{},
+		Page:     query.Page,
+		PageSize: query.PageSize,
+		Total:    len(matches),
+	}
+	offset := (query.Page - 1) * query.PageSize
+	if offset < len(matches) {
+		page.Messages = matches[offset:min(offset+query.PageSize, len(matches))]
+	}
+
+	return page, nil
+}
+
+// Erase drops the player's messages, or keeps them under the pseudonym without the SteamID,
+// and returns how many messages it changed.
+func (s *Service) Erase(_ context.Context, target dto.ErasureTarget) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	fileNames, err := s.listFiles()
+	if err != nil {
+		return 0, err
+	}
+
+	var erased int
+	for _, fileName := range fileNames {
+		var (
+			messages   []dto.ChatMessage
+			fileErased int
+		)
+		err := s.readMessages(fileName, func(message dto.ChatMessage) {
+			if !target.Matches(message.NickName, message.SteamID) {
+				messages = append(messages, message)
+				return
+			}
+			fileErased++
+			if target.Mode == enums.ErasureModes.Pseudonymise() {
+				message.NickName = target.Pseudonym
+				message.SteamID = ""
+				messages = append(messages, message)
+			}
+		})
+		if err != nil {
+			return 0, err
+		}
+		if fileErased == 0 {
+			continue
+		}
+		if err := s.writeMessages(fileName, messages); err != nil {
+			return 0, err
+		}
+		erased += fileErased
+	}
+
+	return erased, nil
+}
+
+func (s *Service) fileInRange(fileName string, from, to *time.Time) bool {
+	monthStart, err := time.Parse(
+		chatFileTimeFormat,
+		strings.TrimSuffix(strings.TrimPrefix(fileName, chatFilePrefix), chatFileSuffix),
+	)
+	if err != nil {
+		return false
+	}
+	monthEnd := monthStart.AddDate(0, 1, 0)
+	if from != nil && !monthEnd.After(*from) {
+		return false
+	}
+	if to != nil && monthStart.After(*to) {
+		return false
+	}
+	return true
+}
+
+// mergeMessages adds the time-ordered messages a chat file does not hold yet. When they all come after the stored
+// ones they are appended, otherwise the file is rewritten in order.
+func (s *Service) mergeMessages(fileName string, messages []dto.ChatMessage) error {
+	var (
+		stored        []dto.ChatMessage
+		lastTimeStamp time.Time
+	)
+	// A message repeated within the same second is kept as often as it was said
+	counts := make(map[messageKey]int)
+	err := s.readMessages(fileName, func(message dto.ChatMessage) {
+		stored = append(stored, message)
+		counts[keyOf(message)]++
+		if message.TimeStamp.After(lastTimeStamp) {
+			lastTimeStamp = message.TimeStamp
+		}
+	})
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	var (
+		missing []dto.ChatMessage
+		inOrder = true
+	)
+	for _, message := range messages {
+		if key := keyOf(message); counts[key] > 0 {
+			counts[key]--
+			continue
+		}
+		missing = append(missing, message)
+		inOrder = inOrder && !message.TimeStamp.Before(lastTimeStamp)
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	if inOrder {
+		return s.appendMessages(fileName, missing)
+	}
+
+	merged := append(stored, missing...)
+	sort.SliceStable(merged, func(i, j int) bool {
+		return merged[i].TimeStamp.Before(merged[j].TimeStamp)
+	})
+	return s.writeMessages(fileName, merged)
+}
+
+func keyOf(message dto.ChatMessage) messageKey {
+	return messageKey{
+		unixNano: message.TimeStamp.UnixNano(),
+		nickName: message.NickName,
+		steamID:  message.SteamID,
+		teamOnly: message.TeamOnly,
+		message:  message.Message,
+	}
+}
+
+// listFiles returns chat file names, newest month first.
+func (s *Service) listFiles() ([]string, error) {
+	entries, err := os.ReadDir(s.directory())
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read chat directory: %w", err)
+	}
+
+	var fileNames []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasPrefix(entry.Name(), chatFilePrefix) &&
+			strings.HasSuffix(entry.Name(), chatFileSuffix) {
+			fileNames = append(fileNames, entry.Name())
+		}
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(fileNames)))
+	return fileNames, nil
+}
+
+func (s *Service) readMessages(fileName string, fn func(message dto.ChatMessage)) error {
+	file, err := os.Open(filepath.Join(s.directory(), fileName))
+	if err != nil {
+		return fmt.Errorf("failed to open chat file %s: %w", fileName, err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), maxLineSize)
+	for scanner.Scan() {
+		var message dto.ChatMessage
+		if err := json.Unmarshal(scanner.Bytes(), &message); err != nil {
+			return fmt.Errorf("failed to decode chat message in %s: %w", fileName, err)
+		}
+		fn(message)
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read chat file %s: %w", fileName, err)
+	}
+	return nil
+}
+
+func (s *Service) appendMessages(fileName string, messages []dto.ChatMessage) error {
+	if err := os.MkdirAll(s.directory(), os.ModePerm); err != nil {
+		return fmt.Errorf("failed to create chat directory: %w", err)
+	}
+
+	file, err := os.OpenFile(filepath.Join(s.directory(), fileName), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open chat file %s: %w", fileName, err)
+	}
+	defer file.Close()
+
+	writer := bufio.NewWriter(file)
+	encoder := json.NewEncoder(writer)
+	for _, message := range messages {
+		if err := encoder.Encode(message); err != nil {
+			return fmt.Errorf("failed to write chat message: %w", err)
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		return fmt.Errorf("failed to flush chat file %s: %w", fileName, err)
+	}
+	return nil
+}
+
+// writeMessages replaces the content of a chat file.
+func (s *Service) writeMessages(fileName string, messages []dto.ChatMessage) error {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	for _, message := range messages {
+		if err := encoder.Encode(message); err != nil {
+			return fmt.Errorf("failed to write chat message: %w", err)
+		}
+	}
+	if err := tools.WriteFileAtomic(filepath.Join(s.directory(), fileName), buf.Bytes(), 0o600); err != nil {
+		return fmt.Errorf("failed to rewrite chat file %s: %w", fileName, err)
+	}
+	return nil
+}
+
+func (s *Service) directory() string {
+	return filepath.Join(s.config.StorageDirectory, "chat")
+}
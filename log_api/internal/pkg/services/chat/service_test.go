@@ -0,0 +1,109 @@
+package chat_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/dto"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/services/chat"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/tools"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestService_Search(t *testing.T) {
+	t.Parallel()
+	base := time.Date(2025, 3, 31, 23, 0, 0, 0, time.UTC)
+	messages := []dto.ChatMessage{
+		{TimeStamp: base, NickName: "Griefer", SteamID: "[U:1:1]", Message: "you are all noobs"},
+		{TimeStamp: base.Add(2 * time.Hour), NickName: "Angel", SteamID: "[U:1:2]", Message: "gg", TeamOnly: true},
+		{TimeStamp: base.Add(3 * time.Hour), NickName: "Griefer", SteamID: "[U:1:1]", Message: "NOOBS again"},
+	}
+	tests := []struct {
+		name   string
+		query  dto.ChatQuery
+		assert func(t *testing.T, page *dto.ChatPage, err error)
+	}{
+		{
+			name:  "success: text search is case-insensitive and newest first",
+			query: dto.ChatQuery{Text: "noob", Page: 1, PageSize: 10},
+			assert: func(t *testing.T, page *dto.ChatPage, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, 2, page.Total)
+				assert.Equal(t, "NOOBS again", page.Messages[0].Message)
+				assert.Equal(t, "you are all noobs", page.Messages[1].Message)
+			},
+		},
+		{
+			name: "success: nick and time range across month files",
+			query: dto.ChatQuery{
+				NickName: "grief",
+				From:     tools.ToPtr(base.Add(time.Hour)),
+				Page:     1,
+				PageSize: 10,
+			},
+			assert: func(t *testing.T, page *dto.ChatPage, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, 1, page.Total)
+				assert.Equal(t, "NOOBS again", page.Messages[0].Message)
+			},
+		},
+		{
+			name:  "success: pagination",
+			query: dto.ChatQuery{Page: 2, PageSize: 2},
+			assert: func(t *testing.T, page *dto.ChatPage, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, 3, page.Total)
+				assert.Len(t, page.Messages, 1)
+				assert.Equal(t, "you are all noobs", page.Messages[0].Message)
+			},
+		},
+		{
+			name:  "success: page out of range",
+			query: dto.ChatQuery{Page: 5, PageSize: 2},
+			assert: func(t *testing.T, page *dto.ChatPage, err error) {
+				assert.NoError(t, err)
+				assert.Empty(t, page.Messages)
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			service := chat.NewService(*chat.NewConfig(t.TempDir()))
+			assert.NoError(t, service.Index(context.Background(), &dto.ParseBatch{Chat: messages}))
+			// The same batch parsed twice must not be stored twice
+			assert.NoError(t, service.Index(context.Background(), &dto.ParseBatch{Chat: messages}))
+			page, err := service.Search(test.query)
+			test.assert(t, page, err)
+		})
+	}
+}
+
+func TestService_IndexBackfill(t *testing.T) {
+	t.Parallel()
+	base := time.Date(2025, 3, 10, 20, 0, 0, 0, time.UTC)
+	service := chat.NewService(*chat.NewConfig(t.TempDir()))
+
+	live := []dto.ChatMessage{
+		{TimeStamp: base.Add(2 * time.Hour), NickName: "Angel", SteamID: "[U:1:2]", Message: "gg"},
+	}
+	backfill := []dto.ChatMessage{
+		{TimeStamp: base, NickName: "Griefer", SteamID: "[U:1:1]", Message: "gg"},
+		{TimeStamp: base, NickName: "Griefer", SteamID: "[U:1:1]", Message: "gg"},
+		{TimeStamp: base.Add(time.Hour), NickName: "Angel", SteamID: "[U:1:2]", Message: "wait for me"},
+	}
+	assert.NoError(t, service.Index(context.Background(), &dto.ParseBatch{Chat: live}))
+	assert.NoError(t, service.Index(context.Background(), &dto.ParseBatch{Chat: backfill}))
+	// Importing the same period again adds nothing
+	assert.NoError(t, service.Index(context.Background(), &dto.ParseBatch{Chat: backfill}))
+
+	page, err := service.Search(dto.ChatQuery{Page: 1, PageSize: 10})
+	assert.NoError(t, err)
+	assert.Equal(t, 4, page.Total)
+	assert.Equal(t, []string{"gg", "wait for me", "gg", "gg"}, []string{
+		page.Messages[0].Message, page.Messages[1].Message, page.Messages[2].Message, page.Messages[3].Message,
+	})
+	assert.Equal(t, base.Add(2*time.Hour), page.Messages[0].TimeStamp)
+}
@@ -4,13 +4,19 @@ import (
 	"bytes"
 	"encoding/csv"
 	"fmt"
-	"log"
+	"io"
+	"log/slog"
+	"slices"
 	"sort"
+	"strconv"
 	"time"
 
 	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/dto"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/enums"
 )
 
+const timeStampFormat = "2006-01-02 15:04:05"
+
 type CSVGenerator struct{}
 
 func NewCSVGenerator() *CSVGenerator {
@@ -19,31 +25,24 @@ func NewCSVGenerator() *CSVGenerator {
 
 func (c *CSVGenerator) Generate(logData []dto.LogData) ([]byte, *time.Time, error) {
 	if len(logData) == 0 {
-		log.Printf("no log data to generate CSV\n")
+		slog.Debug("No log data to generate CSV")
 		return nil, nil, nil
 	}
 
 	var buf bytes.Buffer
 	writer := csv.NewWriter(&buf)
 
-	header := []string{"TimeStamp", "NickName", "Action", "IPAddress", "Country"}
-	if err := writer.Write(header); err != nil {
-		return nil, nil, fmt.Errorf("failed to write CSV header: %w", err)
+	if err := writeHeader(writer, true); err != nil {
+		return nil, nil, err
 	}
 
-	sort.Slice(logData, func(i, j int) bool {
+	logData = slices.Clone(logData)
+	sort.SliceStable(logData, func(i, j int) bool {
 		return logData[i].TimeStamp.Before(logData[j].TimeStamp)
 	})
 
 	for _, data := range logData {
-		row := []string{
-			data.TimeStamp.Format("2006-01-02 15:04:05"),
-			data.NickName,
-			data.Action.String(),
-			data.IPAddress,
-			data.Country,
-		}
-		if err := writer.Write(row); err != nil {
+		if err := writer.Write(row(data)); err != nil {
 			return nil, nil, fmt.Errorf("failed to write CSV row: %w", err)
 		}
 	}
@@ -55,3 +54,78 @@ func (c *CSVGenerator) Generate(logData []dto.LogData) ([]byte, *time.Time, erro
 
 	return buf.Bytes(), &logData[len(logData)-1].TimeStamp, nil
 }
+
+// Writer streams log data in the generated files column layout.
+type Writer struct {
+	writer        *csv.Writer
+	versioned     bool
+	headerWritten bool
+}
+
+// NewWriter writes files for the CSV store, which start with the schema version line.
+func (c *CSVGenerator) NewWriter(w io.Writer) *Writer {
+	return &Writer{writer: csv.NewWriter(w), versioned: true}
+}
+
+// NewExportWriter writes the same columns without the schema version line, for tools that expect plain CSV.
+func (c *CSVGenerator) NewExportWriter(w io.Writer) *Writer {
+	return &Writer{writer: csv.NewWriter(w)}
+}
+
+func (w *Writer) Write(data dto.LogData) error {
+	if !w.headerWritten {
+		if err := writeHeader(w.writer, w.versioned); err != nil {
+			return err
+		}
+		w.headerWritten = true
+	}
+	if err := w.writer.Write(row(data)); err != nil {
+		return fmt.Errorf("failed to write CSV row: %w", err)
+	}
+	return nil
+}
+
+// Flush writes the buffered rows, and the header if there were none.
+func (w *Writer) Flush() error {
+	if !w.headerWritten {
+		if err := writeHeader(w.writer, w.versioned); err != nil {
+			return err
+		}
+		w.headerWritten = true
+	}
+	w.writer.Flush()
+	if err := w.writer.Error(); err != nil {
+		return fmt.Errorf("failed to flush CSV writer: %w", err)
+	}
+	return nil
+}
+
+func writeHeader(writer *csv.Writer, versioned bool) error {
+	if versioned {
+		if err := writer.Write([]string{enums.CSVSchemaVersionPrefix + strconv.Itoa(enums.CSVSchemaVersion)}); err != nil {
+			return fmt.Errorf("failed to write CSV schema version: %w", err)
+		}
+	}
+
+	columns := enums.CSVColumns.All()
+	header := make([]string, 0, len(columns))
+	for _, column := range columns {
+		header = append(header, column.String())
+	}
+	if err := writer.Write(header); err != nil {
+		return fmt.Errorf("failed to write CSV header: %w", err)
+	}
+	return nil
+}
+
+// row follows the order of enums.CSVColumns.All.
+func row(data dto.LogData) []string {
+	return []string{
+		data.TimeStamp.Format(timeStampFormat),
+		data.NickName,
+		data.SteamID,
+		data.Action.String(),
+		data.IPAddress,
+		data.Country,
+	}
+}
@@ -1,6 +1,7 @@
 package csvgenerator_test
 
 import (
+	"slices"
 	"testing"
 	"time"
 
@@ -40,15 +41,17 @@ func TestCSVGenerator_Generate(t *testing.T) {
 				assert.Equal(
 					t,
 					[]byte{
-						0x54, 0x69, 0x6d, 0x65, 0x53, 0x74, 0x61, 0x6d, 0x70, 0x2c, 0x4e, 0x69, 0x63, 0x6b, 0x4e, 0x61,
-						0x6d, 0x65, 0x2c, 0x41, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x2c, 0x49, 0x50, 0x41, 0x64, 0x64, 0x72,
-						0x65, 0x73, 0x73, 0x2c, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x72, 0x79, 0xa, 0x32, 0x30, 0x30, 0x30,
-						0x2d, 0x31, 0x32, 0x2d, 0x33, 0x31, 0x20, 0x30, 0x30, 0x3a, 0x30, 0x30, 0x3a, 0x30, 0x30, 0x2c,
-						0x74, 0x65, 0x73, 0x74, 0x2c, 0x63, 0x6f, 0x6e, 0x6e, 0x65, 0x63, 0x74, 0x65, 0x64, 0x2c, 0x31,
-						0x32, 0x33, 0x2e, 0x32, 0x33, 0x34, 0x2e, 0x31, 0x32, 0x33, 0x2e, 0x32, 0x33, 0x34, 0x2c, 0x52,
-						0x55, 0xa, 0x32, 0x30, 0x30, 0x31, 0x2d, 0x31, 0x32, 0x2d, 0x33, 0x31, 0x20, 0x30, 0x31, 0x3a,
-						0x30, 0x30, 0x3a, 0x30, 0x30, 0x2c, 0x74, 0x65, 0x73, 0x74, 0x2c, 0x64, 0x69, 0x73, 0x63, 0x6f,
-						0x6e, 0x6e, 0x65, 0x63, 0x74, 0x65, 0x64, 0x2c, 0x2c, 0xa,
+						0x23, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x5f, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x3a,
+						0x32, 0xa, 0x54, 0x69, 0x6d, 0x65, 0x53, 0x74, 0x61, 0x6d, 0x70, 0x2c, 0x4e, 0x69, 0x63, 0x6b,
+						0x4e, 0x61, 0x6d, 0x65, 0x2c, 0x53, 0x74, 0x65, 0x61, 0x6d, 0x49, 0x44, 0x2c, 0x41, 0x63, 0x74,
+						0x69, 0x6f, 0x6e, 0x2c, 0x49, 0x50, 0x41, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x2c, 0x43, 0x6f,
+						0x75, 0x6e, 0x74, 0x72, 0x79, 0xa, 0x32, 0x30, 0x30, 0x30, 0x2d, 0x31, 0x32, 0x2d, 0x33, 0x31,
+						0x20, 0x30, 0x30, 0x3a, 0x30, 0x30, 0x3a, 0x30, 0x30, 0x2c, 0x74, 0x65, 0x73, 0x74, 0x2c, 0x2c,
+						0x63, 0x6f, 0x6e, 0x6e, 0x65, 0x63, 0x74, 0x65, 0x64, 0x2c, 0x31, 0x32, 0x33, 0x2e, 0x32, 0x33,
+						0x34, 0x2e, 0x31, 0x32, 0x33, 0x2e, 0x32, 0x33, 0x34, 0x2c, 0x52, 0x55, 0xa, 0x32, 0x30, 0x30,
+						0x31, 0x2d, 0x31, 0x32, 0x2d, 0x33, 0x31, 0x20, 0x30, 0x31, 0x3a, 0x30, 0x30, 0x3a, 0x30, 0x30,
+						0x2c, 0x74, 0x65, 0x73, 0x74, 0x2c, 0x2c, 0x64, 0x69, 0x73, 0x63, 0x6f, 0x6e, 0x6e, 0x65, 0x63,
+						0x74, 0x65, 0x64, 0x2c, 0x2c, 0xa,
 					},
 					b,
 				)
@@ -78,8 +81,11 @@ func TestCSVGenerator_Generate(t *testing.T) {
 		t.Run(test.name, func(t *testing.T) {
 			t.Parallel()
 			c := csvgenerator.NewCSVGenerator()
+			given := slices.Clone(test.logData)
 			b, timeStamp, err := c.Generate(test.logData)
 			test.assert(t, b, timeStamp, err)
+			// The caller's entries keep their order
+			assert.Equal(t, given, test.logData)
 		})
 	}
 }
@@ -0,0 +1,343 @@
+package csvmaintenance
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"sort"
+	"time"
+
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/dto"
+)
+
+const day = 24 * time.Hour
+
+// Compact merges the CSV files into one segment per month, drops duplicated events and applies the retention
+// policy as of now. Data past its retention is moved to the archive store when there is one.
+// The store is read and written one month at a time, so only a month of entries is held in memory.
+// Once the first segment is written the compaction runs to its end, so no duplicates are left for the readers.
+// Parses, erasures and removals wait for it to finish.
+func (s *Service) Compact(ctx context.Context, now time.Time) (*dto.CSVCompactionReport, error) {
+	s.csvRepository.Lock()
+	defer s.csvRepository.Unlock()
+
+	store, err := s.scan(ctx, s.csvRepository)
+	if err != nil {
+		return nil, err
+	}
+	report := &dto.CSVCompactionReport{FilesBefore: len(store.savedAts)}
+	if len(store.savedAts) == 0 {
+		return report, nil
+	}
+	store.checkpoint = store.savedAts[len(store.savedAts)-1]
+
+	var archive *monthlyStore
+	if s.archiveRepository != nil {
+		s.archiveRepository.Lock()
+		defer s.archiveRepository.Unlock()
+
+		if archive, err = s.scan(ctx, s.archiveRepository); err != nil {
+			return nil, err
+		}
+	}
+
+	readCtx, writeCtx := ctx, context.WithoutCancel(ctx)
+	months := store.allMonths()
+	for i, month := range months {
+		entries, err := s.readMonth(readCtx, store, month)
+		if err != nil {
+			return nil, err
+		}
+		entries, duplicates := deduplicate(entries)
+		report.DuplicatesCount += duplicates
+
+		entries, expired := s.applyRetention(entries, now, report)
+		if len(expired) > 0 && archive != nil {
+			// Archived first: a failure must not lose what was about to leave the live store
+			archived, err := s.readMonth(readCtx, archive, month)
+			if err != nil {
+				return nil, err
+			}
+			archived, _ = deduplicate(append(archived, expired...))
+			if err := s.writeMonth(writeCtx, archive, month, archived, false); err != nil {
+				return nil, fmt.Errorf("failed to archive: %w", err)
+			}
+			report.ArchivedCount += len(expired)
+		}
+
+		if err := s.writeMonth(writeCtx, store, month, entries, i == len(months)-1); err != nil {
+			return nil, err
+		}
+		readCtx = writeCtx
+	}
+
+	if archive != nil {
+		if _, err := s.finish(writeCtx, archive); err != nil {
+			return nil, fmt.Errorf("failed to archive: %w", err)
+		}
+	}
+	report.FilesAfter, err = s.finish(writeCtx, store)
+	if err != nil {
+		return nil, err
+	}
+
+	slog.InfoContext(
+		ctx, "Compacted the CSV store",
+		"files_before", report.FilesBefore,
+		"files_after", report.FilesAfter,
+		"duplicates", report.DuplicatesCount,
+		"expired", report.ExpiredCount,
+		"ips_removed", report.IPsRemovedCount,
+		"archived", report.ArchivedCount,
+	)
+
+	return report, nil
+}
+
+// Run compacts the store on every interval until the context is done. A compaction still reading after timeout,
+// if set, gives up until the next interval. Whenever a compaction drops entries, the rebuilders recompute
+// what was derived from them.
+func (s *Service) Run(ctx context.Context, interval time.Duration, timeout time.Duration, rebuilders ...rebuilder) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			report, err := s.compactWithin(ctx, now, timeout)
+			if err != nil {
+				slog.ErrorContext(ctx, "Failed to compact the CSV store", "error", err)
+				continue
+			}
+			if report.DuplicatesCount == 0 && report.ExpiredCount == 0 {
+				continue
+			}
+			for _, r := range rebuilders {
+				if _, err := r.Rebuild(ctx); err != nil {
+					slog.ErrorContext(ctx, "Failed to rebuild after the compaction", "error", err)
+				}
+			}
+		}
+	}
+}
+
+func (s *Service) compactWithin(
+	ctx context.Context,
+	now time.Time,
+	timeout time.Duration,
+) (*dto.CSVCompactionReport, error) {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	return s.Compact(ctx, now)
+}
+
+// applyRetention drops the expired events and strips the IP addresses past their retention. It returns the kept
+// entries and the original rows of everything it changed, for the archive.
+func (s *Service) applyRetention(
+	entries []dto.LogData,
+	now time.Time,
+	report *dto.CSVCompactionReport,
+) ([]dto.LogData, []dto.LogData) {
+	var eventsCutoff, ipsCutoff time.Time
+	if s.config.EventRetentionDays > 0 {
+		eventsCutoff = now.Add(-time.Duration(s.config.EventRetentionDays) * day)
+	}
+	if s.config.IPRetentionDays > 0 {
+		ipsCutoff = now.Add(-time.Duration(s.config.IPRetentionDays) * day)
+	}
+
+	var (
+		kept     = entries[:0]
+		original []dto.LogData
+	)
+	for _, entry := range entries {
+		if entry.TimeStamp.Before(eventsCutoff) {
+			report.ExpiredCount++
+			original = append(original, entry)
+			continue
+		}
+		if entry.IPAddress != "" && entry.TimeStamp.Before(ipsCutoff) {
+			report.IPsRemovedCount++
+			original = append(original, entry)
+			entry.IPAddress = ""
+		}
+		kept = append(kept, entry)
+	}
+	return kept, original
+}
+
+// monthlyStore is what a compaction knows of a store it rewrites month by month: the files, the months
+// their entries fall in, and what it rewrote so far.
+type monthlyStore struct {
+	repository csvRepository
+	savedAts   []time.Time
+	months     map[time.Time]map[string]struct{}
+	// checkpoint is the name the newest segment keeps, zero when there is none to keep
+	checkpoint time.Time
+	rewritten  map[string]struct{}
+	written    map[time.Time]struct{}
+}
+
+// scan reads the files of the repository once, for the months of their entries.
+func (s *Service) scan(ctx context.Context, repository csvRepository) (*monthlyStore, error) {
+	store := &monthlyStore{
+		repository: repository,
+		months:     make(map[time.Time]map[string]struct{}),
+		rewritten:  make(map[string]struct{}),
+		written:    make(map[time.Time]struct{}),
+	}
+	err := repository.EachCSVFile(ctx, func(savedAt time.Time, r io.Reader) error {
+		months := make(map[string]struct{})
+		store.savedAts = append(store.savedAts, savedAt)
+		store.months[savedAt] = months
+		return s.csvParser.ParseReader(r, func(logDataEntry *dto.LogData) error {
+			months[logDataEntry.TimeStamp.Format(monthFormat)] = struct{}{}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV store: %w", err)
+	}
+	return store, nil
+}
+
+// allMonths returns the months with entries, oldest first.
+func (m *monthlyStore) allMonths() []string {
+	seen := make(map[string]struct{})
+	for _, months := range m.months {
+		for month := range months {
+			seen[month] = struct{}{}
+		}
+	}
+	months := make([]string, 0, len(seen))
+	for month := range seen {
+		months = append(months, month)
+	}
+	sort.Strings(months)
+	return months
+}
+
+// done tells whether every month with entries in the file saved at savedAt was rewritten.
+func (m *monthlyStore) done(savedAt time.Time) bool {
+	for month := range m.months[savedAt] {
+		if _, ok := m.rewritten[month]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// readMonth returns the entries of the month, read from the files that hold any.
+func (s *Service) readMonth(ctx context.Context, store *monthlyStore, month string) ([]dto.LogData, error) {
+	var entries []dto.LogData
+	err := store.repository.EachCSVFile(ctx, func(savedAt time.Time, r io.Reader) error {
+		if _, ok := store.months[savedAt][month]; !ok {
+			return nil
+		}
+		return s.csvParser.ParseReader(r, func(logDataEntry *dto.LogData) error {
+			if logDataEntry.TimeStamp.Format(monthFormat) == month {
+				entries = append(entries, *logDataEntry)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV store: %w", err)
+	}
+	return entries, nil
+}
+
+// writeMonth writes the segment of the month, named after its last entry. The newest segment keeps
+// the checkpoint name instead, so the next parse starts where it would have.
+// Segments are written atomically before the replaced files go, so a failure leaves duplicates, never gaps.
+func (s *Service) writeMonth(
+	ctx context.Context,
+	store *monthlyStore,
+	month string,
+	entries []dto.LogData,
+	newest bool,
+) error {
+	store.rewritten[month] = struct{}{}
+	if len(entries) == 0 {
+		return nil
+	}
+
+	var savedAt time.Time
+	for _, entry := range entries {
+		if entry.TimeStamp.After(savedAt) {
+			savedAt = entry.TimeStamp
+		}
+	}
+	if newest && store.checkpoint.After(savedAt) {
+		savedAt = store.checkpoint
+	}
+	if _, ok := store.months[savedAt]; ok && !store.done(savedAt) {
+		return fmt.Errorf("%s holds entries past its name, verify the store", store.repository.FileName(savedAt))
+	}
+
+	if err := s.writeTo(ctx, store.repository, entries, savedAt); err != nil {
+		return err
+	}
+	store.months[savedAt] = map[string]struct{}{month: {}}
+	store.written[savedAt] = struct{}{}
+	return nil
+}
+
+// finish deletes the files whose months were all rewritten and returns how many files the store is left with.
+func (s *Service) finish(ctx context.Context, store *monthlyStore) (int, error) {
+	if !store.checkpoint.IsZero() {
+		if _, ok := store.written[store.checkpoint]; !ok && !store.writtenAfter(store.checkpoint) {
+			// Nothing is left up to the checkpoint, yet it has to stay
+			if err := s.writeTo(ctx, store.repository, nil, store.checkpoint); err != nil {
+				return 0, err
+			}
+			store.written[store.checkpoint] = struct{}{}
+		}
+	}
+
+	files := len(store.written)
+	for _, savedAt := range store.savedAts {
+		if _, ok := store.written[savedAt]; ok {
+			continue
+		}
+		if !store.done(savedAt) {
+			files++
+			continue
+		}
+		if err := store.repository.Delete(savedAt); err != nil {
+			return 0, err
+		}
+	}
+	return files, nil
+}
+
+// writtenAfter tells whether a segment was written under a name past t.
+func (m *monthlyStore) writtenAfter(t time.Time) bool {
+	for savedAt := range m.written {
+		if savedAt.After(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// deduplicate drops the repeated events, keeping the first one, and returns how many it dropped.
+func deduplicate(entries []dto.LogData) ([]dto.LogData, int) {
+	seen := make(map[eventKey]struct{}, len(entries))
+	unique := entries[:0]
+	for _, entry := range entries {
+		key := eventKeyOf(&entry)
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		unique = append(unique, entry)
+	}
+	return unique, len(entries) - len(unique)
+}
@@ -0,0 +1,209 @@
+package csvmaintenance_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/dto"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/services/csvgenerator"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/services/csvmaintenance"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/services/csvparser"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/services/csvrepository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	csvHeader       = "#schema_version:2\nTimeStamp,NickName,SteamID,Action,IPAddress,Country\n"
+	legacyCSVHeader = "TimeStamp,NickName,Action,IPAddress,Country\n"
+)
+
+// compactionFixture is written in the legacy schema and spans two months with a duplicated row and a checkpoint past the last entry.
+//
+//nolint:gochecknoglobals // test fixture
+var compactionFixture = map[string]string{
+	"logs_2025-02-10_10:00:00.csv": legacyCSVHeader +
+		"2025-02-10 09:00:00,Alice,connected,1.1.1.1,DE\n" +
+		"2025-02-10 10:00:00,Alice,disconnected,,\n",
+	"logs_2025-03-01_11:00:00.csv": legacyCSVHeader +
+		"2025-02-10 10:00:00,Alice,disconnected,,\n" +
+		"2025-03-01 10:00:00,Bob,connected,2.2.2.2,LV\n" +
+		"2025-03-01 11:00:00,Bob,disconnected,,\n",
+	"logs_2025-03-02_00:00:00.csv": legacyCSVHeader,
+}
+
+func readDir(t *testing.T, dir string) map[string]string {
+	t.Helper()
+	files, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	contents := make(map[string]string, len(files))
+	for _, file := range files {
+		data, err := os.ReadFile(filepath.Join(dir, file.Name()))
+		require.NoError(t, err)
+		contents[file.Name()] = string(data)
+	}
+	return contents
+}
+
+func TestService_Compact(t *testing.T) {
+	t.Parallel()
+	now := time.Date(2025, 3, 20, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name               string
+		ipRetentionDays    int
+		eventRetentionDays int
+		archive            bool
+		wantReport         dto.CSVCompactionReport
+		wantFiles          map[string]string
+		wantArchive        map[string]string
+	}{
+		{
+			name:       "success: monthly segments without duplicates, keeping the checkpoint",
+			wantReport: dto.CSVCompactionReport{FilesBefore: 3, FilesAfter: 2, DuplicatesCount: 1},
+			wantFiles: map[string]string{
+				"logs_2025-02-10_10:00:00.csv": csvHeader +
+					"2025-02-10 09:00:00,Alice,,connected,1.1.1.1,DE\n" +
+					"2025-02-10 10:00:00,Alice,,disconnected,,\n",
+				"logs_2025-03-02_00:00:00.csv": csvHeader +
+					"2025-03-01 10:00:00,Bob,,connected,2.2.2.2,LV\n" +
+					"2025-03-01 11:00:00,Bob,,disconnected,,\n",
+			},
+		},
+		{
+			name:               "success: retention deletes old events and IPs",
+			ipRetentionDays:    7,
+			eventRetentionDays: 30,
+			wantReport: dto.CSVCompactionReport{
+				FilesBefore:     3,
+				FilesAfter:      1,
+				DuplicatesCount: 1,
+				ExpiredCount:    2,
+				IPsRemovedCount: 1,
+			},
+			wantFiles: map[string]string{
+				"logs_2025-03-02_00:00:00.csv": csvHeader +
+					"2025-03-01 10:00:00,Bob,,connected,,LV\n" +
+					"2025-03-01 11:00:00,Bob,,disconnected,,\n",
+			},
+		},
+		{
+			name:               "success: retention archives the original rows",
+			ipRetentionDays:    7,
+			eventRetentionDays: 30,
+			archive:            true,
+			wantReport: dto.CSVCompactionReport{
+				FilesBefore:     3,
+				FilesAfter:      1,
+				DuplicatesCount: 1,
+				ExpiredCount:    2,
+				IPsRemovedCount: 1,
+				ArchivedCount:   3,
+			},
+			wantFiles: map[string]string{
+				"logs_2025-03-02_00:00:00.csv": csvHeader +
+					"2025-03-01 10:00:00,Bob,,connected,,LV\n" +
+					"2025-03-01 11:00:00,Bob,,disconnected,,\n",
+			},
+			wantArchive: map[string]string{
+				"logs_2025-02-10_10:00:00.csv": csvHeader +
+					"2025-02-10 09:00:00,Alice,,connected,1.1.1.1,DE\n" +
+					"2025-02-10 10:00:00,Alice,,disconnected,,\n",
+				"logs_2025-03-01_10:00:00.csv": csvHeader +
+					"2025-03-01 10:00:00,Bob,,connected,2.2.2.2,LV\n",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			dir := t.TempDir()
+			for name, content := range compactionFixture {
+				require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600))
+			}
+			repository := csvrepository.NewService(*csvrepository.NewConfig(dir))
+			archiveDir := t.TempDir()
+			var archiveRepository *csvrepository.Service
+			if tt.archive {
+				archiveRepository = csvrepository.NewService(*csvrepository.NewConfig(archiveDir))
+			}
+			config := *csvmaintenance.NewConfig(tt.ipRetentionDays, tt.eventRetentionDays)
+			service := csvmaintenance.NewService(
+				config, repository, nil, csvparser.NewService(), csvgenerator.NewCSVGenerator(),
+			)
+			if archiveRepository != nil {
+				service = csvmaintenance.NewService(
+					config, repository, archiveRepository, csvparser.NewService(), csvgenerator.NewCSVGenerator(),
+				)
+			}
+
+			report, err := service.Compact(context.Background(), now)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantReport, *report)
+			assert.Equal(t, tt.wantFiles, readDir(t, dir))
+			if tt.wantArchive != nil {
+				assert.Equal(t, tt.wantArchive, readDir(t, archiveDir))
+			}
+
+			// Compaction is idempotent
+			report, err = service.Compact(context.Background(), now)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantReport.FilesAfter, report.FilesAfter)
+			assert.Zero(t, report.DuplicatesCount+report.ExpiredCount+report.IPsRemovedCount)
+			assert.Equal(t, tt.wantFiles, readDir(t, dir))
+		})
+	}
+}
+
+func TestService_CompactDropsWhatVerifyReports(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"logs_2025-03-31_23:30:00.csv": csvHeader +
+			"2025-03-31 23:00:00,Alice,,connected,1.1.1.1,DE\n" +
+			"2025-03-31 23:30:00,Alice,,disconnected,,\n",
+		// spans both months, with a repeated row and a distinct event at the same time
+		"logs_2025-04-01_01:00:00.csv": csvHeader +
+			"2025-03-31 23:30:00,Alice,,disconnected,,\n" +
+			"2025-04-01 00:00:00,Bob,,connected,2.2.2.2,LV\n" +
+			"2025-04-01 00:00:00,Bob,,connected,3.3.3.3,LV\n" +
+			"2025-04-01 01:00:00,Bob,,disconnected,,\n",
+	} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600))
+	}
+	service := csvmaintenance.NewService(
+		*csvmaintenance.NewConfig(0, 0),
+		csvrepository.NewService(*csvrepository.NewConfig(dir)),
+		nil,
+		csvparser.NewService(),
+		csvgenerator.NewCSVGenerator(),
+	)
+
+	issues, err := service.Verify(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []dto.CSVStoreIssue{{
+		File:   "logs_2025-04-01_01:00:00.csv",
+		Entry:  1,
+		Reason: "entry duplicates one in logs_2025-03-31_23:30:00.csv",
+	}}, issues)
+
+	report, err := service.Compact(context.Background(), time.Date(2025, 4, 2, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.Equal(t, dto.CSVCompactionReport{FilesBefore: 2, FilesAfter: 2, DuplicatesCount: len(issues)}, *report)
+	assert.Equal(t, map[string]string{
+		"logs_2025-03-31_23:30:00.csv": csvHeader +
+			"2025-03-31 23:00:00,Alice,,connected,1.1.1.1,DE\n" +
+			"2025-03-31 23:30:00,Alice,,disconnected,,\n",
+		"logs_2025-04-01_01:00:00.csv": csvHeader +
+			"2025-04-01 00:00:00,Bob,,connected,2.2.2.2,LV\n" +
+			"2025-04-01 00:00:00,Bob,,connected,3.3.3.3,LV\n" +
+			"2025-04-01 01:00:00,Bob,,disconnected,,\n",
+	}, readDir(t, dir))
+
+	issues, err = service.Verify(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, issues)
+}
@@ -0,0 +1,16 @@
+package csvmaintenance
+
+type config struct {
+	// IPRetentionDays is how long raw IP addresses are kept, 0 keeps them forever
+	IPRetentionDays int
+	// EventRetentionDays is how long events are kept, 0 keeps them forever
+	EventRetentionDays int
+}
+
+//nolint:revive // no sense in export here
+func NewConfig(ipRetentionDays int, eventRetentionDays int) *config {
+	return &config{
+		IPRetentionDays:    ipRetentionDays,
+		EventRetentionDays: eventRetentionDays,
+	}
+}
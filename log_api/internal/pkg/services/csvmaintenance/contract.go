@@ -0,0 +1,33 @@
+package csvmaintenance
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/dto"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/services/csvgenerator"
+)
+
+type csvRepository interface {
+	sync.Locker
+	EachCSVFile(ctx context.Context, fn func(savedAt time.Time, r io.Reader) error) error
+	Save(ctx context.Context, data []byte, requestTimeStamp time.Time) error
+	Delete(savedAt time.Time) error
+	FileName(savedAt time.Time) string
+}
+
+type csvParser interface {
+	ParseReader(r io.Reader, fn func(logDataEntry *dto.LogData) error) error
+	SchemaVersion(r *bufio.Reader) (int, error)
+}
+
+type csvGenerator interface {
+	NewWriter(w io.Writer) *csvgenerator.Writer
+}
+
+type rebuilder interface {
+	Rebuild(ctx context.Context) (int, error)
+}
@@ -0,0 +1,47 @@
+package csvmaintenance
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/dto"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/enums"
+)
+
+// Migrate rewrites the files of an older schema version in the current one, under the same names,
+// and returns how many files were rewritten. Columns the older schema did not have are left empty.
+func (s *Service) Migrate(ctx context.Context) (int, error) {
+	s.csvRepository.Lock()
+	defer s.csvRepository.Unlock()
+
+	var rewritten int
+	err := s.rewriteFiles(ctx, s.csvRepository, func(savedAt time.Time, r io.Reader) ([]fileRewrite, error) {
+		reader := bufio.NewReader(r)
+		version, err := s.csvParser.SchemaVersion(reader)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", s.csvRepository.FileName(savedAt), err)
+		}
+		if version == enums.CSVSchemaVersion {
+			return nil, nil //nolint:nilnil // files of the current schema stay as they are
+		}
+
+		var entries []dto.LogData
+		err = s.csvParser.ParseReader(reader, func(logDataEntry *dto.LogData) error {
+			entries = append(entries, *logDataEntry)
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", s.csvRepository.FileName(savedAt), err)
+		}
+		rewritten++
+		return []fileRewrite{{savedAt: savedAt, entries: entries}}, nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return rewritten, nil
+}
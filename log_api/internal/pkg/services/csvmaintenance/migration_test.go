@@ -0,0 +1,51 @@
+package csvmaintenance_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/services/csvgenerator"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/services/csvmaintenance"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/services/csvparser"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/services/csvrepository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestService_Migrate(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	current := csvHeader + "2025-03-02 00:00:00,Carol,[U:1:42],connected,,\n"
+	for name, content := range map[string]string{
+		"logs_2025-02-10_10:00:00.csv": legacyCSVHeader +
+			"2025-02-10 09:00:00,Alice,connected,1.1.1.1,DE\n" +
+			"2025-02-10 10:00:00,Alice,disconnected,,\n",
+		"logs_2025-03-02_00:00:00.csv": current,
+	} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600))
+	}
+	service := csvmaintenance.NewService(
+		*csvmaintenance.NewConfig(0, 0),
+		csvrepository.NewService(*csvrepository.NewConfig(dir)),
+		nil,
+		csvparser.NewService(),
+		csvgenerator.NewCSVGenerator(),
+	)
+
+	migrated, err := service.Migrate(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, migrated)
+	assert.Equal(t, map[string]string{
+		"logs_2025-02-10_10:00:00.csv": csvHeader +
+			"2025-02-10 09:00:00,Alice,,connected,1.1.1.1,DE\n" +
+			"2025-02-10 10:00:00,Alice,,disconnected,,\n",
+		"logs_2025-03-02_00:00:00.csv": current,
+	}, readDir(t, dir))
+
+	// an up to date store is left alone
+	migrated, err = service.Migrate(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 0, migrated)
+}
@@ -0,0 +1,314 @@
+package csvmaintenance
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/dto"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/enums"
+)
+
+const monthFormat = "2006-01"
+
+// Service inspects, compacts and rewrites the CSV store. The name of the newest file is the parse checkpoint,
+// so rewrites never move it. Reads stop once their context is done, but a rewrite that started writing
+// runs to its end, so a cancellation never leaves the store half rewritten.
+type Service struct {
+	config            config
+	csvRepository     csvRepository
+	archiveRepository csvRepository
+	csvParser         csvParser
+	csvGenerator      csvGenerator
+}
+
+// NewService takes an optional archiveRepository: without it, data past its retention is deleted.
+func NewService(
+	config config,
+	csvRepository csvRepository,
+	archiveRepository csvRepository,
+	csvParser csvParser,
+	csvGenerator csvGenerator,
+) *Service {
+	return &Service{
+		config:            config,
+		csvRepository:     csvRepository,
+		archiveRepository: archiveRepository,
+		csvParser:         csvParser,
+		csvGenerator:      csvGenerator,
+	}
+}
+
+// eventKey identifies a stored event. Rows with the same key are duplicates: Verify reports them
+// and Compact keeps the first one only.
+type eventKey struct {
+	timeStamp time.Time
+	nickName  string
+	steamID   string
+	action    enums.Action
+	ipAddress string
+	country   string
+}
+
+func eventKeyOf(entry *dto.LogData) eventKey {
+	return eventKey{
+		timeStamp: entry.TimeStamp,
+		nickName:  entry.NickName,
+		steamID:   entry.SteamID,
+		action:    entry.Action,
+		ipAddress: entry.IPAddress,
+		country:   entry.Country,
+	}
+}
+
+// Stats counts the stored events per action and per month.
+func (s *Service) Stats(ctx context.Context) (*dto.CSVStoreStats, error) {
+	stats := &dto.CSVStoreStats{
+		ActionCounts:   make(map[string]int),
+		EventsPerMonth: make(map[string]int),
+	}
+	players := make(map[string]struct{})
+
+	err := s.csvRepository.EachCSVFile(ctx, func(savedAt time.Time, r io.Reader) error {
+		stats.FilesCount++
+		stats.Checkpoint = &savedAt
+		return s.csvParser.ParseReader(r, func(logDataEntry *dto.LogData) error {
+			stats.EventsCount++
+			stats.ActionCounts[logDataEntry.Action.String()]++
+			stats.EventsPerMonth[logDataEntry.TimeStamp.Format(monthFormat)]++
+			players[logDataEntry.NickName] = struct{}{}
+			if stats.First == nil || logDataEntry.TimeStamp.Before(*stats.First) {
+				stats.First = &logDataEntry.TimeStamp
+			}
+			if stats.Last == nil || logDataEntry.TimeStamp.After(*stats.Last) {
+				stats.Last = &logDataEntry.TimeStamp
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV store: %w", err)
+	}
+
+	stats.PlayersCount = len(players)
+	return stats, nil
+}
+
+// Verify reports unreadable files, entries out of order, entries past the file checkpoint and duplicated entries.
+func (s *Service) Verify(ctx context.Context) ([]dto.CSVStoreIssue, error) {
+	var issues []dto.CSVStoreIssue
+	seen := make(map[eventKey]string)
+
+	err := s.csvRepository.EachCSVFile(ctx, func(savedAt time.Time, r io.Reader) error {
+		fileName := s.csvRepository.FileName(savedAt)
+		var (
+			entry    int
+			previous time.Time
+		)
+		err := s.csvParser.ParseReader(r, func(logDataEntry *dto.LogData) error {
+			entry++
+			issue := func(reason string) {
+				issues = append(issues, dto.CSVStoreIssue{File: fileName, Entry: entry, Reason: reason})
+			}
+			if logDataEntry.TimeStamp.Before(previous) {
+				issue("entry is out of order")
+			}
+			previous = logDataEntry.TimeStamp
+			if logDataEntry.TimeStamp.After(savedAt) {
+				issue("entry is newer than the file checkpoint")
+			}
+
+			key := eventKeyOf(logDataEntry)
+			if duplicateOf, ok := seen[key]; ok {
+				issue("entry duplicates one in " + duplicateOf)
+			} else {
+				seen[key] = fileName
+			}
+			return nil
+		})
+		if err != nil {
+			issues = append(issues, dto.CSVStoreIssue{File: fileName, Reason: err.Error()})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV store: %w", err)
+	}
+
+	return issues, nil
+}
+
+// Remove deletes the stored entries from `from` to `to`, both inclusive, and returns how many were removed.
+// Emptied files are kept with their header only, to keep the parse checkpoint.
+func (s *Service) Remove(ctx context.Context, from time.Time, to time.Time) (int, error) {
+	s.csvRepository.Lock()
+	defer s.csvRepository.Unlock()
+
+	var removed int
+	err := s.rewriteFiles(ctx, s.csvRepository, func(savedAt time.Time, r io.Reader) ([]fileRewrite, error) {
+		var (
+			kept        []dto.LogData
+			fileRemoved int
+		)
+		err := s.csvParser.ParseReader(r, func(logDataEntry *dto.LogData) error {
+			if !logDataEntry.TimeStamp.Before(from) && !logDataEntry.TimeStamp.After(to) {
+				fileRemoved++
+				return nil
+			}
+			kept = append(kept, *logDataEntry)
+			return nil
+		})
+		if err != nil || fileRemoved == 0 {
+			return nil, err
+		}
+		removed += fileRemoved
+
+		// A re-parse of the range may save a file under this very name, so the kept entries move out of the way
+		if len(kept) > 0 && !savedAt.Before(from) && !savedAt.After(to) {
+			lastKept := kept[len(kept)-1].TimeStamp
+			for _, entry := range kept {
+				if entry.TimeStamp.After(lastKept) {
+					lastKept = entry.TimeStamp
+				}
+			}
+			return []fileRewrite{
+				{savedAt: lastKept, entries: kept, moved: true},
+				{savedAt: savedAt},
+			}, nil
+		}
+		return []fileRewrite{{savedAt: savedAt, entries: kept}}, nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return removed, nil
+}
+
+// fileRewrite is a file to write in place of a stored one.
+type fileRewrite struct {
+	savedAt time.Time
+	entries []dto.LogData
+	// moved files take a new name, which no stored file may have
+	moved bool
+}
+
+// rewriteFiles hands every file of the repository to read, which returns the files to write in its place, if any.
+// They are written once all files were read, as EachCSVFile keeps the current one open, and even if ctx
+// is done by then, so a rewrite is never left halfway. The caller holds the repository lock.
+func (s *Service) rewriteFiles(
+	ctx context.Context,
+	repository csvRepository,
+	read func(savedAt time.Time, r io.Reader) ([]fileRewrite, error),
+) error {
+	var (
+		rewrites []fileRewrite
+		savedAts = make(map[time.Time]struct{})
+	)
+	err := repository.EachCSVFile(ctx, func(savedAt time.Time, r io.Reader) error {
+		savedAts[savedAt] = struct{}{}
+		fileRewrites, err := read(savedAt, r)
+		rewrites = append(rewrites, fileRewrites...)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to read CSV store: %w", err)
+	}
+
+	for _, r := range rewrites {
+		if _, exists := savedAts[r.savedAt]; exists && r.moved {
+			return fmt.Errorf("%s already exists, compact the store first", repository.FileName(r.savedAt))
+		}
+	}
+	writeCtx := context.WithoutCancel(ctx)
+	for _, r := range rewrites {
+		if err := s.writeTo(writeCtx, repository, r.entries, r.savedAt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Service) writeTo(
+	ctx context.Context,
+	repository csvRepository,
+	entries []dto.LogData,
+	savedAt time.Time,
+) error {
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].TimeStamp.Before(entries[j].TimeStamp)
+	})
+
+	var buf bytes.Buffer
+	writer := s.csvGenerator.NewWriter(&buf)
+	for _, entry := range entries {
+		if err := writer.Write(entry); err != nil {
+			return err
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+
+	if err := repository.Save(ctx, buf.Bytes(), savedAt); err != nil {
+		return fmt.Errorf("failed to rewrite %s: %w", repository.FileName(savedAt), err)
+	}
+	return nil
+}
+
+// Rewrite hands every stored entry to fn, which may change it in place or drop it by returning false,
+// and rewrites the files with changed entries under the same names. It returns how many entries were changed
+// or dropped. Emptied files are kept with their header only, to keep the parse checkpoint.
+func (s *Service) Rewrite(ctx context.Context, fn func(entry *dto.LogData) bool) (int, error) {
+	return s.rewrite(ctx, s.csvRepository, fn)
+}
+
+// RewriteArchive does what Rewrite does to the archive store. Without an archive store nothing changes.
+func (s *Service) RewriteArchive(ctx context.Context, fn func(entry *dto.LogData) bool) (int, error) {
+	if s.archiveRepository == nil {
+		return 0, nil
+	}
+	return s.rewrite(ctx, s.archiveRepository, fn)
+}
+
+func (s *Service) rewrite(
+	ctx context.Context,
+	repository csvRepository,
+	fn func(entry *dto.LogData) bool,
+) (int, error) {
+	repository.Lock()
+	defer repository.Unlock()
+
+	var changed int
+	err := s.rewriteFiles(ctx, repository, func(savedAt time.Time, r io.Reader) ([]fileRewrite, error) {
+		var (
+			entries     []dto.LogData
+			fileChanged int
+		)
+		err := s.csvParser.ParseReader(r, func(logDataEntry *dto.LogData) error {
+			original := *logDataEntry
+			if !fn(logDataEntry) {
+				fileChanged++
+				return nil
+			}
+			if *logDataEntry != original {
+				fileChanged++
+			}
+			entries = append(entries, *logDataEntry)
+			return nil
+		})
+		if err != nil || fileChanged == 0 {
+			return nil, err
+		}
+		changed += fileChanged
+		return []fileRewrite{{savedAt: savedAt, entries: entries}}, nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return changed, nil
+}
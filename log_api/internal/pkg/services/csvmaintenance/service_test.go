@@ -0,0 +1,198 @@
+package csvmaintenance_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/dto"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/enums"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/services/csvgenerator"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/services/csvmaintenance"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/services/csvparser"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/services/csvrepository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+//nolint:gochecknoglobals // test fixture
+var base = time.Date(2025, 3, 31, 23, 0, 0, 0, time.UTC)
+
+func newStore(t *testing.T) (string, *csvrepository.Service, *csvmaintenance.Service) {
+	t.Helper()
+	dir := t.TempDir()
+	generator := csvgenerator.NewCSVGenerator()
+	repository := csvrepository.NewService(*csvrepository.NewConfig(dir))
+
+	for _, batch := range [][]dto.LogData{
+		{
+			{TimeStamp: base, NickName: "Alice", Action: enums.Actions.Connected()},
+			{TimeStamp: base.Add(30 * time.Minute), NickName: "Alice", Action: enums.Actions.Disconnected()},
+		},
+		{
+			{TimeStamp: base.Add(2 * time.Hour), NickName: "Bob", Action: enums.Actions.Connected()},
+			{TimeStamp: base.Add(3 * time.Hour), NickName: "Bob", Action: enums.Actions.Disconnected()},
+		},
+	} {
+		data, lastTimeStamp, err := generator.Generate(batch)
+		require.NoError(t, err)
+		require.NoError(t, repository.Save(context.Background(), data, *lastTimeStamp))
+	}
+
+	return dir, repository, csvmaintenance.NewService(
+		*csvmaintenance.NewConfig(0, 0),
+		repository,
+		nil,
+		csvparser.NewService(),
+		generator,
+	)
+}
+
+func TestService_Stats(t *testing.T) {
+	t.Parallel()
+	_, _, service := newStore(t)
+
+	stats, err := service.Stats(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, stats.FilesCount)
+	assert.Equal(t, 4, stats.EventsCount)
+	assert.Equal(t, 2, stats.PlayersCount)
+	assert.Equal(t, base, *stats.First)
+	assert.Equal(t, base.Add(3*time.Hour), *stats.Last)
+	assert.Equal(t, base.Add(3*time.Hour), *stats.Checkpoint)
+	assert.Equal(t, map[string]int{"2025-03": 2, "2025-04": 2}, stats.EventsPerMonth)
+	assert.Equal(t, map[string]int{"connected": 2, "disconnected": 2}, stats.ActionCounts)
+}
+
+func TestService_Verify(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		corrupt func(t *testing.T, dir string)
+		want    []dto.CSVStoreIssue
+	}{
+		{
+			name:    "success: a consistent store",
+			corrupt: func(_ *testing.T, _ string) {},
+		},
+		{
+			name: "success: issues are reported per file",
+			corrupt: func(t *testing.T, dir string) {
+				require.NoError(t, os.WriteFile(
+					filepath.Join(dir, "logs_2025-04-01_03:00:00.csv"),
+					[]byte("TimeStamp,NickName,Action,IPAddress,Country\n"+
+						"2025-04-01 01:00:00,Bob,connected,,\n"+
+						"2025-04-01 00:45:00,Carol,connected,,\n"+
+						"2025-04-01 04:00:00,Carol,disconnected,,\n"),
+					0o600,
+				))
+				require.NoError(t, os.WriteFile(
+					filepath.Join(dir, "logs_2025-04-02_00:00:00.csv"),
+					[]byte("TimeStamp,NickName,Action,IPAddress,Country\nyesterday,Dave,connected,,\n"),
+					0o600,
+				))
+			},
+			want: []dto.CSVStoreIssue{
+				{File: "logs_2025-04-01_03:00:00.csv", Entry: 1, Reason: "entry duplicates one in logs_2025-04-01_02:00:00.csv"},
+				{File: "logs_2025-04-01_03:00:00.csv", Entry: 2, Reason: "entry is out of order"},
+				{File: "logs_2025-04-01_03:00:00.csv", Entry: 3, Reason: "entry is newer than the file checkpoint"},
+				{
+					File: "logs_2025-04-02_00:00:00.csv",
+					Reason: "failed to parse timestamp in line 2: " +
+						`parsing time "yesterday" as "2006-01-02 15:04:05": cannot parse "yesterday" as "2006"`,
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			dir, _, service := newStore(t)
+			tt.corrupt(t, dir)
+
+			issues, err := service.Verify(context.Background())
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, issues)
+		})
+	}
+}
+
+func TestService_Remove(t *testing.T) {
+	t.Parallel()
+	dir, _, service := newStore(t)
+
+	removed, err := service.Remove(context.Background(), base.Add(30*time.Minute), base.Add(3*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, 3, removed)
+
+	// The emptied newest file is kept, as it carries the parse checkpoint
+	data, err := os.ReadFile(filepath.Join(dir, "logs_2025-04-01_02:00:00.csv"))
+	require.NoError(t, err)
+	assert.Equal(t, csvHeader, string(data))
+
+	stats, err := service.Stats(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, stats.EventsCount)
+	assert.Equal(t, base, *stats.Last)
+}
+
+func TestService_Remove_MovesEntriesKeptFromACheckpointInRange(t *testing.T) {
+	t.Parallel()
+	dir, _, service := newStore(t)
+
+	// The second file is saved at 02:00, within the range, and keeps Bob's connect from before it
+	removed, err := service.Remove(context.Background(), base.Add(150*time.Minute), base.Add(4*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, 1, removed)
+
+	files, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	names := make([]string, 0, len(files))
+	for _, file := range files {
+		names = append(names, file.Name())
+	}
+	assert.Equal(t, []string{
+		"logs_2025-03-31_23:30:00.csv",
+		"logs_2025-04-01_01:00:00.csv",
+		"logs_2025-04-01_02:00:00.csv",
+	}, names)
+
+	data, err := os.ReadFile(filepath.Join(dir, "logs_2025-04-01_02:00:00.csv"))
+	require.NoError(t, err)
+	assert.Equal(t, csvHeader, string(data))
+
+	stats, err := service.Stats(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 3, stats.EventsCount)
+	assert.Equal(t, base.Add(3*time.Hour), *stats.Checkpoint)
+}
+
+func TestService_RewriteDuringCompactionIsKept(t *testing.T) {
+	t.Parallel()
+	_, _, service := newStore(t)
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		_, err := service.Compact(context.Background(), base.Add(4*time.Hour))
+		assert.NoError(t, err)
+	}()
+	go func() {
+		defer wg.Done()
+		_, err := service.Rewrite(context.Background(), func(entry *dto.LogData) bool {
+			return entry.NickName != "Alice"
+		})
+		assert.NoError(t, err)
+	}()
+	wg.Wait()
+
+	stats, err := service.Stats(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, stats.EventsCount)
+	assert.Equal(t, 1, stats.PlayersCount)
+}
@@ -1,18 +1,29 @@
 package csvparser
 
 import (
+	"bufio"
 	"bytes"
 	"encoding/csv"
 	"errors"
 	"fmt"
-	"log"
+	"io"
+	"iter"
+	"log/slog"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/dto"
 	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/enums"
 )
 
-const minValuesCount = 4
+// legacySchemaVersion is the schema of files written before the header carried a version.
+const legacySchemaVersion = 1
+
+const maxVersionLineLength = 64
+
+// errStopped ends a parse once the consumer of Entries stopped the loop.
+var errStopped = errors.New("stopped")
 
 type Service struct{}
 
@@ -21,44 +32,196 @@ func NewService() *Service {
 }
 
 func (s *Service) Parse(data []byte) ([]*dto.LogData, error) {
-	reader := csv.NewReader(bytes.NewReader(data))
-
-	records, err := reader.ReadAll()
+	var results []*dto.LogData
+	err := s.ParseReader(bytes.NewReader(data), func(logDataEntry *dto.LogData) error {
+		results = append(results, logDataEntry)
+		return nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to read csv: %w", err)
+		return nil, err
 	}
+	return results, nil
+}
 
-	if len(records) < 1 {
-		return nil, errors.New("csv does not contain header")
-	}
+// ParseReader reads the CSV record by record and hands every log entry over to fn, without loading the whole file.
+// Columns are looked up by their header name, and a header repeated further down switches the mapping,
+// so files of different schema versions can be read one after another from the same stream.
+func (s *Service) ParseReader(r io.Reader, fn func(logDataEntry *dto.LogData) error) error {
+	reader := csv.NewReader(r)
+	// the version line and rows of older schemas have less fields than the current header
+	reader.FieldsPerRecord = -1
 
-	var results []*dto.LogData
-	for i, record := range records[1:] {
-		if len(record) < minValuesCount {
-			log.Println("record is too short, skipping: line No.", i+1)
+	var current *schema
+	version := legacySchemaVersion
+
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			if current == nil {
+				return errors.New("csv does not contain header")
+			}
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read csv: %w", err)
+		}
+		line, _ := reader.FieldPos(0)
+
+		if recordVersion, ok, err := parseVersionLine(record); ok {
+			if err != nil {
+				return fmt.Errorf("line %d: %w", line, err)
+			}
+			version = recordVersion
 			continue
 		}
 
-		ts, err := time.Parse("2006-01-02 15:04:05", record[0])
-		if err != nil {
-			return nil, fmt.Errorf("failed to parse timestamp in record %d: %w", i+1, err)
+		if current == nil || record[0] == enums.CSVColumns.TimeStamp().String() {
+			current = newSchema(version, record)
+			version = legacySchemaVersion
+			continue
 		}
 
-		action := enums.Action(record[2])
-		if !action.IsValid() {
+		logDataEntry, err := current.logData(record, line)
+		if err != nil {
+			return err
+		}
+		if logDataEntry == nil {
 			continue
 		}
 
-		logDataEntry := &dto.LogData{
-			TimeStamp: ts,
-			NickName:  record[1],
-			Action:    action,
-			IPAddress: record[3],
-			Country:   record[4],
+		if err := fn(logDataEntry); err != nil {
+			return err
 		}
+	}
+}
 
-		results = append(results, logDataEntry)
+// Entries streams the log entries of every reader in turn, stopping at the first error.
+// Nothing but the entry being handed over is kept in memory.
+func (s *Service) Entries(readers iter.Seq2[io.Reader, error]) iter.Seq2[*dto.LogData, error] {
+	return func(yield func(*dto.LogData, error) bool) {
+		for r, err := range readers {
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+
+			err := s.ParseReader(r, func(logDataEntry *dto.LogData) error {
+				if !yield(logDataEntry, nil) {
+					return errStopped
+				}
+				return nil
+			})
+			if errors.Is(err, errStopped) {
+				return
+			}
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+		}
 	}
+}
 
-	return results, nil
+// SchemaVersion peeks at the start of r for the schema version, without consuming anything.
+func (s *Service) SchemaVersion(r *bufio.Reader) (int, error) {
+	peeked, err := r.Peek(maxVersionLineLength)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, bufio.ErrBufferFull) {
+		return 0, fmt.Errorf("failed to read csv: %w", err)
+	}
+
+	line, _, _ := bytes.Cut(peeked, []byte("\n"))
+	version, ok, err := parseVersionLine([]string{strings.TrimSpace(string(line))})
+	if !ok {
+		return legacySchemaVersion, nil
+	}
+	return version, err
+}
+
+func parseVersionLine(record []string) (int, bool, error) {
+	if len(record) != 1 || !strings.HasPrefix(record[0], enums.CSVSchemaVersionPrefix) {
+		return 0, false, nil
+	}
+
+	version, err := strconv.Atoi(strings.TrimPrefix(record[0], enums.CSVSchemaVersionPrefix))
+	if err != nil || version < legacySchemaVersion {
+		return 0, true, fmt.Errorf("invalid csv schema version %q", record[0])
+	}
+	if version > enums.CSVSchemaVersion {
+		return 0, true, fmt.Errorf("csv schema version %d is newer than the supported %d", version, enums.CSVSchemaVersion)
+	}
+	return version, true, nil
+}
+
+// schema maps the columns of the header being read to their position in a record.
+type schema struct {
+	version int
+	columns map[enums.CSVColumn]int
+	checked bool
+}
+
+func newSchema(version int, header []string) *schema {
+	columns := make(map[enums.CSVColumn]int, len(header))
+	for i, name := range header {
+		column := enums.CSVColumn(strings.TrimSpace(name))
+		if _, duplicate := columns[column]; column.IsValid() && !duplicate {
+			columns[column] = i
+		}
+	}
+	return &schema{version: version, columns: columns}
+}
+
+// validate is deferred to the first row, so a header nothing is read with can not fail the parse.
+func (s *schema) validate() error {
+	if s.checked {
+		return nil
+	}
+	for _, column := range enums.CSVColumns.All() {
+		if _, ok := s.columns[column]; column.IsRequired() && !ok {
+			return fmt.Errorf("csv schema version %d is missing the required column %s", s.version, column)
+		}
+	}
+	s.checked = true
+	return nil
+}
+
+// logData maps a record, or returns nil for a row that is too short or has an unknown action.
+func (s *schema) logData(record []string, line int) (*dto.LogData, error) {
+	if err := s.validate(); err != nil {
+		return nil, fmt.Errorf("line %d: %w", line, err)
+	}
+
+	for column, i := range s.columns {
+		if column.IsRequired() && i >= len(record) {
+			slog.Warn("Skipping a CSV record too short", "line", line)
+			return nil, nil //nolint:nilnil // a skipped row is not an error
+		}
+	}
+
+	ts, err := time.Parse("2006-01-02 15:04:05", s.value(record, enums.CSVColumns.TimeStamp()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse timestamp in line %d: %w", line, err)
+	}
+
+	action := enums.Action(s.value(record, enums.CSVColumns.Action()))
+	if !action.IsValid() {
+		return nil, nil //nolint:nilnil // a skipped row is not an error
+	}
+
+	return &dto.LogData{
+		TimeStamp: ts,
+		NickName:  s.value(record, enums.CSVColumns.NickName()),
+		SteamID:   s.value(record, enums.CSVColumns.SteamID()),
+		Action:    action,
+		IPAddress: s.value(record, enums.CSVColumns.IPAddress()),
+		Country:   s.value(record, enums.CSVColumns.Country()),
+	}, nil
+}
+
+// value returns an empty string for a column the schema or the record does not have.
+func (s *schema) value(record []string, column enums.CSVColumn) string {
+	i, ok := s.columns[column]
+	if !ok || i >= len(record) {
+		return ""
+	}
+	return record[i]
 }
@@ -1,13 +1,18 @@
 package csvparser_test
 
 import (
+	"bufio"
+	"io"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/dto"
 	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/enums"
 	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/services/csvparser"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/tools"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestService_Parse(t *testing.T) {
@@ -228,6 +233,68 @@ func TestService_Parse(t *testing.T) {
 				}
 			},
 		},
+		{
+			name: "success: columns are mapped by the header of each schema version",
+			data: []byte("TimeStamp,NickName,Action,IPAddress,Country\n" +
+				"2000-12-31 00:00:00,test,connected,123.234.123.234,RU\n" +
+				"#schema_version:2\n" +
+				"TimeStamp,NickName,SteamID,Action,IPAddress,Country\n" +
+				"2001-12-31 01:00:00,test,[U:1:42],disconnected,,\n"),
+			assert: func(t *testing.T, logData []*dto.LogData, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, []*dto.LogData{
+					{
+						TimeStamp: time.Date(2000, 12, 31, 0, 0, 0, 0, time.UTC),
+						NickName:  "test",
+						Action:    enums.Actions.Connected(),
+						IPAddress: "123.234.123.234",
+						Country:   "RU",
+					},
+					{
+						TimeStamp: time.Date(2001, 12, 31, 1, 0, 0, 0, time.UTC),
+						NickName:  "test",
+						SteamID:   "[U:1:42]",
+						Action:    enums.Actions.Disconnected(),
+					},
+				}, logData)
+			},
+		},
+		{
+			name: "success: optional columns missing from a row are left empty",
+			data: []byte("TimeStamp,NickName,Action,IPAddress,Country\n" +
+				"2000-12-31 00:00:00,test,connected,123.234.123.234\n" +
+				"2000-12-31 01:00:00,test\n"),
+			assert: func(t *testing.T, logData []*dto.LogData, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, []*dto.LogData{
+					{
+						TimeStamp: time.Date(2000, 12, 31, 0, 0, 0, 0, time.UTC),
+						NickName:  "test",
+						Action:    enums.Actions.Connected(),
+						IPAddress: "123.234.123.234",
+					},
+				}, logData)
+			},
+		},
+		{
+			name: "failed: required column missing from the header",
+			data: []byte("#schema_version:2\n" +
+				"TimeStamp,SteamID,Action\n" +
+				"2000-12-31 00:00:00,[U:1:42],connected\n"),
+			assert: func(t *testing.T, logData []*dto.LogData, err error) {
+				assert.EqualError(t, err, "line 3: csv schema version 2 is missing the required column NickName")
+				assert.Nil(t, logData)
+			},
+		},
+		{
+			name: "failed: schema version newer than supported",
+			data: []byte("#schema_version:3\n" +
+				"TimeStamp,NickName,Action\n"),
+			assert: func(t *testing.T, logData []*dto.LogData, err error) {
+				assert.EqualError(t, err, "line 1: csv schema version 3 is newer than the supported 2")
+				assert.Nil(t, logData)
+			},
+		},
 	}
 
 	for _, test := range tests {
@@ -239,3 +306,68 @@ func TestService_Parse(t *testing.T) {
 		})
 	}
 }
+
+func TestService_SchemaVersion(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		data    string
+		want    int
+		wantErr bool
+	}{
+		{name: "success: legacy file without a version line", data: "TimeStamp,NickName,Action,IPAddress,Country\n", want: 1},
+		{name: "success: versioned file", data: "#schema_version:2\nTimeStamp,NickName,SteamID\n", want: 2},
+		{name: "success: empty file", data: "", want: 1},
+		{name: "failed: invalid version", data: "#schema_version:x\n", wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			reader := bufio.NewReader(strings.NewReader(test.data))
+			version, err := csvparser.NewService().SchemaVersion(reader)
+			if test.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.want, version)
+
+			// nothing is consumed
+			rest, err := io.ReadAll(reader)
+			assert.NoError(t, err)
+			assert.Equal(t, test.data, string(rest))
+		})
+	}
+}
+
+func TestService_Entries(t *testing.T) {
+	t.Parallel()
+	files := []io.Reader{
+		strings.NewReader("TimeStamp,NickName,Action,IPAddress,Country\n" +
+			"2000-12-31 00:00:00,a,connected,,\n" +
+			"2000-12-31 01:00:00,a,disconnected,,\n"),
+		strings.NewReader("#schema_version:2\nTimeStamp,NickName,SteamID,Action,IPAddress,Country\n" +
+			"2001-12-31 00:00:00,b,[U:1:42],connected,,\n" +
+			"2001-12-31 01:00:00,b,[U:1:42],disconnected,,\n"),
+		strings.NewReader("TimeStamp\nyesterday\n"),
+	}
+	service := csvparser.NewService()
+
+	var nickNames []string
+	for logData, err := range service.Entries(tools.SeqOf(files)) {
+		require.NoError(t, err)
+		nickNames = append(nickNames, logData.NickName)
+		// the broken file after the stop is never read
+		if len(nickNames) == 3 {
+			break
+		}
+	}
+	assert.Equal(t, []string{"a", "a", "b"}, nickNames)
+
+	var lastErr error
+	for _, err := range service.Entries(tools.SeqOf(files[2:])) {
+		lastErr = err
+	}
+	assert.Error(t, lastErr)
+}
@@ -1,37 +1,62 @@
 package csvrepository
 
 import (
-	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"iter"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
+
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/dto"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/tools"
+)
+
+const (
+	csvFilePrefix     = "logs_"
+	csvFileSuffix     = ".csv"
+	csvFileTimeFormat = "2006-01-02_15:04:05"
 )
 
 type Service struct {
 	config config
+	mu     sync.Mutex
 }
 
 func NewService(config config) *Service {
 	return &Service{config: config}
 }
 
+// Lock gives the caller the store to itself until Unlock. Whatever changes the store holds it from its first read
+// to its last write, so a parse, a compaction or an erasure never overwrites what another one wrote meanwhile.
+// Readers go without it, as files are replaced atomically. It does not reach other processes.
+func (s *Service) Lock() {
+	s.mu.Lock()
+}
+
+func (s *Service) Unlock() {
+	s.mu.Unlock()
+}
+
 func (s *Service) GetLastSavedDate() (*time.Time, error) {
 	files, err := os.ReadDir(s.config.CsvStorageDirectory)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read directory: %w", err)
 	}
-	if len(files) == 0 {
-		return nil, nil
-	}
 
 	var lastTime time.Time
 	for _, file := range files {
 		name := file.Name()
+		if file.IsDir() || !isCSVLogFileName(name) {
+			continue
+		}
 		// example: logs_2006-01-02_15:04:05.csv
-		dateString := name[5:24]
-		parsedTime, err := time.Parse("2006-01-02_15:04:05", dateString)
+		dateString := name[len(csvFilePrefix) : len(name)-len(csvFileSuffix)]
+		parsedTime, err := time.Parse(csvFileTimeFormat, dateString)
 		if err != nil {
 			return nil, fmt.Errorf("failed to parse time: %w", err)
 		}
@@ -40,51 +65,138 @@ func (s *Service) GetLastSavedDate() (*time.Time, error) {
 		}
 	}
 
+	if lastTime.IsZero() {
+		return nil, nil
+	}
+
 	return &lastTime, nil
 }
 
-func (s *Service) Save(csvBytes []byte, requestTimeStamp time.Time) error {
-	if err := os.MkdirAll(s.config.CsvStorageDirectory, os.ModePerm); err != nil {
-		return fmt.Errorf("failed to create directory: %w", err)
+// Save writes the file atomically, so readers never come across a half-written one.
+// It is not started once ctx is done.
+func (s *Service) Save(ctx context.Context, csvBytes []byte, requestTimeStamp time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return err
 	}
+	filePath := filepath.Join(s.config.CsvStorageDirectory, s.FileName(requestTimeStamp))
 
-	fileName := fmt.Sprintf("logs_%s.csv", requestTimeStamp.Format("2006-01-02_15:04:05"))
-	filePath := filepath.Join(s.config.CsvStorageDirectory, fileName)
-
-	if err := os.WriteFile(filePath, csvBytes, 0o600); err != nil {
+	if err := tools.WriteFileAtomic(filePath, csvBytes, 0o600); err != nil {
 		return fmt.Errorf("failed to write CSV file: %w", err)
 	}
 
 	return nil
 }
 
-func (s *Service) GetAllCSVData() ([]byte, error) {
-	files, err := os.ReadDir(s.config.CsvStorageDirectory)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read directory: %w", err)
+// Delete removes the CSV file saved at savedAt.
+func (s *Service) Delete(savedAt time.Time) error {
+	if err := os.Remove(filepath.Join(s.config.CsvStorageDirectory, s.FileName(savedAt))); err != nil {
+		return fmt.Errorf("failed to delete CSV file: %w", err)
 	}
+	return nil
+}
 
-	var combined bytes.Buffer
-	firstFile := true
+// FileName names the CSV file saved at savedAt, e.g. logs_2006-01-02_15:04:05.csv.
+func (s *Service) FileName(savedAt time.Time) string {
+	return csvFilePrefix + savedAt.Format(csvFileTimeFormat) + csvFileSuffix
+}
+
+// EachCSVFile opens the saved CSV files one at a time, oldest first, and hands each over to fn
+// together with the time it was saved at, which is the time of its last entry. A missing directory is an empty store.
+// Reading stops with the context error once ctx is done.
+func (s *Service) EachCSVFile(ctx context.Context, fn func(savedAt time.Time, r io.Reader) error) error {
+	files, err := s.csvFiles()
+	if err != nil {
+		return err
+	}
 
 	for _, file := range files {
-		if !file.IsDir() && strings.HasSuffix(file.Name(), ".csv") {
-			filePath := filepath.Join(s.config.CsvStorageDirectory, file.Name())
-			content, err := os.ReadFile(filePath)
-			if err != nil {
-				return nil, fmt.Errorf("failed to read file %s: %w", filePath, err)
+		if err := s.withFile(ctx, file.path, func(r io.Reader) error {
+			return fn(file.savedAt, r)
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CSVFiles streams the saved CSV files, oldest first, skipping the ones outside the time range by their name.
+// A file holds the entries saved after the previous one up to its own time, so the files saved before the range
+// start are skipped, and the first file saved after the range end is the last one read.
+// Every file is closed once the loop moves past it, and reading stops with the context error once ctx is done.
+func (s *Service) CSVFiles(ctx context.Context, timeRange dto.TimeRange) iter.Seq2[io.Reader, error] {
+	return func(yield func(io.Reader, error) bool) {
+		files, err := s.csvFiles()
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+
+		for _, file := range files {
+			if timeRange.From != nil && file.savedAt.Before(*timeRange.From) {
+				continue
 			}
 
-			if !firstFile {
-				if pos := bytes.IndexByte(content, '\n'); pos != -1 && pos+1 < len(content) {
-					content = content[pos+1:]
-				}
+			proceed := true
+			if err := s.withFile(ctx, file.path, func(r io.Reader) error {
+				proceed = yield(r, nil)
+				return nil
+			}); err != nil {
+				yield(nil, err)
+				return
 			}
-			combined.Write(content)
-			combined.WriteString("\n")
-			firstFile = false
+			if !proceed || (timeRange.To != nil && file.savedAt.After(*timeRange.To)) {
+				return
+			}
+		}
+	}
+}
+
+type csvFile struct {
+	path    string
+	savedAt time.Time
+}
+
+// csvFiles lists the saved CSV files oldest first. A missing directory is an empty store.
+func (s *Service) csvFiles() ([]csvFile, error) {
+	entries, err := os.ReadDir(s.config.CsvStorageDirectory)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read directory: %w", err)
+	}
+
+	// os.ReadDir sorts by name, and the names sort chronologically
+	files := make([]csvFile, 0, len(entries))
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !isCSVLogFileName(name) {
+			continue
 		}
+		savedAt, err := time.Parse(csvFileTimeFormat, name[len(csvFilePrefix):len(name)-len(csvFileSuffix)])
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse time: %w", err)
+		}
+		files = append(files, csvFile{path: filepath.Join(s.config.CsvStorageDirectory, name), savedAt: savedAt})
 	}
+	return files, nil
+}
+
+func (s *Service) withFile(ctx context.Context, filePath string, fn func(r io.Reader) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	f, err := os.Open(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to open file %s: %w", filePath, err)
+	}
+	defer f.Close()
+
+	return fn(tools.NewContextReader(ctx, f))
+}
 
-	return combined.Bytes(), nil
+func isCSVLogFileName(name string) bool {
+	return len(name) == len(csvFilePrefix)+len(csvFileTimeFormat)+len(csvFileSuffix) &&
+		strings.HasPrefix(name, csvFilePrefix) &&
+		strings.HasSuffix(name, csvFileSuffix)
 }
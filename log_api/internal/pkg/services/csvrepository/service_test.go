@@ -1,13 +1,16 @@
 package csvrepository_test
 
 import (
-	"os"
+	"context"
+	"io"
 	"testing"
 	"time"
 
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/dto"
 	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/services/csvrepository"
-	"github.com/dmitriitimoshenko/nmrih/log_api/internal/tools/testhelper"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/tools"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestService_GetLastSavedDate(t *testing.T) {
@@ -31,13 +34,62 @@ func TestService_GetLastSavedDate(t *testing.T) {
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
 			t.Parallel()
-			th := testhelper.NewTestHelper(t)
-			th.UseTestEnv()
-
-			cfg := csvrepository.NewConfig(os.Getenv("CSV_STORAGE_DIRECTORY"))
+			cfg := csvrepository.NewConfig("./internal/data")
 			service := csvrepository.NewService(*cfg)
 			actualDateTime, err := service.GetLastSavedDate()
 			test.assert(t, actualDateTime, err)
 		})
 	}
 }
+
+func TestService_CSVFiles(t *testing.T) {
+	t.Parallel()
+	savedAts := []time.Time{
+		time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC),
+		time.Date(2025, 2, 28, 0, 0, 0, 0, time.UTC),
+		time.Date(2025, 3, 31, 0, 0, 0, 0, time.UTC),
+		time.Date(2025, 4, 30, 0, 0, 0, 0, time.UTC),
+	}
+	tests := []struct {
+		name      string
+		timeRange dto.TimeRange
+		want      []string
+	}{
+		{
+			name: "success: every file without a range",
+			want: []string{"2025-01-31", "2025-02-28", "2025-03-31", "2025-04-30"},
+		},
+		{
+			name: "success: files saved before the range start or after the first one past its end are skipped",
+			timeRange: dto.TimeRange{
+				From: tools.ToPtr(time.Date(2025, 2, 15, 0, 0, 0, 0, time.UTC)),
+				To:   tools.ToPtr(time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)),
+			},
+			want: []string{"2025-02-28", "2025-03-31"},
+		},
+		{
+			name:      "success: a range past the last file",
+			timeRange: dto.TimeRange{From: tools.ToPtr(time.Date(2025, 5, 1, 0, 0, 0, 0, time.UTC))},
+		},
+	}
+
+	dir := t.TempDir()
+	service := csvrepository.NewService(*csvrepository.NewConfig(dir))
+	for _, savedAt := range savedAts {
+		require.NoError(t, service.Save(context.Background(), []byte(savedAt.Format(time.DateOnly)), savedAt))
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			var contents []string
+			for r, err := range service.CSVFiles(context.Background(), test.timeRange) {
+				require.NoError(t, err)
+				content, err := io.ReadAll(r)
+				require.NoError(t, err)
+				contents = append(contents, string(content))
+			}
+			assert.Equal(t, test.want, contents)
+		})
+	}
+}
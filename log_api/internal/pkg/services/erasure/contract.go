@@ -0,0 +1,33 @@
+package erasure
+
+import (
+	"context"
+
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/dto"
+)
+
+type csvStore interface {
+	Rewrite(ctx context.Context, fn func(entry *dto.LogData) bool) (int, error)
+	RewriteArchive(ctx context.Context, fn func(entry *dto.LogData) bool) (int, error)
+}
+
+type aliasRepository interface {
+	GetAliases(steamID string) (*dto.PlayerAliases, error)
+}
+
+type pseudonymiser interface {
+	Pseudonym(value string) (string, error)
+}
+
+type auditLog interface {
+	Record(entry dto.AuditEntry) error
+}
+
+type redisCache interface {
+	Del(ctx context.Context, keys ...string) error
+}
+
+// eraser removes or pseudonymises the player in a store derived from the logs (aliases, chat, records, ...).
+type eraser interface {
+	Erase(ctx context.Context, target dto.ErasureTarget) (int, error)
+}
@@ -0,0 +1,203 @@
+package erasure
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/dto"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/enums"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/services/aliases"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/tools"
+)
+
+var ErrInvalidRequest = errors.New("invalid erasure request")
+
+// Service erases a player on request: their events in the CSV store and its archive are dropped or pseudonymised,
+// the derived stores follow, and the cached graphs are dropped. Every erasure is audited by the pseudonym only.
+type Service struct {
+	csvStore        csvStore
+	aliasRepository aliasRepository
+	pseudonymiser   pseudonymiser
+	auditLog        auditLog
+	redisCache      redisCache
+	erasers         []eraser
+	// mu keeps two erasures from rewriting the same files at once
+	mu sync.Mutex
+}
+
+// NewService takes an optional redisCache, offline there are no cached graphs to drop.
+// The erasers run in the given order after the CSV store was rewritten.
+func NewService(
+	csvStore csvStore,
+	aliasRepository aliasRepository,
+	pseudonymiser pseudonymiser,
+	auditLog auditLog,
+	redisCache redisCache,
+	erasers ...eraser,
+) *Service {
+	return &Service{
+		csvStore:        csvStore,
+		aliasRepository: aliasRepository,
+		pseudonymiser:   pseudonymiser,
+		auditLog:        auditLog,
+		redisCache:      redisCache,
+		erasers:         erasers,
+	}
+}
+
+// Erase removes or pseudonymises every stored event of the player. The SteamID may be given in any notation.
+// A player named by SteamID is also matched by the nicknames the SteamID used in the entries without one,
+// such as the ones stored before SteamIDs were.
+func (s *Service) Erase(ctx context.Context, actor string, request dto.ErasureRequest) (*dto.ErasureReport, error) {
+	target, err := s.target(request)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	report, err := s.erase(ctx, target)
+	s.audit(ctx, actor, target, err)
+	if err != nil {
+		return nil, err
+	}
+
+	slog.InfoContext(
+		ctx, "Erased a player",
+		"pseudonym", target.Pseudonym,
+		"csv_entries", report.CSVEntriesCount,
+		"archive_entries", report.ArchiveEntriesCount,
+		"state_entries", report.StateEntriesCount,
+	)
+	return report, nil
+}
+
+func (s *Service) target(request dto.ErasureRequest) (dto.ErasureTarget, error) {
+	steamID, nickName := strings.TrimSpace(request.SteamID), strings.TrimSpace(request.NickName)
+	if steamID == "" && nickName == "" {
+		return dto.ErasureTarget{}, fmt.Errorf("%w: steam_id or nick_name is required", ErrInvalidRequest)
+	}
+	if !request.Mode.IsValid() {
+		return dto.ErasureTarget{}, fmt.Errorf("%w: mode [%s]", ErrInvalidRequest, request.Mode)
+	}
+
+	if steamID != "" {
+		normalized, err := tools.NormalizeSteamID(steamID)
+		if err != nil {
+			return dto.ErasureTarget{}, fmt.Errorf("%w: %w", ErrInvalidRequest, err)
+		}
+		steamID = normalized
+	}
+
+	target := dto.ErasureTarget{SteamID: steamID, Mode: request.Mode}
+	if nickName != "" {
+		target.NickNames = append(target.NickNames, nickName)
+	}
+	pseudonymOf := nickName
+	if steamID != "" {
+		pseudonymOf = steamID
+	}
+	// The audit log names every erased player by the pseudonym, whatever the mode
+	pseudonym, err := s.pseudonymiser.Pseudonym(pseudonymOf)
+	if err != nil {
+		return dto.ErasureTarget{}, err
+	}
+	target.Pseudonym = pseudonym
+
+	if steamID != "" {
+		playerAliases, err := s.aliasRepository.GetAliases(steamID)
+		if err != nil && !errors.Is(err, aliases.ErrPlayerNotFound) {
+			return dto.ErasureTarget{}, err
+		}
+		if playerAliases != nil {
+			for _, alias := range playerAliases.Aliases {
+				if !target.HasNickName(alias.NickName) {
+					target.NickNames = append(target.NickNames, alias.NickName)
+				}
+			}
+		}
+	}
+	return target, nil
+}
+
+func (s *Service) erase(ctx context.Context, target dto.ErasureTarget) (*dto.ErasureReport, error) {
+	report := &dto.ErasureReport{
+		SteamID:   target.SteamID,
+		NickNames: target.NickNames,
+		Mode:      target.Mode,
+	}
+	if target.Mode == enums.ErasureModes.Pseudonymise() {
+		report.Pseudonym = target.Pseudonym
+	}
+
+	eraseEntry := func(entry *dto.LogData) bool {
+		if !target.Matches(entry.NickName, entry.SteamID) {
+			return true
+		}
+		if target.Mode == enums.ErasureModes.Delete() {
+			return false
+		}
+		entry.NickName = target.Pseudonym
+		entry.SteamID = ""
+		entry.IPAddress = ""
+		return true
+	}
+	csvEntriesCount, err := s.csvStore.Rewrite(ctx, eraseEntry)
+	if err != nil {
+		return nil, fmt.Errorf("failed to erase from the CSV store: %w", err)
+	}
+	report.CSVEntriesCount = csvEntriesCount
+
+	// Once the CSV store is rewritten the archive and the state stores have to follow, whatever happens to the request
+	ctx = context.WithoutCancel(ctx)
+
+	// The archive keeps the original rows of what left the CSV store, raw IP addresses included
+	archiveEntriesCount, err := s.csvStore.RewriteArchive(ctx, eraseEntry)
+	if err != nil {
+		return nil, fmt.Errorf("failed to erase from the CSV archive: %w", err)
+	}
+	report.ArchiveEntriesCount = archiveEntriesCount
+
+	// The aliases go last among the state stores: a failed erasure can be retried by SteamID as long as they exist
+	for _, e := range s.erasers {
+		count, err := e.Erase(ctx, target)
+		if err != nil {
+			return nil, fmt.Errorf("failed to erase from the state: %w", err)
+		}
+		report.StateEntriesCount += count
+	}
+
+	if s.redisCache != nil {
+		keys := make([]string, 0, len(enums.GraphTypes.All()))
+		for _, graphType := range enums.GraphTypes.All() {
+			keys = append(keys, graphType.CacheKey())
+		}
+		if err := s.redisCache.Del(ctx, keys...); err != nil {
+			return nil, fmt.Errorf("failed to drop the cached graphs: %w", err)
+		}
+		report.CachesCleared = true
+	}
+
+	return report, nil
+}
+
+func (s *Service) audit(ctx context.Context, actor string, target dto.ErasureTarget, err error) {
+	entry := dto.AuditEntry{
+		TimeStamp: time.Now(),
+		Actor:     actor,
+		Command:   fmt.Sprintf("erase %s %s", target.Mode, target.Pseudonym),
+		Success:   err == nil,
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	if auditErr := s.auditLog.Record(entry); auditErr != nil {
+		slog.ErrorContext(ctx, "Failed to audit the erasure", "pseudonym", target.Pseudonym, "error", auditErr)
+	}
+}
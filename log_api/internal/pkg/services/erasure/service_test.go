@@ -0,0 +1,347 @@
+package erasure_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/dto"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/enums"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/services/aliases"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/services/chat"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/services/csvgenerator"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/services/csvmaintenance"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/services/csvparser"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/services/csvrepository"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/services/erasure"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/services/privacy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type auditLogStub struct {
+	entries []dto.AuditEntry
+}
+
+func (a *auditLogStub) Record(entry dto.AuditEntry) error {
+	a.entries = append(a.entries, entry)
+	return nil
+}
+
+type redisCacheStub struct {
+	keys []string
+}
+
+func (r *redisCacheStub) Del(_ context.Context, keys ...string) error {
+	r.keys = append(r.keys, keys...)
+	return nil
+}
+
+type fixture struct {
+	service           *erasure.Service
+	csvRepository     *csvrepository.Service
+	archiveRepository *csvrepository.Service
+	chatService       *chat.Service
+	aliasService      *aliases.Service
+	auditLog          *auditLogStub
+	redisCache        *redisCacheStub
+	pseudonymiser     *privacy.Service
+}
+
+func newFixture(t *testing.T) *fixture {
+	t.Helper()
+	base := time.Date(2025, 3, 15, 12, 0, 0, 0, time.UTC)
+	generator := csvgenerator.NewCSVGenerator()
+	parser := csvparser.NewService()
+	repository := csvrepository.NewService(*csvrepository.NewConfig(t.TempDir()))
+	archiveRepository := csvrepository.NewService(*csvrepository.NewConfig(t.TempDir()))
+	stateDirectory := t.TempDir()
+
+	logs := []dto.LogData{
+		{
+			TimeStamp: base,
+			NickName:  "Alice",
+			SteamID:   "[U:1:1]",
+			Action:    enums.Actions.Connected(),
+			IPAddress: "1.1.1.1",
+			Country:   "LV",
+		},
+		// stored before SteamIDs were, matched by the nickname the SteamID used
+		{TimeStamp: base.Add(time.Minute), NickName: "Ally", Action: enums.Actions.Connected(), Country: "LV"},
+		{TimeStamp: base.Add(2 * time.Minute), NickName: "Bob", SteamID: "[U:1:2]", Action: enums.Actions.Connected()},
+		// another player using the nickname is left alone
+		{TimeStamp: base.Add(3 * time.Minute), NickName: "Ally", SteamID: "[U:1:3]", Action: enums.Actions.Connected()},
+		{TimeStamp: base.Add(time.Hour), NickName: "Alice", SteamID: "[U:1:1]", Action: enums.Actions.Disconnected()},
+	}
+	data, lastTimeStamp, err := generator.Generate(logs)
+	require.NoError(t, err)
+	require.NoError(t, repository.Save(context.Background(), data, *lastTimeStamp))
+	// the archive keeps the original rows of what a compaction stripped or expired
+	archived, lastArchived, err := generator.Generate(logs[:3])
+	require.NoError(t, err)
+	require.NoError(t, archiveRepository.Save(context.Background(), archived, *lastArchived))
+
+	aliasService := aliases.NewService(*aliases.NewConfig(stateDirectory))
+	require.NoError(t, aliasService.Index(context.Background(), &dto.ParseBatch{Logs: []dto.LogData{
+		{TimeStamp: base, NickName: "Alice", SteamID: "[U:1:1]"},
+		{TimeStamp: base.Add(-time.Hour), NickName: "Ally", SteamID: "[U:1:1]"},
+		{TimeStamp: base, NickName: "Bob", SteamID: "[U:1:2]"},
+	}}))
+	chatService := chat.NewService(*chat.NewConfig(stateDirectory))
+	require.NoError(t, chatService.Index(context.Background(), &dto.ParseBatch{Chat: []dto.ChatMessage{
+		{TimeStamp: base.Add(5 * time.Minute), NickName: "Alice", SteamID: "[U:1:1]", Message: "hi"},
+		{TimeStamp: base.Add(6 * time.Minute), NickName: "Bob", SteamID: "[U:1:2]", Message: "hello"},
+	}}))
+
+	pseudonymiser := privacy.NewService(*privacy.NewConfig(enums.IPModes.Plain(), "key"))
+	csvMaintenance := csvmaintenance.NewService(
+		*csvmaintenance.NewConfig(0, 0),
+		repository,
+		archiveRepository,
+		parser,
+		csvgenerator.NewCSVGenerator(),
+	)
+	auditLog := &auditLogStub{}
+	redisCache := &redisCacheStub{}
+
+	return &fixture{
+		service: erasure.NewService(
+			csvMaintenance,
+			aliasService,
+			pseudonymiser,
+			auditLog,
+			redisCache,
+			chatService,
+			aliasService,
+		),
+		csvRepository:     repository,
+		archiveRepository: archiveRepository,
+		chatService:       chatService,
+		aliasService:      aliasService,
+		auditLog:          auditLog,
+		redisCache:        redisCache,
+		pseudonymiser:     pseudonymiser,
+	}
+}
+
+func (f *fixture) storedEntries(t *testing.T) []dto.LogData {
+	t.Helper()
+	return entriesOf(t, f.csvRepository)
+}
+
+func (f *fixture) archivedEntries(t *testing.T) []dto.LogData {
+	t.Helper()
+	return entriesOf(t, f.archiveRepository)
+}
+
+func entriesOf(t *testing.T, repository *csvrepository.Service) []dto.LogData {
+	t.Helper()
+	var entries []dto.LogData
+	for entry, err := range csvparser.NewService().Entries(repository.CSVFiles(context.Background(), dto.TimeRange{})) {
+		require.NoError(t, err)
+		entries = append(entries, *entry)
+	}
+	return entries
+}
+
+func (f *fixture) chatNickNames(t *testing.T) []string {
+	t.Helper()
+	page, err := f.chatService.Search(dto.ChatQuery{Page: 1, PageSize: 10})
+	require.NoError(t, err)
+	nickNames := make([]string, 0, len(page.Messages))
+	for _, message := range page.Messages {
+		nickNames = append(nickNames, message.NickName)
+	}
+	return nickNames
+}
+
+func TestService_Erase(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		request dto.ErasureRequest
+		assert  func(t *testing.T, f *fixture, report *dto.ErasureReport, err error)
+	}{
+		{
+			name:    "success: delete by SteamID drops the events of every nickname it used",
+			request: dto.ErasureRequest{SteamID: "[U:1:1]", Mode: enums.ErasureModes.Delete()},
+			assert: func(t *testing.T, f *fixture, report *dto.ErasureReport, err error) {
+				require.NoError(t, err)
+				assert.Equal(t, 3, report.CSVEntriesCount)
+				assert.ElementsMatch(t, []string{"Alice", "Ally"}, report.NickNames)
+				assert.True(t, report.CachesCleared)
+				assert.Contains(t, f.redisCache.keys, enums.GraphTypes.TopTimeSpentGraphType().CacheKey())
+
+				var players []string
+				for _, entry := range f.storedEntries(t) {
+					players = append(players, entry.NickName+" "+entry.SteamID)
+				}
+				assert.Equal(t, []string{"Bob [U:1:2]", "Ally [U:1:3]"}, players)
+				assert.Equal(t, 2, report.ArchiveEntriesCount)
+				archived := f.archivedEntries(t)
+				require.Len(t, archived, 1)
+				assert.Equal(t, "Bob", archived[0].NickName)
+				assert.Equal(t, []string{"Bob"}, f.chatNickNames(t))
+				_, err = f.aliasService.GetAliases("[U:1:1]")
+				assert.ErrorIs(t, err, aliases.ErrPlayerNotFound)
+
+				// the audit log names the player by the pseudonym only
+				require.Len(t, f.auditLog.entries, 1)
+				assert.True(t, f.auditLog.entries[0].Success)
+				assert.NotContains(t, f.auditLog.entries[0].Command, "[U:1:1]")
+				pseudonym, err := f.pseudonymiser.Pseudonym("[U:1:1]")
+				require.NoError(t, err)
+				assert.Contains(t, f.auditLog.entries[0].Command, pseudonym)
+			},
+		},
+		{
+			name:    "success: pseudonymise keeps the events without the identity",
+			request: dto.ErasureRequest{SteamID: "[U:1:1]", Mode: enums.ErasureModes.Pseudonymise()},
+			assert: func(t *testing.T, f *fixture, report *dto.ErasureReport, err error) {
+				require.NoError(t, err)
+				pseudonym, err := f.pseudonymiser.Pseudonym("[U:1:1]")
+				require.NoError(t, err)
+				assert.Equal(t, pseudonym, report.Pseudonym)
+
+				entries := f.storedEntries(t)
+				require.Len(t, entries, 5)
+				assert.Equal(t, dto.LogData{
+					TimeStamp: entries[0].TimeStamp,
+					NickName:  pseudonym,
+					Action:    enums.Actions.Connected(),
+					Country:   "LV",
+				}, entries[0])
+				assert.Equal(t, pseudonym, entries[1].NickName)
+				assert.Equal(t, pseudonym, entries[4].NickName)
+				assert.ElementsMatch(t, []string{pseudonym, "Bob"}, f.chatNickNames(t))
+
+				assert.Equal(t, 2, report.ArchiveEntriesCount)
+				archived := f.archivedEntries(t)
+				require.Len(t, archived, 3)
+				assert.Equal(t, pseudonym, archived[0].NickName)
+				assert.Empty(t, archived[0].SteamID)
+				assert.Empty(t, archived[0].IPAddress)
+			},
+		},
+		{
+			name:    "success: erase by nickname",
+			request: dto.ErasureRequest{NickName: "Bob", Mode: enums.ErasureModes.Delete()},
+			assert: func(t *testing.T, f *fixture, report *dto.ErasureReport, err error) {
+				require.NoError(t, err)
+				assert.Equal(t, 1, report.CSVEntriesCount)
+				assert.Equal(t, 1, report.ArchiveEntriesCount)
+				assert.Len(t, f.storedEntries(t), 4)
+				assert.Equal(t, []string{"Alice"}, f.chatNickNames(t))
+				_, err = f.aliasService.GetAliases("[U:1:2]")
+				assert.ErrorIs(t, err, aliases.ErrPlayerNotFound)
+			},
+		},
+		{
+			name:    "error: no player given",
+			request: dto.ErasureRequest{Mode: enums.ErasureModes.Delete()},
+			assert: func(t *testing.T, f *fixture, _ *dto.ErasureReport, err error) {
+				assert.ErrorIs(t, err, erasure.ErrInvalidRequest)
+				assert.Len(t, f.storedEntries(t), 5)
+				assert.Empty(t, f.auditLog.entries)
+			},
+		},
+		{
+			name:    "error: unknown mode",
+			request: dto.ErasureRequest{NickName: "Bob", Mode: "forget"},
+			assert: func(t *testing.T, _ *fixture, _ *dto.ErasureReport, err error) {
+				assert.ErrorIs(t, err, erasure.ErrInvalidRequest)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			f := newFixture(t)
+			report, err := f.service.Erase(context.Background(), "127.0.0.1", tt.request)
+			tt.assert(t, f, report, err)
+		})
+	}
+}
+
+func TestService_EraseSteamIDNotations(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		steamID string
+		wantErr bool
+	}{
+		{
+			name:    "success: SteamID3",
+			steamID: "[U:1:1]",
+		},
+		{
+			name:    "success: SteamID3 without brackets",
+			steamID: "U:1:1",
+		},
+		{
+			name:    "success: SteamID2",
+			steamID: "STEAM_0:1:0",
+		},
+		{
+			name:    "success: SteamID64",
+			steamID: "76561197960265729",
+		},
+		{
+			name:    "error: not a SteamID",
+			steamID: "Alice",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			f := newFixture(t)
+			report, err := f.service.Erase(context.Background(), "127.0.0.1", dto.ErasureRequest{
+				SteamID: tt.steamID,
+				Mode:    enums.ErasureModes.Delete(),
+			})
+			if tt.wantErr {
+				require.ErrorIs(t, err, erasure.ErrInvalidRequest)
+				assert.Len(t, f.storedEntries(t), 5)
+				assert.Empty(t, f.auditLog.entries)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "[U:1:1]", report.SteamID)
+			assert.Equal(t, 3, report.CSVEntriesCount)
+			assert.ElementsMatch(t, []string{"Alice", "Ally"}, report.NickNames)
+			assert.Equal(t, []string{"Bob"}, f.chatNickNames(t))
+		})
+	}
+}
+
+func TestService_EraseWithoutHashKey(t *testing.T) {
+	t.Parallel()
+	f := newFixture(t)
+	service := erasure.NewService(
+		csvmaintenance.NewService(
+			*csvmaintenance.NewConfig(0, 0),
+			f.csvRepository,
+			f.archiveRepository,
+			csvparser.NewService(),
+			csvgenerator.NewCSVGenerator(),
+		),
+		f.aliasService,
+		privacy.NewService(*privacy.NewConfig(enums.IPModes.Plain(), "")),
+		f.auditLog,
+		f.redisCache,
+		f.chatService,
+		f.aliasService,
+	)
+
+	// an unkeyed pseudonym, even the audited one, is reversed by hashing candidate SteamIDs
+	for _, mode := range []enums.ErasureMode{enums.ErasureModes.Pseudonymise(), enums.ErasureModes.Delete()} {
+		_, err := service.Erase(context.Background(), "127.0.0.1", dto.ErasureRequest{SteamID: "[U:1:1]", Mode: mode})
+		require.ErrorIs(t, err, privacy.ErrNoHashKey)
+	}
+	assert.Len(t, f.storedEntries(t), 5)
+	assert.Len(t, f.archivedEntries(t), 3)
+	assert.Empty(t, f.auditLog.entries)
+}
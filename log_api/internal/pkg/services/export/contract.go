@@ -0,0 +1,30 @@
+package export
+
+import (
+	"context"
+	"io"
+	"iter"
+
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/dto"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/services/csvgenerator"
+)
+
+type csvRepository interface {
+	CSVFiles(ctx context.Context, timeRange dto.TimeRange) iter.Seq2[io.Reader, error]
+}
+
+type csvParser interface {
+	Entries(readers iter.Seq2[io.Reader, error]) iter.Seq2[*dto.LogData, error]
+}
+
+type csvGenerator interface {
+	NewExportWriter(w io.Writer) *csvgenerator.Writer
+}
+
+type graphService interface {
+	SessionsOf(logs iter.Seq2[*dto.LogData, error]) ([]dto.Session, error)
+}
+
+type ipAnonymiser interface {
+	AnonymiseIP(ip string) string
+}
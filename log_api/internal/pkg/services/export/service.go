@@ -0,0 +1,99 @@
+package export
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"iter"
+
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/dto"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/enums"
+)
+
+// Service streams the stored data out in CSV, NDJSON or Parquet, row by row.
+type Service struct {
+	csvRepository csvRepository
+	csvParser     csvParser
+	csvGenerator  csvGenerator
+	graphService  graphService
+	ipAnonymiser  ipAnonymiser
+}
+
+func NewService(
+	csvRepository csvRepository,
+	csvParser csvParser,
+	csvGenerator csvGenerator,
+	graphService graphService,
+	ipAnonymiser ipAnonymiser,
+) *Service {
+	return &Service{
+		csvRepository: csvRepository,
+		csvParser:     csvParser,
+		csvGenerator:  csvGenerator,
+		graphService:  graphService,
+		ipAnonymiser:  ipAnonymiser,
+	}
+}
+
+// ExportEvents writes the stored log events within the query range. IP addresses stored before the privacy
+// configuration was set are anonymised on the way out.
+func (s *Service) ExportEvents(ctx context.Context, w io.Writer, format enums.ExportFormat, query dto.TimeRange) error {
+	var writer rowWriter[dto.LogData]
+	switch format {
+	case enums.ExportFormats.CSV():
+		writer = &eventCSVWriter{writer: s.csvGenerator.NewExportWriter(w)}
+	case enums.ExportFormats.NDJSON():
+		writer = newNDJSONWriter(w, toEventRecord)
+	default:
+		writer = newParquetWriter(w, toEventRecord)
+	}
+
+	for logData, err := range s.events(ctx, query) {
+		if err != nil {
+			return fmt.Errorf("failed to read stored events: %w", err)
+		}
+		logData.IPAddress = s.ipAnonymiser.AnonymiseIP(logData.IPAddress)
+		if err := writer.Write(*logData); err != nil {
+			return err
+		}
+	}
+	return writer.Close()
+}
+
+// ExportSessions writes the play sessions overlapping the query range.
+// Sessions are rebuilt from every event up to the end of the range, so the ones started before it are whole.
+func (s *Service) ExportSessions(ctx context.Context, w io.Writer, format enums.ExportFormat, query dto.TimeRange) error {
+	sessions, err := s.graphService.SessionsOf(s.events(ctx, dto.TimeRange{To: query.To}))
+	if err != nil {
+		return fmt.Errorf("failed to read stored events: %w", err)
+	}
+
+	var writer rowWriter[dto.Session]
+	switch format {
+	case enums.ExportFormats.CSV():
+		csvWriter, err := newSessionCSVWriter(w)
+		if err != nil {
+			return err
+		}
+		writer = csvWriter
+	case enums.ExportFormats.NDJSON():
+		writer = newNDJSONWriter(w, toSessionRecord)
+	default:
+		writer = newParquetWriter(w, toSessionRecord)
+	}
+
+	for _, session := range sessions {
+		if query.From != nil && session.End.Before(*query.From) {
+			continue
+		}
+		if err := writer.Write(session); err != nil {
+			return err
+		}
+	}
+	return writer.Close()
+}
+
+// events streams the stored events in the range, reading only the CSV files that can hold them.
+func (s *Service) events(ctx context.Context, query dto.TimeRange) iter.Seq2[*dto.LogData, error] {
+	return query.Filter(s.csvParser.Entries(s.csvRepository.CSVFiles(ctx, query)))
+}
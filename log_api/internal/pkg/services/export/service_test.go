@@ -0,0 +1,155 @@
+package export_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/dto"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/enums"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/services/csvgenerator"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/services/csvparser"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/services/csvrepository"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/services/export"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/services/graph"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/services/privacy"
+	"github.com/parquet-go/parquet-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type parquetEvent struct {
+	TimeStamp time.Time `parquet:"time_stamp,timestamp(millisecond)"`
+	NickName  string    `parquet:"nick_name"`
+	Action    string    `parquet:"action"`
+	Country   string    `parquet:"country,optional"`
+}
+
+func newService(t *testing.T) *export.Service {
+	t.Helper()
+	base := time.Date(2025, 3, 15, 12, 0, 0, 0, time.UTC)
+	generator := csvgenerator.NewCSVGenerator()
+	repository := csvrepository.NewService(*csvrepository.NewConfig(t.TempDir()))
+
+	for _, batch := range [][]dto.LogData{
+		{
+			{TimeStamp: base, NickName: "Alice", Action: enums.Actions.Connected(), Country: "Germany"},
+			{TimeStamp: base.Add(time.Hour), NickName: "Alice", Action: enums.Actions.Disconnected()},
+		},
+		{
+			{
+				TimeStamp: base.Add(24 * time.Hour),
+				NickName:  "Bob",
+				SteamID:   "[U:1:42]",
+				Action:    enums.Actions.Connected(),
+				IPAddress: "5.6.7.8",
+				Country:   "Latvia",
+			},
+			{TimeStamp: base.Add(26 * time.Hour), NickName: "Bob", Action: enums.Actions.Disconnected()},
+		},
+	} {
+		data, lastTimeStamp, err := generator.Generate(batch)
+		require.NoError(t, err)
+		require.NoError(t, repository.Save(context.Background(), data, *lastTimeStamp))
+	}
+
+	return export.NewService(
+		repository,
+		csvparser.NewService(),
+		generator,
+		graph.NewService(nil),
+		privacy.NewService(*privacy.NewConfig(enums.IPModes.Truncate(), "")),
+	)
+}
+
+func TestService_ExportEvents(t *testing.T) {
+	t.Parallel()
+	from := time.Date(2025, 3, 16, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		format enums.ExportFormat
+		query  dto.TimeRange
+		assert func(t *testing.T, output []byte)
+	}{
+		{
+			name:   "success: csv keeps the stored column layout with anonymised IP addresses",
+			format: enums.ExportFormats.CSV(),
+			query:  dto.TimeRange{From: &from},
+			assert: func(t *testing.T, output []byte) {
+				assert.Equal(t, "TimeStamp,NickName,SteamID,Action,IPAddress,Country\n"+
+					"2025-03-16 12:00:00,Bob,[U:1:42],connected,5.6.7.0,Latvia\n"+
+					"2025-03-16 14:00:00,Bob,,disconnected,,\n", string(output))
+			},
+		},
+		{
+			name:   "success: csv of an empty range has the header only",
+			format: enums.ExportFormats.CSV(),
+			query:  dto.TimeRange{From: toPtr(time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC))},
+			assert: func(t *testing.T, output []byte) {
+				assert.Equal(t, "TimeStamp,NickName,SteamID,Action,IPAddress,Country\n", string(output))
+			},
+		},
+		{
+			name:   "success: ndjson",
+			format: enums.ExportFormats.NDJSON(),
+			assert: func(t *testing.T, output []byte) {
+				var nickNames []string
+				scanner := bufio.NewScanner(bytes.NewReader(output))
+				for scanner.Scan() {
+					var event struct {
+						NickName string `json:"nick_name"`
+						Action   string `json:"action"`
+					}
+					require.NoError(t, json.Unmarshal(scanner.Bytes(), &event))
+					nickNames = append(nickNames, event.NickName+" "+event.Action)
+				}
+				assert.Equal(t, []string{"Alice connected", "Alice disconnected", "Bob connected", "Bob disconnected"}, nickNames)
+			},
+		},
+		{
+			name:   "success: parquet",
+			format: enums.ExportFormats.Parquet(),
+			query:  dto.TimeRange{From: &from},
+			assert: func(t *testing.T, output []byte) {
+				events, err := parquet.Read[parquetEvent](bytes.NewReader(output), int64(len(output)))
+				require.NoError(t, err)
+				require.Len(t, events, 2)
+				assert.Equal(t, "Bob", events[0].NickName)
+				assert.Equal(t, "Latvia", events[0].Country)
+				assert.True(t, time.Date(2025, 3, 16, 12, 0, 0, 0, time.UTC).Equal(events[0].TimeStamp))
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var output bytes.Buffer
+			require.NoError(t, newService(t).ExportEvents(context.Background(), &output, tt.format, tt.query))
+			tt.assert(t, output.Bytes())
+		})
+	}
+}
+
+func TestService_ExportSessions(t *testing.T) {
+	t.Parallel()
+	// Alice's session ends within the range although it started before it
+	from := time.Date(2025, 3, 15, 12, 30, 0, 0, time.UTC)
+	to := time.Date(2025, 3, 16, 0, 0, 0, 0, time.UTC)
+
+	var output bytes.Buffer
+	err := newService(t).ExportSessions(context.Background(), &output, enums.ExportFormats.CSV(), dto.TimeRange{From: &from, To: &to})
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"NickName,Start,End,DurationSeconds",
+		"Alice,2025-03-15 12:00:00,2025-03-15 13:00:00,3600",
+	}, strings.Split(strings.TrimSpace(output.String()), "\n"))
+}
+
+func toPtr(timeStamp time.Time) *time.Time {
+	return &timeStamp
+}
@@ -0,0 +1,158 @@
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/dto"
+	"github.com/parquet-go/parquet-go"
+)
+
+const (
+	// parquetRowGroupSize bounds the rows buffered before a row group is written out
+	parquetRowGroupSize = 10000
+	sessionTimeFormat   = "2006-01-02 15:04:05"
+)
+
+// rowWriter streams rows of one kind in one of the export formats.
+type rowWriter[T any] interface {
+	Write(row T) error
+	Close() error
+}
+
+// ndjsonWriter converts rows to their exported record on the fly.
+type ndjsonWriter[T, R any] struct {
+	encoder *json.Encoder
+	convert func(row T) R
+}
+
+func newNDJSONWriter[T, R any](w io.Writer, convert func(row T) R) *ndjsonWriter[T, R] {
+	return &ndjsonWriter[T, R]{encoder: json.NewEncoder(w), convert: convert}
+}
+
+func (w *ndjsonWriter[T, R]) Write(row T) error {
+	if err := w.encoder.Encode(w.convert(row)); err != nil {
+		return fmt.Errorf("failed to write NDJSON row: %w", err)
+	}
+	return nil
+}
+
+func (w *ndjsonWriter[T, R]) Close() error {
+	return nil
+}
+
+// parquetWriter converts rows to their parquet schema on the fly.
+type parquetWriter[T, R any] struct {
+	writer  *parquet.GenericWriter[R]
+	convert func(row T) R
+}
+
+func newParquetWriter[T, R any](w io.Writer, convert func(row T) R) *parquetWriter[T, R] {
+	return &parquetWriter[T, R]{
+		writer:  parquet.NewGenericWriter[R](w, parquet.MaxRowsPerRowGroup(parquetRowGroupSize)),
+		convert: convert,
+	}
+}
+
+func (w *parquetWriter[T, R]) Write(row T) error {
+	if _, err := w.writer.Write([]R{w.convert(row)}); err != nil {
+		return fmt.Errorf("failed to write parquet row: %w", err)
+	}
+	return nil
+}
+
+func (w *parquetWriter[T, R]) Close() error {
+	if err := w.writer.Close(); err != nil {
+		return fmt.Errorf("failed to close parquet writer: %w", err)
+	}
+	return nil
+}
+
+type eventRecord struct {
+	TimeStamp time.Time `json:"time_stamp" parquet:"time_stamp,timestamp(millisecond)"`
+	NickName  string    `json:"nick_name"  parquet:"nick_name"`
+	SteamID   string    `json:"steam_id"   parquet:"steam_id,optional"`
+	Action    string    `json:"action"     parquet:"action"`
+	IPAddress string    `json:"ip_address" parquet:"ip_address,optional"`
+	Country   string    `json:"country"    parquet:"country,optional"`
+}
+
+func toEventRecord(logData dto.LogData) eventRecord {
+	return eventRecord{
+		TimeStamp: logData.TimeStamp,
+		NickName:  logData.NickName,
+		SteamID:   logData.SteamID,
+		Action:    logData.Action.String(),
+		IPAddress: logData.IPAddress,
+		Country:   logData.Country,
+	}
+}
+
+type sessionRecord struct {
+	NickName        string    `json:"nick_name"        parquet:"nick_name"`
+	Start           time.Time `json:"start"            parquet:"start,timestamp(millisecond)"`
+	End             time.Time `json:"end"              parquet:"end,timestamp(millisecond)"`
+	DurationSeconds int64     `json:"duration_seconds" parquet:"duration_seconds"`
+}
+
+func toSessionRecord(session dto.Session) sessionRecord {
+	return sessionRecord{
+		NickName:        session.NickName,
+		Start:           session.Start,
+		End:             session.End,
+		DurationSeconds: int64(session.End.Sub(session.Start).Seconds()),
+	}
+}
+
+type sessionCSVWriter struct {
+	writer *csv.Writer
+}
+
+func newSessionCSVWriter(w io.Writer) (*sessionCSVWriter, error) {
+	writer := csv.NewWriter(w)
+	if err := writer.Write([]string{"NickName", "Start", "End", "DurationSeconds"}); err != nil {
+		return nil, fmt.Errorf("failed to write CSV header: %w", err)
+	}
+	return &sessionCSVWriter{writer: writer}, nil
+}
+
+func (w *sessionCSVWriter) Write(session dto.Session) error {
+	record := toSessionRecord(session)
+	if err := w.writer.Write([]string{
+		record.NickName,
+		record.Start.Format(sessionTimeFormat),
+		record.End.Format(sessionTimeFormat),
+		strconv.FormatInt(record.DurationSeconds, 10),
+	}); err != nil {
+		return fmt.Errorf("failed to write CSV row: %w", err)
+	}
+	return nil
+}
+
+func (w *sessionCSVWriter) Close() error {
+	w.writer.Flush()
+	if err := w.writer.Error(); err != nil {
+		return fmt.Errorf("failed to flush CSV writer: %w", err)
+	}
+	return nil
+}
+
+// eventCSVWriter adapts the csvgenerator writer, so events keep the column layout of the stored files.
+type eventCSVWriter struct {
+	writer interface {
+		Write(data dto.LogData) error
+		Flush() error
+	}
+}
+
+func (w *eventCSVWriter) Write(logData dto.LogData) error {
+	return w.writer.Write(logData)
+}
+
+func (w *eventCSVWriter) Close() error {
+	return w.writer.Flush()
+}
@@ -0,0 +1,98 @@
+package generation
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/dto"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/tools"
+)
+
+const generationFileName = "generation.json"
+
+// Service keeps the generation of the stored data, which HTTP caching validators are derived from.
+// It is kept on disk, so the offline tools move it on for the API as well.
+type Service struct {
+	config config
+	mu     sync.Mutex
+}
+
+func NewService(config config) *Service {
+	return &Service{
+		config: config,
+	}
+}
+
+// Get returns the current generation, the zero one if the data never changed since it is tracked.
+func (s *Service) Get() (*dto.Generation, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.load()
+}
+
+// Bump moves on to the next generation.
+func (s *Service) Bump() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	generation, err := s.load()
+	if err != nil {
+		return err
+	}
+	generation.Number++
+	generation.UpdatedAt = time.Now()
+
+	content, err := json.Marshal(generation)
+	if err != nil {
+		return fmt.Errorf("failed to encode generation: %w", err)
+	}
+	if err := tools.WriteFileAtomic(s.filePath(), content, 0o600); err != nil {
+		return fmt.Errorf("failed to save generation: %w", err)
+	}
+	return nil
+}
+
+// Index bumps the generation after a parse stored entries the graphs are built from. Chat alone feeds none.
+func (s *Service) Index(_ context.Context, batch *dto.ParseBatch) error {
+	if len(batch.Logs) == 0 && len(batch.RoundEvents) == 0 {
+		return nil
+	}
+	return s.Bump()
+}
+
+// Rebuild bumps the generation after a compaction dropped entries.
+func (s *Service) Rebuild(_ context.Context) (int, error) {
+	return 0, s.Bump()
+}
+
+// Erase bumps the generation after a player was erased.
+func (s *Service) Erase(_ context.Context, _ dto.ErasureTarget) (int, error) {
+	return 0, s.Bump()
+}
+
+func (s *Service) load() (*dto.Generation, error) {
+	var generation dto.Generation
+
+	content, err := os.ReadFile(s.filePath())
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return &generation, nil
+		}
+		return nil, fmt.Errorf("failed to read generation: %w", err)
+	}
+	if err := json.Unmarshal(content, &generation); err != nil {
+		return nil, fmt.Errorf("failed to decode generation: %w", err)
+	}
+	return &generation, nil
+}
+
+func (s *Service) filePath() string {
+	return filepath.Join(s.config.StorageDirectory, generationFileName)
+}
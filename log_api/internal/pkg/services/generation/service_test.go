@@ -0,0 +1,72 @@
+package generation_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/dto"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/enums"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/services/generation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestService_Index(t *testing.T) {
+	t.Parallel()
+	base := time.Date(2025, 3, 15, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		batches []*dto.ParseBatch
+		want    uint64
+	}{
+		{
+			name: "success: entries move the generation on",
+			batches: []*dto.ParseBatch{
+				{Logs: []dto.LogData{{TimeStamp: base, NickName: "a", Action: enums.Actions.Connected()}}},
+				{RoundEvents: []dto.RoundEvent{{TimeStamp: base, Type: enums.RoundEventTypes.RoundStarted()}}},
+			},
+			want: 2,
+		},
+		{
+			name: "success: chat alone keeps the generation",
+			batches: []*dto.ParseBatch{
+				{Chat: []dto.ChatMessage{{TimeStamp: base, NickName: "a", Message: "hi"}}},
+				{},
+			},
+			want: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			service := generation.NewService(*generation.NewConfig(t.TempDir()))
+			for _, batch := range tt.batches {
+				require.NoError(t, service.Index(context.Background(), batch))
+			}
+
+			current, err := service.Get()
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, current.Number)
+			assert.Equal(t, tt.want == 0, current.UpdatedAt.IsZero())
+		})
+	}
+}
+
+func TestService_Bump(t *testing.T) {
+	t.Parallel()
+	directory := t.TempDir()
+	service := generation.NewService(*generation.NewConfig(directory))
+
+	_, err := service.Rebuild(context.Background())
+	require.NoError(t, err)
+	_, err = service.Erase(context.Background(), dto.ErasureTarget{SteamID: "[U:1:42]"})
+	require.NoError(t, err)
+
+	// Another process sharing the state directory sees the same generation
+	current, err := generation.NewService(*generation.NewConfig(directory)).Get()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), current.Number)
+	assert.WithinDuration(t, time.Now(), current.UpdatedAt, time.Minute)
+}
@@ -0,0 +1,208 @@
+package graph
+
+import (
+	"iter"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/dto"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/tools"
+)
+
+const (
+	dailyPeaksLimit  = 31
+	weeklyPeaksLimit = 12
+	daysInWeek       = 7
+)
+
+type concurrencyEvent struct {
+	timeStamp time.Time
+	nickName  string
+	delta     int
+}
+
+type period struct {
+	start func(t time.Time) time.Time
+	next  func(t time.Time) time.Time
+}
+
+// Sessions returns the sessionizer output for the given logs, ordered by session start.
+// The logs are left in the order they came in.
+func (s *Service) Sessions(logs []*dto.LogData) []dto.Session {
+	logs = slices.Clone(logs)
+	sort.SliceStable(logs, func(i, j int) bool {
+		return logs[i].TimeStamp.Before(logs[j].TimeStamp)
+	})
+
+	// a slice can not fail
+	sessions, _ := s.SessionsOf(tools.SeqOf(logs))
+	return sessions
+}
+
+// SessionsOf returns the sessions of log entries streamed in chronological order, ordered by session start.
+// Only the sessions are kept in memory, not the entries.
+func (s *Service) SessionsOf(logs iter.Seq2[*dto.LogData, error]) ([]dto.Session, error) {
+	var sessions []dto.Session
+	if err := sessionize(logs, func(session dto.Session, _ bool) {
+		sessions = append(sessions, session)
+	}); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		if sessions[i].Start.Equal(sessions[j].Start) {
+			return sessions[i].NickName < sessions[j].NickName
+		}
+		return sessions[i].Start.Before(sessions[j].Start)
+	})
+
+	return sessions, nil
+}
+
+// ConcurrencySteps turns sessions into an exact concurrent-players step function.
+// Leaves are applied before joins happening at the same second, so a player swap does not produce a fake spike.
+func (s *Service) ConcurrencySteps(sessions []dto.Session) []dto.ConcurrencyStep {
+	events := make([]concurrencyEvent, 0, len(sessions)*2) //nolint:mnd // start and end of every session
+	for _, session := range sessions {
+		if !session.End.After(session.Start) {
+			continue
+		}
+		events = append(events,
+			concurrencyEvent{timeStamp: session.Start, nickName: session.NickName, delta: 1},
+			concurrencyEvent{timeStamp: session.End, nickName: session.NickName, delta: -1},
+		)
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		if events[i].timeStamp.Equal(events[j].timeStamp) {
+			return events[i].delta < events[j].delta
+		}
+		return events[i].timeStamp.Before(events[j].timeStamp)
+	})
+
+	var steps []dto.ConcurrencyStep
+	online := make(map[string]int)
+	for i := 0; i < len(events); {
+		timeStamp := events[i].timeStamp
+		for ; i < len(events) && events[i].timeStamp.Equal(timeStamp); i++ {
+			online[events[i].nickName] += events[i].delta
+			if online[events[i].nickName] <= 0 {
+				delete(online, events[i].nickName)
+			}
+		}
+
+		players := make([]string, 0, len(online))
+		for nickName := range online {
+			players = append(players, nickName)
+		}
+		sort.Strings(players)
+
+		if len(steps) > 0 && slices.Equal(steps[len(steps)-1].Players, players) {
+			continue
+		}
+		steps = append(steps, dto.ConcurrencyStep{
+			TimeStamp: timeStamp,
+			Count:     len(players),
+			Players:   players,
+		})
+	}
+
+	return steps
+}
+
+// PeakConcurrency reports daily, weekly and all-time concurrency peaks with the players online at the peak.
+func (s *Service) PeakConcurrency(logs iter.Seq2[*dto.LogData, error]) (dto.ConcurrencyPeaks, error) {
+	sessions, err := s.SessionsOf(logs)
+	if err != nil {
+		return dto.ConcurrencyPeaks{}, err
+	}
+	steps := s.ConcurrencySteps(sessions)
+
+	location := tools.GetCETLocation()
+	day := period{
+		start: func(t time.Time) time.Time {
+			t = t.In(location)
+			return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, location)
+		},
+		next: func(t time.Time) time.Time { return t.AddDate(0, 0, 1) },
+	}
+	week := period{
+		start: func(t time.Time) time.Time {
+			dayStart := day.start(t)
+			// Weeks start on Monday
+			return dayStart.AddDate(0, 0, -((int(dayStart.Weekday()) + daysInWeek - 1) % daysInWeek))
+		},
+		next: func(t time.Time) time.Time { return t.AddDate(0, 0, daysInWeek) },
+	}
+
+	return dto.ConcurrencyPeaks{
+		Daily:   lastPeaks(s.peaksByPeriod(steps, day), dailyPeaksLimit),
+		Weekly:  lastPeaks(s.peaksByPeriod(steps, week), weeklyPeaksLimit),
+		AllTime: s.AllTimePeak(steps),
+	}, nil
+}
+
+// AllTimePeak returns the first moment the highest concurrency was reached, or nil if nobody was ever online.
+func (s *Service) AllTimePeak(steps []dto.ConcurrencyStep) *dto.ConcurrencyPeak {
+	var peak *dto.ConcurrencyPeak
+	for _, step := range steps {
+		if step.Count == 0 || (peak != nil && step.Count <= peak.Count) {
+			continue
+		}
+		peak = &dto.ConcurrencyPeak{
+			TimeStamp: step.TimeStamp,
+			Count:     step.Count,
+			Players:   step.Players,
+		}
+	}
+	return peak
+}
+
+func (s *Service) peaksByPeriod(steps []dto.ConcurrencyStep, p period) []dto.ConcurrencyPeak {
+	peaks := make(map[time.Time]*dto.ConcurrencyPeak)
+	consider := func(periodStart, timeStamp time.Time, step dto.ConcurrencyStep) {
+		if step.Count == 0 {
+			return
+		}
+		if current, ok := peaks[periodStart]; ok && current.Count >= step.Count {
+			return
+		}
+		peaks[periodStart] = &dto.ConcurrencyPeak{
+			PeriodStart: periodStart,
+			TimeStamp:   timeStamp,
+			Count:       step.Count,
+			Players:     step.Players,
+		}
+	}
+
+	for i, step := range steps {
+		periodStart := p.start(step.TimeStamp)
+		consider(periodStart, step.TimeStamp, step)
+		if i+1 >= len(steps) {
+			continue
+		}
+		// The step lasts until the next one, so it also counts at the start of every period it spans
+		stepEnd := steps[i+1].TimeStamp
+		for next := p.next(periodStart); next.Before(stepEnd); next = p.next(next) {
+			consider(next, next, step)
+		}
+	}
+
+	result := make([]dto.ConcurrencyPeak, 0, len(peaks))
+	for _, peak := range peaks {
+		result = append(result, *peak)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].PeriodStart.Before(result[j].PeriodStart)
+	})
+
+	return result
+}
+
+func lastPeaks(peaks []dto.ConcurrencyPeak, limit int) []dto.ConcurrencyPeak {
+	if len(peaks) > limit {
+		return peaks[len(peaks)-limit:]
+	}
+	return peaks
+}
@@ -0,0 +1,116 @@
+package graph_test
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/dto"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/enums"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/services/graph"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/tools"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestService_Sessions(t *testing.T) {
+	t.Parallel()
+	base := time.Date(2025, 3, 15, 12, 0, 0, 0, time.UTC)
+	logs := []*dto.LogData{
+		{TimeStamp: base.Add(time.Hour), NickName: "a", Action: enums.Actions.Disconnected()},
+		{TimeStamp: base, NickName: "a", Action: enums.Actions.Connected()},
+	}
+	given := slices.Clone(logs)
+
+	sessions := graph.NewService(nil).Sessions(logs)
+	assert.Equal(t, []dto.Session{{NickName: "a", Start: base, End: base.Add(time.Hour)}}, sessions)
+	// The caller's slice keeps its order
+	assert.Equal(t, given, logs)
+}
+
+func TestService_ConcurrencySteps(t *testing.T) {
+	t.Parallel()
+	base := time.Date(2025, 3, 15, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		sessions []dto.Session
+		assert   func(t *testing.T, steps []dto.ConcurrencyStep)
+	}{
+		{
+			name: "success: overlapping sessions",
+			sessions: []dto.Session{
+				{NickName: "a", Start: base, End: base.Add(time.Hour)},
+				{NickName: "b", Start: base.Add(10 * time.Minute), End: base.Add(30 * time.Minute)},
+			},
+			assert: func(t *testing.T, steps []dto.ConcurrencyStep) {
+				assert.Equal(t, []dto.ConcurrencyStep{
+					{TimeStamp: base, Count: 1, Players: []string{"a"}},
+					{TimeStamp: base.Add(10 * time.Minute), Count: 2, Players: []string{"a", "b"}},
+					{TimeStamp: base.Add(30 * time.Minute), Count: 1, Players: []string{"a"}},
+					{TimeStamp: base.Add(time.Hour), Count: 0, Players: []string{}},
+				}, steps)
+			},
+		},
+		{
+			name: "success: swap at the same second is not a spike",
+			sessions: []dto.Session{
+				{NickName: "a", Start: base, End: base.Add(time.Hour)},
+				{NickName: "b", Start: base.Add(time.Hour), End: base.Add(2 * time.Hour)},
+			},
+			assert: func(t *testing.T, steps []dto.ConcurrencyStep) {
+				for _, step := range steps {
+					assert.LessOrEqual(t, step.Count, 1)
+				}
+			},
+		},
+		{
+			name: "success: empty sessions are ignored",
+			sessions: []dto.Session{
+				{NickName: "a", Start: base, End: base},
+			},
+			assert: func(t *testing.T, steps []dto.ConcurrencyStep) {
+				assert.Empty(t, steps)
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			service := graph.NewService(nil)
+			test.assert(t, service.ConcurrencySteps(test.sessions))
+		})
+	}
+}
+
+func TestService_PeakConcurrency(t *testing.T) {
+	t.Parallel()
+	base := time.Date(2025, 3, 17, 10, 0, 0, 0, time.UTC)
+	logs := []*dto.LogData{
+		{TimeStamp: base, NickName: "a", Action: enums.Actions.Connected()},
+		{TimeStamp: base.Add(time.Minute), NickName: "b", Action: enums.Actions.Connected()},
+		{TimeStamp: base.Add(2 * time.Minute), NickName: "c", Action: enums.Actions.Connected()},
+		{TimeStamp: base.Add(time.Hour), NickName: "c", Action: enums.Actions.Disconnected()},
+		{TimeStamp: base.Add(2 * time.Hour), NickName: "b", Action: enums.Actions.Disconnected()},
+		{TimeStamp: base.Add(3 * time.Hour), NickName: "a", Action: enums.Actions.Disconnected()},
+		{TimeStamp: base.Add(24 * time.Hour), NickName: "d", Action: enums.Actions.Connected()},
+		{TimeStamp: base.Add(25 * time.Hour), NickName: "d", Action: enums.Actions.Disconnected()},
+	}
+
+	peaks, err := graph.NewService(nil).PeakConcurrency(tools.SeqOf(logs))
+	require.NoError(t, err)
+
+	assert.NotNil(t, peaks.AllTime)
+	assert.Equal(t, 3, peaks.AllTime.Count)
+	assert.Equal(t, base.Add(2*time.Minute), peaks.AllTime.TimeStamp)
+	assert.Equal(t, []string{"a", "b", "c"}, peaks.AllTime.Players)
+
+	assert.Len(t, peaks.Daily, 2)
+	assert.Equal(t, 3, peaks.Daily[0].Count)
+	assert.Equal(t, 1, peaks.Daily[1].Count)
+	assert.Equal(t, []string{"d"}, peaks.Daily[1].Players)
+
+	assert.Len(t, peaks.Weekly, 1)
+	assert.Equal(t, 3, peaks.Weekly[0].Count)
+	assert.Equal(t, time.Monday, peaks.Weekly[0].PeriodStart.Weekday())
+}
@@ -1,7 +1,11 @@
 package graph
 
-import "github.com/rumblefrog/go-a2s"
+import (
+	"context"
+
+	"github.com/rumblefrog/go-a2s"
+)
 
 type a2sClient interface {
-	QueryPlayer() (*a2s.PlayerInfo, error)
+	QueryPlayer(ctx context.Context) (*a2s.PlayerInfo, error)
 }
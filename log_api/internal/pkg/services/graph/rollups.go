@@ -0,0 +1,71 @@
+package graph
+
+import (
+	"iter"
+	"time"
+
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/dto"
+)
+
+const dailyActivesDays = 31
+
+// TopTimeSpentFromRollups answers TopTimeSpent from the rollups, the open sessions counting up to the last activity.
+func (s *Service) TopTimeSpentFromRollups(rollups *dto.Rollups) dto.TopTimeSpentList {
+	totalSessionsDurations := make(map[string]time.Duration, len(rollups.Players))
+	for nickName, player := range rollups.Players {
+		totalSessionsDurations[nickName] = player.TimeSpent
+	}
+	for nickName, session := range rollups.OpenSessions {
+		totalSessionsDurations[nickName] += session.LastActivity.Sub(session.Start)
+	}
+	return topTimeSpent(totalSessionsDurations)
+}
+
+// TopCountriesFromRollups answers TopCountries from the rollups.
+func (s *Service) TopCountriesFromRollups(rollups *dto.Rollups) dto.TopCountriesPercentageList {
+	return topCountriesPercentages(rollups.CountryConnections, rollups.ConnectionsCount)
+}
+
+// OnlineStatisticsFromRollups answers OnlineStatistics from the rollups.
+func (s *Service) OnlineStatisticsFromRollups(rollups *dto.Rollups, until time.Time) dto.OnlineStatistics {
+	hourlyOnlineSeconds := make([]float64, hoursInDay)
+	copy(hourlyOnlineSeconds, rollups.HourlyOnlineSeconds)
+	return onlineStatistics(hourlyOnlineSeconds, rollups.FirstDay, until)
+}
+
+// DailyActives counts the players seen per day over the last dailyActivesDays days until the given time.
+func (s *Service) DailyActives(logs iter.Seq2[*dto.LogData, error], until time.Time) (dto.DailyActives, error) {
+	dailyActivePlayers := make(map[string]map[string]struct{})
+	firstDay := startOfDay(until).AddDate(0, 0, 1-dailyActivesDays)
+	for logEntry, err := range logs {
+		if err != nil {
+			return nil, err
+		}
+		if logEntry.TimeStamp.Before(firstDay) {
+			continue
+		}
+		day := logEntry.TimeStamp.Format(time.DateOnly)
+		if dailyActivePlayers[day] == nil {
+			dailyActivePlayers[day] = make(map[string]struct{})
+		}
+		dailyActivePlayers[day][logEntry.NickName] = struct{}{}
+	}
+
+	return dailyActives(func(day string) int { return len(dailyActivePlayers[day]) }, until), nil
+}
+
+// DailyActivesFromRollups answers DailyActives from the rollups.
+func (s *Service) DailyActivesFromRollups(rollups *dto.Rollups, until time.Time) dto.DailyActives {
+	return dailyActives(func(day string) int { return len(rollups.DailyActivePlayers[day]) }, until)
+}
+
+// dailyActives lists the days until the given time oldest first, the ones without players included.
+func dailyActives(playersCount func(day string) int, until time.Time) dto.DailyActives {
+	lastDay := startOfDay(until)
+	actives := make(dto.DailyActives, 0, dailyActivesDays)
+	for d := lastDay.AddDate(0, 0, 1-dailyActivesDays); !d.After(lastDay); d = d.AddDate(0, 0, 1) {
+		day := d.Format(time.DateOnly)
+		actives = append(actives, dto.DailyActive{Day: day, PlayersCount: playersCount(day)})
+	}
+	return actives
+}
@@ -0,0 +1,77 @@
+package graph
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/dto"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/enums"
+)
+
+type mapRoundsTotals struct {
+	mode           enums.GameMode
+	rounds         int
+	finishedRounds int
+	wins           int
+	participants   int
+	extracted      int
+	duration       time.Duration
+}
+
+// RoundStatistics reports per map win rate and extraction rate (both in percent) and the average round duration.
+// Aborted rounds are counted, but they do not affect the rates and the average duration.
+func (s *Service) RoundStatistics(rounds []dto.Round) dto.RoundStatisticsList {
+	totalsByMap := make(map[string]*mapRoundsTotals)
+	for _, round := range rounds {
+		totals, ok := totalsByMap[round.Map]
+		if !ok {
+			totals = &mapRoundsTotals{mode: round.Mode}
+			totalsByMap[round.Map] = totals
+		}
+		totals.rounds++
+		if round.Outcome == enums.RoundOutcomes.Aborted() {
+			continue
+		}
+		totals.finishedRounds++
+		if round.Outcome == enums.RoundOutcomes.Success() {
+			totals.wins++
+		}
+		totals.participants += len(round.Participants)
+		totals.extracted += len(round.Extracted)
+		totals.duration += round.End.Sub(round.Start)
+	}
+
+	statistics := make(dto.RoundStatisticsList, 0, len(totalsByMap))
+	for mapName, totals := range totalsByMap {
+		roundStatistics := dto.RoundStatistics{
+			Map:         mapName,
+			Mode:        totals.mode,
+			RoundsCount: totals.rounds,
+			Wins:        totals.wins,
+		}
+		if totals.finishedRounds > 0 {
+			roundStatistics.WinRate = percentage(totals.wins, totals.finishedRounds)
+			roundStatistics.AverageRoundDuration = (totals.duration / time.Duration(totals.finishedRounds)).
+				Round(time.Second)
+		}
+		if totals.participants > 0 {
+			roundStatistics.ExtractionRate = percentage(totals.extracted, totals.participants)
+		}
+		statistics = append(statistics, roundStatistics)
+	}
+
+	sort.Slice(statistics, func(i, j int) bool {
+		if statistics[i].RoundsCount == statistics[j].RoundsCount {
+			return statistics[i].Map < statistics[j].Map
+		}
+		return statistics[i].RoundsCount > statistics[j].RoundsCount
+	})
+
+	return statistics
+}
+
+func percentage(part, total int) float64 {
+	//nolint:mnd // Round to 2 decimals
+	return math.Round(float64(part)/float64(total)*maxCentsCount*100) / 100
+}
@@ -0,0 +1,53 @@
+package graph_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/dto"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/enums"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/services/graph"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestService_RoundStatistics(t *testing.T) {
+	t.Parallel()
+	base := time.Date(2025, 3, 15, 12, 0, 0, 0, time.UTC)
+	roundsTable := []dto.Round{
+		{
+			Map: "nmo_broadway", Mode: enums.GameModes.Objective(),
+			Start: base, End: base.Add(20 * time.Minute), Outcome: enums.RoundOutcomes.Success(),
+			Participants: []string{"a", "b", "c", "d"}, Extracted: []string{"a"},
+		},
+		{
+			Map: "nmo_broadway", Mode: enums.GameModes.Objective(),
+			Start: base, End: base.Add(10 * time.Minute), Outcome: enums.RoundOutcomes.Failure(),
+			Participants: []string{"a", "b", "c", "d"},
+		},
+		{
+			Map: "nmo_broadway", Mode: enums.GameModes.Objective(),
+			Start: base, End: base.Add(time.Hour), Outcome: enums.RoundOutcomes.Aborted(),
+			Participants: []string{"a"},
+		},
+		{
+			Map: "nms_midway", Mode: enums.GameModes.Survival(),
+			Start: base, End: base.Add(30 * time.Minute), Outcome: enums.RoundOutcomes.Success(),
+			Participants: []string{"a"}, Extracted: []string{"a"},
+		},
+	}
+
+	statistics := graph.NewService(nil).RoundStatistics(roundsTable)
+
+	assert.Equal(t, dto.RoundStatisticsList{
+		{
+			Map: "nmo_broadway", Mode: enums.GameModes.Objective(),
+			RoundsCount: 3, Wins: 1, WinRate: 50, ExtractionRate: 12.5,
+			AverageRoundDuration: 15 * time.Minute,
+		},
+		{
+			Map: "nms_midway", Mode: enums.GameModes.Survival(),
+			RoundsCount: 1, Wins: 1, WinRate: 100, ExtractionRate: 100,
+			AverageRoundDuration: 30 * time.Minute,
+		},
+	}, statistics)
+}
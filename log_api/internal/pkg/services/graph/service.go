@@ -1,6 +1,8 @@
 package graph
 
 import (
+	"context"
+	"iter"
 	"math"
 	"sort"
 	"time"
@@ -29,9 +31,19 @@ func NewService(a2sClient a2sClient) *Service {
 	return &Service{a2sClient: a2sClient}
 }
 
-func (s *Service) TopTimeSpent(logs []*dto.LogData) dto.TopTimeSpentList {
-	totalSessionsDurations := s.getTotalSessionsDuration(logs)
+// TopTimeSpent sums up the session durations per player.
+func (s *Service) TopTimeSpent(logs iter.Seq2[*dto.LogData, error]) (dto.TopTimeSpentList, error) {
+	totalSessionsDurations := make(map[string]time.Duration)
+	if err := sessionize(logs, func(session dto.Session, _ bool) {
+		totalSessionsDurations[session.NickName] += session.End.Sub(session.Start)
+	}); err != nil {
+		return nil, err
+	}
 
+	return topTimeSpent(totalSessionsDurations), nil
+}
+
+func topTimeSpent(totalSessionsDurations map[string]time.Duration) dto.TopTimeSpentList {
 	topTimeSpentList := make(dto.TopTimeSpentList, 0, len(totalSessionsDurations))
 	for nickName, totalSessionsDuration := range totalSessionsDurations {
 		topTimeSpentList = append(topTimeSpentList, &dto.TopTimeSpent{
@@ -51,135 +63,30 @@ func (s *Service) TopTimeSpent(logs []*dto.LogData) dto.TopTimeSpentList {
 	return topTimeSpentList
 }
 
-func (s *Service) getTotalSessionsDuration(logs []*dto.LogData) map[string]time.Duration {
-	totalSessionsDurations := make(map[string]time.Duration)
-	lastConnected := make(map[string]time.Time)
-
-	for _, logEntry := range logs {
-		switch logEntry.Action {
-		case enums.Actions.Connected():
-			{
-				if _, ok := lastConnected[logEntry.NickName]; ok {
-					lastActivityTimeStamp := s.findLastUserActivityTimeStampBefore(
-						logs,
-						logEntry.TimeStamp,
-						logEntry.NickName,
-					)
-					if lastActivityTimeStamp == nil {
-						// Impossible sceanrio, but just in case
-						// If there is no activity at all before the current (second in line) connection, skip
-						continue
-					}
-					s.addDurationToTotal(
-						logEntry.NickName,
-						*lastActivityTimeStamp,
-						lastConnected,
-						totalSessionsDurations,
-					)
-				}
-				lastConnected[logEntry.NickName] = logEntry.TimeStamp
-				break
-			}
-		case enums.Actions.Disconnected():
-			{
-				if _, ok := lastConnected[logEntry.NickName]; !ok {
-					continue
-				}
-				s.addDurationToTotal(
-					logEntry.NickName,
-					logEntry.TimeStamp,
-					lastConnected,
-					totalSessionsDurations,
-				)
-				break
-			}
-		}
-	}
-
-	if len(lastConnected) > 0 {
-		// If there are still connected users at the end of the logs
-		// Add their current session duration to the total
-		for nickName := range lastConnected {
-			lastActivityTimeStamp := s.findLastUserActivityTimeStamp(
-				logs,
-				nickName,
-			)
-			if lastActivityTimeStamp == nil {
-				// Impossible sceanrio, but just in case
-				// If there is no activity at all before the current (second in line) connection, skip
-				continue
-			}
-			s.addDurationToTotal(
-				nickName,
-				*lastActivityTimeStamp,
-				lastConnected,
-				totalSessionsDurations,
-			)
-		}
-	}
-
-	return totalSessionsDurations
-}
-
-func (s *Service) addDurationToTotal(
-	nickName string,
-	lastActivityTimeStamp time.Time,
-	lastConnected map[string]time.Time,
-	totalSessionsDurations map[string]time.Duration,
-) {
-	lastSessionDuration := lastActivityTimeStamp.Sub(lastConnected[nickName])
-	totalSessionsDurations[nickName] += lastSessionDuration
-	delete(lastConnected, nickName)
-}
+func (s *Service) TopCountries(logs iter.Seq2[*dto.LogData, error]) (dto.TopCountriesPercentageList, error) {
+	countriesConnectionsList := make(map[string]int)
+	var allConnectionsCount int
 
-func (s *Service) findLastUserActivityTimeStampBefore(
-	logs []*dto.LogData,
-	before time.Time,
-	nickName string,
-) *time.Time {
-	var lastActivity time.Time
-	for _, entry := range logs {
-		if entry.NickName == nickName && entry.TimeStamp.Before(before) {
-			if entry.TimeStamp.After(lastActivity) {
-				lastActivity = entry.TimeStamp
-			}
+	for logEntry, err := range logs {
+		if err != nil {
+			return nil, err
 		}
-	}
-
-	if lastActivity.IsZero() {
-		return nil
-	}
-	return &lastActivity
-}
-
-func (s *Service) findLastUserActivityTimeStamp(
-	logs []*dto.LogData,
-	nickName string,
-) *time.Time {
-	var lastActivity time.Time
-	for _, entry := range logs {
-		if entry.NickName == nickName && entry.TimeStamp.After(lastActivity) {
-			lastActivity = entry.TimeStamp
+		if logEntry.Action == enums.Actions.Connected() {
+			countriesConnectionsList[logEntry.Country]++
+			allConnectionsCount++
 		}
 	}
 
-	if lastActivity.IsZero() {
-		return nil
-	}
-	return &lastActivity
+	return topCountriesPercentages(countriesConnectionsList, allConnectionsCount), nil
 }
 
-func (s *Service) TopCountries(logs []*dto.LogData) dto.TopCountriesPercentageList {
-	countriesConnectionsList := make(map[string]int)
-	var allConnectionsCount int
-
-	for _, logEntry := range logs {
-		if logEntry.Action == enums.Actions.Connected() {
-			if logEntry.Country == "" {
-				countriesConnectionsList["Unknown"]++
-			}
-			countriesConnectionsList[logEntry.Country]++
-			allConnectionsCount++
+// topCountriesPercentages ranks the connections per country, the ones without a country are also counted as Unknown.
+func topCountriesPercentages(connections map[string]int, allConnectionsCount int) dto.TopCountriesPercentageList {
+	countriesConnectionsList := make(map[string]int, len(connections)+1)
+	for country, connectionsCount := range connections {
+		countriesConnectionsList[country] += connectionsCount
+		if country == "" {
+			countriesConnectionsList["Unknown"] += connectionsCount
 		}
 	}
 
@@ -221,8 +128,8 @@ func (s *Service) TopCountries(logs []*dto.LogData) dto.TopCountriesPercentageLi
 	return topCountriesPercentageList
 }
 
-func (s *Service) PlayersInfo() (*dto.PlayersInfo, error) {
-	playersInfo, err := s.a2sClient.QueryPlayer()
+func (s *Service) PlayersInfo(ctx context.Context) (*dto.PlayersInfo, error) {
+	playersInfo, err := s.a2sClient.QueryPlayer(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -248,86 +155,81 @@ func (s *Service) mapPlayersInfo(playersInfo *a2s.PlayerInfo) *dto.PlayersInfo {
 	return playersInfoDto
 }
 
-func (s *Service) OnlineStatistics(logsInput []*dto.LogData) dto.OnlineStatistics {
-	sort.Slice(logsInput, func(i, j int) bool {
-		return logsInput[i].TimeStamp.Before(logsInput[j].TimeStamp)
-	})
-
-	requestTimeStamp := time.Now()
-	earliestLogEntry := requestTimeStamp
-	var logs []*dto.LogData
-	for _, logEntry := range logsInput {
-		if logEntry.Action != enums.Actions.Connected() && logEntry.Action != enums.Actions.Disconnected() {
-			continue
-		}
-		logs = append(logs, logEntry)
-		if logEntry.TimeStamp.Before(earliestLogEntry) {
-			earliestLogEntry = logEntry.TimeStamp
+// OnlineStatistics averages the players online per hour of the day, from the day of the first entry until the given time.
+func (s *Service) OnlineStatistics(
+	logs iter.Seq2[*dto.LogData, error],
+	until time.Time,
+) (dto.OnlineStatistics, error) {
+	var timelineStart time.Time
+	connections := func(yield func(*dto.LogData, error) bool) {
+		for logEntry, err := range logs {
+			if err == nil && logEntry.Action != enums.Actions.Connected() &&
+				logEntry.Action != enums.Actions.Disconnected() {
+				continue
+			}
+			if err == nil && timelineStart.IsZero() {
+				timelineStart = startOfDay(logEntry.TimeStamp)
+			}
+			if !yield(logEntry, err) {
+				return
+			}
 		}
 	}
 
-	sessions := s.getSessionsFromLogs(logs)
-	sessions = s.filterInvalidSessions(sessions)
-
-	timelineStart := time.Date(
-		earliestLogEntry.Year(),
-		earliestLogEntry.Month(),
-		earliestLogEntry.Day(),
-		0, 0, 0, 0, time.UTC,
-	)
-	timelineEnd := time.Date(
-		requestTimeStamp.Year(),
-		requestTimeStamp.Month(),
-		requestTimeStamp.Day(),
-		0, 0, 0, 0, time.UTC,
-	)
-
-	dayCount := 0
-	for d := timelineStart; d.Before(timelineEnd); d = d.Add(hoursInDay * time.Hour) {
-		dayCount++
+	hourlyOnlineSeconds := make([]float64, hoursInDay)
+	if err := sessionize(connections, func(session dto.Session, online bool) {
+		if online {
+			s.AddOnlineSeconds(hourlyOnlineSeconds, session)
+		}
+	}); err != nil {
+		return nil, err
 	}
-	if dayCount == 0 {
-		dayCount = 1
+
+	if timelineStart.IsZero() {
+		return onlineStatistics(hourlyOnlineSeconds, nil, until), nil
 	}
+	return onlineStatistics(hourlyOnlineSeconds, &timelineStart, until), nil
+}
 
-	hourlyOverlap := make([]float64, hoursInDay)
+// AddOnlineSeconds adds the seconds a session spent in every hour to the CET hour of the day.
+// Sessions shorter than minSessionDurationInMinutes are left out as noise.
+func (s *Service) AddOnlineSeconds(hourlyOnlineSeconds []float64, session dto.Session) {
+	if session.End.Sub(session.Start) < minSessionDurationInMinutes*time.Minute {
+		return
+	}
 
-	for _, session := range sessions {
-		effectiveStart := session.Start
-		if effectiveStart.Before(timelineStart) {
-			effectiveStart = timelineStart
+	for blockStart := session.Start.Truncate(time.Hour); blockStart.Before(session.End); blockStart = blockStart.Add(time.Hour) {
+		overlapStart := session.Start
+		if blockStart.After(overlapStart) {
+			overlapStart = blockStart
 		}
-		effectiveEnd := session.End
-		if effectiveEnd.After(timelineEnd.Add(hoursInDay * time.Hour)) {
-			effectiveEnd = timelineEnd.Add(hoursInDay * time.Hour)
+		overlapEnd := session.End
+		if blockEnd := blockStart.Add(time.Hour); blockEnd.Before(overlapEnd) {
+			overlapEnd = blockEnd
 		}
-		if !effectiveEnd.After(effectiveStart) {
-			continue
+		if overlap := overlapEnd.Sub(overlapStart).Seconds(); overlap > 0 {
+			hourlyOnlineSeconds[blockStart.In(tools.GetCETLocation()).Hour()] += overlap
 		}
+	}
+}
 
-		startIndex := int(effectiveStart.Sub(timelineStart).Hours())
-		endIndex := int(math.Ceil(effectiveEnd.Sub(timelineStart).Hours()))
-		for i := startIndex; i < endIndex; i++ {
-			blockStart := timelineStart.Add(time.Duration(i) * time.Hour)
-			blockEnd := blockStart.Add(time.Hour)
-			overlapStart := effectiveStart
-			if blockStart.After(overlapStart) {
-				overlapStart = blockStart
-			}
-			overlapEnd := effectiveEnd
-			if blockEnd.Before(overlapEnd) {
-				overlapEnd = blockEnd
-			}
-			overlap := overlapEnd.Sub(overlapStart).Seconds()
-			if overlap > 0 {
-				bucket := blockStart.In(tools.GetCETLocation()).Hour()
-				hourlyOverlap[bucket] += overlap
-			}
-		}
+// onlineStatistics averages the online seconds per hour over the days from firstDay until the given time.
+func onlineStatistics(hourlyOnlineSeconds []float64, firstDay *time.Time, until time.Time) dto.OnlineStatistics {
+	timelineEnd := startOfDay(until)
+	timelineStart := timelineEnd
+	if firstDay != nil && firstDay.Before(timelineEnd) {
+		timelineStart = *firstDay
+	}
+	dayCount := 0
+	for d := timelineStart; d.Before(timelineEnd); d = d.Add(hoursInDay * time.Hour) {
+		dayCount++
+	}
+	if dayCount == 0 {
+		dayCount = 1
 	}
 
 	avgHourlyStats := make(dto.OnlineStatistics, 0, hoursInDay)
-	for hour, totalOverlap := range hourlyOverlap {
+	for hour, totalOverlap := range hourlyOnlineSeconds {
 		//nolint:mnd // Round to 2 decimals
 		avg := math.Round(totalOverlap/(float64(dayCount)*secondsInHour)*100) / 100
 		avgHourlyStats = append(avgHourlyStats, dto.OnlineStatisticsHourUnit{
@@ -339,66 +241,55 @@ func (s *Service) OnlineStatistics(logsInput []*dto.LogData) dto.OnlineStatistic
 	return append(avgHourlyStats[5:], avgHourlyStats[:6]...)
 }
 
-func (s *Service) filterInvalidSessions(sessions []dto.Session) []dto.Session {
-	minSessionDuration := minSessionDurationInMinutes * time.Minute
-	validSessions := make([]dto.Session, 0, len(sessions))
-	for _, sess := range sessions {
-		if sess.End.Sub(sess.Start) >= minSessionDuration {
-			validSessions = append(validSessions, sess)
-		}
-	}
-	sessions = validSessions
-	return sessions
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
 }
 
-func (s *Service) getSessionsFromLogs(logs []*dto.LogData) []dto.Session {
-	var sessions []dto.Session
-	activeConnections := make(map[string]time.Time)
-	for _, logEntry := range logs {
+// sessionize turns log entries in chronological order into play sessions, keeping the state of the online players only.
+// A reconnect without a disconnect ends the previous session at the last activity before it,
+// and the sessions still open at the end of the logs end at the last activity of the player.
+// fn is also told whether the session counts as online time. The online statistics only count the sessions
+// ended by a disconnect: a reconnect without a disconnect drops the session it ends and the one it opens.
+func sessionize(logs iter.Seq2[*dto.LogData, error], fn func(session dto.Session, online bool)) error {
+	started := make(map[string]time.Time)
+	lastActivity := make(map[string]time.Time)
+	// reconnected holds the players whose session was opened by a reconnect without a disconnect
+	reconnected := make(map[string]struct{})
+
+	for logEntry, err := range logs {
+		if err != nil {
+			return err
+		}
+		nickName := logEntry.NickName
 		switch logEntry.Action {
 		case enums.Actions.Connected():
-			if _, exists := activeConnections[logEntry.NickName]; !exists {
-				activeConnections[logEntry.NickName] = logEntry.TimeStamp
-				continue
-			}
-			lastActivityTimeStamp := s.findLastUserActivityTimeStampBefore(
-				logs,
-				logEntry.TimeStamp,
-				logEntry.NickName,
-			)
-			if lastActivityTimeStamp == nil {
-				continue
+			if start, ok := started[nickName]; ok {
+				fn(dto.Session{NickName: nickName, Start: start, End: lastActivity[nickName]}, false)
+				// A dropped session does not drop the next one
+				if _, ok := reconnected[nickName]; ok {
+					delete(reconnected, nickName)
+				} else {
+					reconnected[nickName] = struct{}{}
+				}
 			}
-			sessions = append(sessions, dto.Session{
-				NickName: logEntry.NickName,
-				Start:    activeConnections[logEntry.NickName],
-				End:      *lastActivityTimeStamp,
-			})
-			delete(activeConnections, logEntry.NickName)
+			started[nickName] = logEntry.TimeStamp
 		case enums.Actions.Disconnected():
-			if start, exists := activeConnections[logEntry.NickName]; exists {
-				sessions = append(sessions, dto.Session{
-					NickName: logEntry.NickName,
-					Start:    start,
-					End:      logEntry.TimeStamp,
-				})
-				delete(activeConnections, logEntry.NickName)
+			if start, ok := started[nickName]; ok {
+				_, dropped := reconnected[nickName]
+				fn(dto.Session{NickName: nickName, Start: start, End: logEntry.TimeStamp}, !dropped)
+				delete(started, nickName)
+				delete(lastActivity, nickName)
+				delete(reconnected, nickName)
 			}
+			continue
 		}
-	}
-	if len(activeConnections) > 0 {
-		for nickName := range activeConnections {
-			lastActivityTimeStamp := s.findLastUserActivityTimeStamp(logs, nickName)
-			if lastActivityTimeStamp == nil {
-				continue
-			}
-			sessions = append(sessions, dto.Session{
-				NickName: nickName,
-				Start:    activeConnections[nickName],
-				End:      *lastActivityTimeStamp,
-			})
-			delete(activeConnections, nickName)
+		if _, online := started[nickName]; online {
+			lastActivity[nickName] = logEntry.TimeStamp
 		}
 	}
-	return sessions
+
+	for nickName, start := range started {
+		fn(dto.Session{NickName: nickName, Start: start, End: lastActivity[nickName]}, false)
+	}
+	return nil
 }
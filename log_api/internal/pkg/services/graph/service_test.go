@@ -0,0 +1,194 @@
+package graph_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/app/a2sclient"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/app/a2sclient/config"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/dto"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/enums"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/services/graph"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/testsupport"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/tools"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestService_TopTimeSpent(t *testing.T) {
+	t.Parallel()
+	base := time.Date(2025, 3, 17, 10, 0, 0, 0, time.UTC)
+	logs := []*dto.LogData{
+		{TimeStamp: base, NickName: "a", Action: enums.Actions.Connected()},
+		{TimeStamp: base.Add(time.Minute), NickName: "b", Action: enums.Actions.Connected()},
+		{TimeStamp: base.Add(11 * time.Minute), NickName: "b", Action: enums.Actions.Entered()},
+		{TimeStamp: base.Add(20 * time.Minute), NickName: "a", Action: enums.Actions.Disconnected()},
+		// a reconnect without a disconnect ends the session at the last activity before it
+		{TimeStamp: base.Add(30 * time.Minute), NickName: "b", Action: enums.Actions.Connected()},
+		// a disconnect of a player who is not online is ignored
+		{TimeStamp: base.Add(40 * time.Minute), NickName: "a", Action: enums.Actions.Disconnected()},
+		// a session still open ends at the last activity
+		{TimeStamp: base.Add(90 * time.Minute), NickName: "b", Action: enums.Actions.Entered()},
+	}
+
+	topTimeSpent, err := graph.NewService(nil).TopTimeSpent(tools.SeqOf(logs))
+	require.NoError(t, err)
+	assert.Equal(t, dto.TopTimeSpentList{
+		{NickName: "b", TimeSpent: 70 * time.Minute},
+		{NickName: "a", TimeSpent: 20 * time.Minute},
+	}, topTimeSpent)
+}
+
+func TestService_OnlineStatistics(t *testing.T) {
+	t.Parallel()
+	// 10:00 to 12:00 UTC is 11:00 to 13:00 CET
+	base := time.Date(2025, 1, 15, 10, 0, 0, 0, time.UTC)
+	logs := []*dto.LogData{
+		{TimeStamp: base, NickName: "a", Action: enums.Actions.Connected()},
+		{TimeStamp: base.Add(time.Minute), NickName: "b", Action: enums.Actions.Connected()},
+		// shorter than the minimal session, left out
+		{TimeStamp: base.Add(5 * time.Minute), NickName: "b", Action: enums.Actions.Disconnected()},
+		{TimeStamp: base.Add(2 * time.Hour), NickName: "a", Action: enums.Actions.Disconnected()},
+	}
+
+	stats, err := graph.NewService(nil).OnlineStatistics(tools.SeqOf(logs), base.AddDate(0, 0, 1))
+	require.NoError(t, err)
+
+	counts := make(map[int]float64)
+	for _, unit := range stats {
+		counts[unit.Hour] += unit.ConcurrentPlayersCount
+	}
+	assert.InDelta(t, 1.0, counts[11], 0.001)
+	assert.InDelta(t, 1.0, counts[12], 0.001)
+	assert.InDelta(t, 0.0, counts[13], 0.001)
+}
+
+func TestService_OnlineStatisticsReconnects(t *testing.T) {
+	t.Parallel()
+	// 10:00 to 12:00 UTC is 11:00 to 13:00 CET
+	base := time.Date(2025, 1, 15, 10, 0, 0, 0, time.UTC)
+	logs := []*dto.LogData{
+		{TimeStamp: base, NickName: "a", Action: enums.Actions.Connected()},
+		{TimeStamp: base, NickName: "b", Action: enums.Actions.Connected()},
+		// a reconnect without a disconnect drops the session it ends and the one it opens
+		{TimeStamp: base.Add(30 * time.Minute), NickName: "a", Action: enums.Actions.Connected()},
+		{TimeStamp: base.Add(30 * time.Minute), NickName: "b", Action: enums.Actions.Connected()},
+		// the next reconnect opens a session again
+		{TimeStamp: base.Add(time.Hour), NickName: "b", Action: enums.Actions.Connected()},
+		{TimeStamp: base.Add(2 * time.Hour), NickName: "a", Action: enums.Actions.Disconnected()},
+		{TimeStamp: base.Add(2 * time.Hour), NickName: "b", Action: enums.Actions.Disconnected()},
+	}
+	service := graph.NewService(nil)
+
+	stats, err := service.OnlineStatistics(tools.SeqOf(logs), base.AddDate(0, 0, 1))
+	require.NoError(t, err)
+	counts := make(map[int]float64)
+	for _, unit := range stats {
+		counts[unit.Hour] += unit.ConcurrentPlayersCount
+	}
+	assert.InDelta(t, 0.0, counts[11], 0.001)
+	assert.InDelta(t, 1.0, counts[12], 0.001)
+
+	// The time spent counts every session, up to the last activity before a reconnect
+	topTimeSpent, err := service.TopTimeSpent(tools.SeqOf(logs))
+	require.NoError(t, err)
+	assert.Equal(t, dto.TopTimeSpentList{
+		{NickName: "a", TimeSpent: 90 * time.Minute},
+		{NickName: "b", TimeSpent: 60 * time.Minute},
+	}, topTimeSpent)
+}
+
+func TestService_StreamErrors(t *testing.T) {
+	t.Parallel()
+	readErr := errors.New("broken file")
+	failing := func(yield func(*dto.LogData, error) bool) {
+		if !yield(&dto.LogData{NickName: "a", Action: enums.Actions.Connected()}, nil) {
+			return
+		}
+		yield(nil, readErr)
+	}
+	service := graph.NewService(nil)
+
+	_, err := service.TopTimeSpent(failing)
+	require.ErrorIs(t, err, readErr)
+	_, err = service.TopCountries(failing)
+	require.ErrorIs(t, err, readErr)
+	_, err = service.OnlineStatistics(failing, time.Now())
+	require.ErrorIs(t, err, readErr)
+	_, err = service.PeakConcurrency(failing)
+	require.ErrorIs(t, err, readErr)
+}
+
+func TestService_DailyActives(t *testing.T) {
+	t.Parallel()
+	until := time.Date(2025, 3, 31, 18, 0, 0, 0, time.UTC)
+	logs := []*dto.LogData{
+		// before the window, left out
+		{TimeStamp: time.Date(2025, 2, 28, 10, 0, 0, 0, time.UTC), NickName: "a", Action: enums.Actions.Connected()},
+		{TimeStamp: time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC), NickName: "a", Action: enums.Actions.Connected()},
+		{TimeStamp: time.Date(2025, 3, 1, 11, 0, 0, 0, time.UTC), NickName: "a", Action: enums.Actions.Entered()},
+		{TimeStamp: time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC), NickName: "b", Action: enums.Actions.Connected()},
+		{TimeStamp: time.Date(2025, 3, 31, 9, 0, 0, 0, time.UTC), NickName: "b", Action: enums.Actions.Disconnected()},
+	}
+
+	dailyActives, err := graph.NewService(nil).DailyActives(tools.SeqOf(logs), until)
+	require.NoError(t, err)
+	require.Len(t, dailyActives, 31)
+	assert.Equal(t, dto.DailyActive{Day: "2025-03-01", PlayersCount: 2}, dailyActives[0])
+	assert.Equal(t, dto.DailyActive{Day: "2025-03-02", PlayersCount: 0}, dailyActives[1])
+	assert.Equal(t, dto.DailyActive{Day: "2025-03-31", PlayersCount: 1}, dailyActives[30])
+}
+
+func TestService_PlayersInfo(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		players []testsupport.A2SPlayer
+		silent  bool
+		want    *dto.PlayersInfo
+		wantErr bool
+	}{
+		{
+			name: "success: players online",
+			players: []testsupport.A2SPlayer{
+				{Name: "Bateman", Score: 42, Duration: 90 * time.Minute},
+				{Name: "Wally", Score: 7, Duration: 30 * time.Second},
+			},
+			want: &dto.PlayersInfo{Count: 2, PlayerInfo: []*dto.PlayerInfo{
+				{Name: "Bateman", Score: 42, Duration: 5400},
+				{Name: "Wally", Score: 7, Duration: 30},
+			}},
+		},
+		{
+			name: "success: nobody online",
+			want: &dto.PlayersInfo{},
+		},
+		{
+			name:    "failure: server does not answer",
+			silent:  true,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			server := testsupport.NewFakeA2SServer(t, testsupport.A2SServerConfig{
+				Players:   tt.players,
+				Challenge: true,
+				Silent:    tt.silent,
+			})
+			client := a2sclient.NewA2SClient(config.NewA2SClientConfig(server.Host(), server.Port(), 100*time.Millisecond))
+
+			playersInfo, err := graph.NewService(client).PlayersInfo(context.Background())
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, playersInfo)
+		})
+	}
+}
@@ -0,0 +1,15 @@
+package health
+
+import "time"
+
+type config struct {
+	// CheckTimeout bounds every check, a slow dependency counts as a failed one
+	CheckTimeout time.Duration
+}
+
+//nolint:revive // no sense in export here
+func NewConfig(checkTimeout time.Duration) *config {
+	return &config{
+		CheckTimeout: checkTimeout,
+	}
+}
@@ -0,0 +1,63 @@
+package health
+
+import (
+	"context"
+	"sync"
+
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/dto"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/enums"
+)
+
+// Check probes a dependency of the API.
+type Check struct {
+	Name     string
+	Critical bool
+	Probe    func(ctx context.Context) error
+}
+
+type Service struct {
+	config config
+	checks []Check
+}
+
+func NewService(config config, checks ...Check) *Service {
+	return &Service{
+		config: config,
+		checks: checks,
+	}
+}
+
+// Readiness runs the checks concurrently, each within the check timeout.
+func (s *Service) Readiness(ctx context.Context) dto.Readiness {
+	results := make([]dto.HealthCheck, len(s.checks))
+
+	var wg sync.WaitGroup
+	for i, check := range s.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			checkCtx, cancel := context.WithTimeout(ctx, s.config.CheckTimeout)
+			defer cancel()
+
+			results[i] = dto.HealthCheck{Name: check.Name, Critical: check.Critical, OK: true}
+			if err := check.Probe(checkCtx); err != nil {
+				results[i].OK = false
+				results[i].Error = err.Error()
+			}
+		}()
+	}
+	wg.Wait()
+
+	readiness := dto.Readiness{Status: enums.ReadinessStatuses.Ready(), Checks: results}
+	for _, result := range results {
+		switch {
+		case result.OK:
+		case result.Critical:
+			readiness.Status = enums.ReadinessStatuses.NotReady()
+		case readiness.Status == enums.ReadinessStatuses.Ready():
+			readiness.Status = enums.ReadinessStatuses.Degraded()
+		}
+	}
+	return readiness
+}
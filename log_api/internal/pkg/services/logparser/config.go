@@ -0,0 +1,24 @@
+package logparser
+
+import "time"
+
+type config struct {
+	// BestEffort keeps the lines that parsed fine when others fail, instead of rejecting the whole batch
+	BestEffort bool
+	// Timeout bounds how long a parse may read and map the logs, zero means no limit
+	Timeout time.Duration
+	// Workers is how many log files are parsed at the same time
+	Workers int
+	// LookupWorkers is how many country lookups may be in flight at the same time
+	LookupWorkers int
+}
+
+//nolint:revive // no sense in export here
+func NewConfig(bestEffort bool, timeout time.Duration, workers int, lookupWorkers int) *config {
+	return &config{
+		BestEffort:    bestEffort,
+		Timeout:       timeout,
+		Workers:       max(workers, 1),
+		LookupWorkers: max(lookupWorkers, 1),
+	}
+}
@@ -1,13 +1,15 @@
 package logparser
 
 import (
+	"context"
+	"sync"
 	"time"
 
 	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/dto"
 )
 
 type logRepository interface {
-	GetLogs() (map[string][]byte, error)
+	GetLogs(ctx context.Context) (map[string][]byte, error)
 }
 
 type csvGenerator interface {
@@ -15,10 +17,26 @@ type csvGenerator interface {
 }
 
 type csvRepository interface {
-	Save(data []byte, requestTimeStamp time.Time) error
+	sync.Locker
+	Save(ctx context.Context, data []byte, requestTimeStamp time.Time) error
 	GetLastSavedDate() (*time.Time, error)
 }
 
 type ipAPIClient interface {
-	GetCountryByIP(ip string) (*dto.IPInfo, error)
+	GetCountryByIP(ctx context.Context, ip string) (*dto.IPInfo, error)
+}
+
+// ipAnonymiser reduces IP addresses to what the privacy configuration allows to keep.
+type ipAnonymiser interface {
+	AnonymiseIP(ip string) string
+	AnonymiseText(text string) string
+}
+
+// indexer keeps a derived view (records, aliases, ...) up to date with every parsed batch.
+type indexer interface {
+	Index(ctx context.Context, batch *dto.ParseBatch) error
+}
+
+type reportRepository interface {
+	Save(report *dto.ParseReport) error
 }
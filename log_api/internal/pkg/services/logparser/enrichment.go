@@ -0,0 +1,85 @@
+package logparser
+
+import (
+	"context"
+	"sync"
+)
+
+// lookupQueueSize bounds the addresses waiting for a lookup worker.
+const lookupQueueSize = 64
+
+// connection is a parsed connection whose country is still to be looked up.
+type connection struct {
+	line sourceLine
+	ip   string
+}
+
+type lookupResult struct {
+	countryCode string
+	err         error
+}
+
+// countryLookups resolves the countries of IP addresses through a bounded queue. Every address is looked up
+// once however many connections share it, by at most as many lookups in flight as there are workers.
+type countryLookups struct {
+	ipAPIClient ipAPIClient
+	queue       chan string
+	// seen is only touched by the goroutine enqueueing
+	seen    map[string]struct{}
+	mu      sync.Mutex
+	results map[string]lookupResult
+	wg      sync.WaitGroup
+}
+
+func newCountryLookups(ctx context.Context, ipAPIClient ipAPIClient, workers int) *countryLookups {
+	l := &countryLookups{
+		ipAPIClient: ipAPIClient,
+		queue:       make(chan string, lookupQueueSize),
+		seen:        make(map[string]struct{}),
+		results:     make(map[string]lookupResult),
+	}
+	for range workers {
+		l.wg.Add(1)
+		go l.work(ctx)
+	}
+	return l
+}
+
+// enqueue schedules the lookup of an address not seen yet, waiting while the queue is full.
+func (l *countryLookups) enqueue(ctx context.Context, ip string) {
+	if _, ok := l.seen[ip]; ok {
+		return
+	}
+	l.seen[ip] = struct{}{}
+
+	select {
+	case l.queue <- ip:
+	case <-ctx.Done():
+	}
+}
+
+// wait returns the results by address once the enqueued lookups are done. Nothing can be enqueued afterwards.
+func (l *countryLookups) wait() map[string]lookupResult {
+	close(l.queue)
+	l.wg.Wait()
+	return l.results
+}
+
+func (l *countryLookups) work(ctx context.Context) {
+	defer l.wg.Done()
+
+	for ip := range l.queue {
+		var result lookupResult
+		if err := ctx.Err(); err != nil {
+			result.err = err
+		} else if ipInfo, err := l.ipAPIClient.GetCountryByIP(ctx, ip); err != nil {
+			result.err = err
+		} else if ipInfo != nil {
+			result.countryCode = ipInfo.CountryCode
+		}
+
+		l.mu.Lock()
+		l.results[ip] = result
+		l.mu.Unlock()
+	}
+}
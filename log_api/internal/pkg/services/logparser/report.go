@@ -0,0 +1,69 @@
+package logparser
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/dto"
+)
+
+const (
+	maxReportedDiagnostics  = 1000
+	maxUnrecognisedPatterns = 500
+	otherPatternsKey        = "<other>"
+	chatActionKey           = "chat"
+	killActionKey           = "kill"
+)
+
+//nolint:gochecknoglobals // compiled once
+var (
+	linePrefixRegex   = regexp.MustCompile(`^L\s+\d{2}/\d{2}/\d{4}\s-\s\d{2}:\d{2}:\d{2}:\s*`)
+	quotedStringRegex = regexp.MustCompile(`"[^"]*"`)
+	angleTokenRegex   = regexp.MustCompile(`<[^<>]*>`)
+	numberRegex       = regexp.MustCompile(`\d+`)
+)
+
+func newParseReport(bestEffort bool) *dto.ParseReport {
+	return &dto.ParseReport{
+		BestEffort:           bestEffort,
+		ActionCounts:         make(map[string]int),
+		UnrecognisedPatterns: make(map[string]int),
+		Diagnostics:          []dto.ParseDiagnostic{},
+	}
+}
+
+func addDiagnostic(report *dto.ParseReport, diagnostic dto.ParseDiagnostic) {
+	report.DiagnosticsCount++
+	if len(report.Diagnostics) < maxReportedDiagnostics {
+		report.Diagnostics = append(report.Diagnostics, diagnostic)
+	}
+}
+
+func addUnrecognised(report *dto.ParseReport, line string) {
+	pattern := linePattern(line)
+	if _, ok := report.UnrecognisedPatterns[pattern]; !ok && len(report.UnrecognisedPatterns) >= maxUnrecognisedPatterns {
+		pattern = otherPatternsKey
+	}
+	report.UnrecognisedPatterns[pattern]++
+}
+
+// linePattern masks the variable parts of a log line, so lines of the same format share a key:
+// `L 03/07/2013 - 19:37:57: [META] Loaded 0 plugins` -> `[META] Loaded N plugins`.
+func linePattern(line string) string {
+	pattern := linePrefixRegex.ReplaceAllString(line, "")
+	pattern = quotedStringRegex.ReplaceAllString(pattern, `"*"`)
+	pattern = angleTokenRegex.ReplaceAllString(pattern, "<*>")
+	return numberRegex.ReplaceAllString(pattern, "N")
+}
+
+func diagnosticsError(diagnostics []dto.ParseDiagnostic) error {
+	errs := make([]error, 0, len(diagnostics))
+	for _, diagnostic := range diagnostics {
+		errs = append(errs, fmt.Errorf(
+			"%s:%d: %s [%s]",
+			diagnostic.File, diagnostic.Line, diagnostic.Reason, diagnostic.Raw,
+		))
+	}
+	return errors.Join(errs...)
+}
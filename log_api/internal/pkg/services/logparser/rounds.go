@@ -0,0 +1,63 @@
+package logparser
+
+import (
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/dto"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/enums"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/tools"
+)
+
+// Round lifecycle triggers written by NMRiH into the srcds log.
+//
+//nolint:gochecknoglobals // lookup tables
+var (
+	worldRoundTriggers = map[string]enums.RoundEventType{
+		"Round_Start":        enums.RoundEventTypes.RoundStarted(),
+		"nmrih_round_begin":  enums.RoundEventTypes.RoundStarted(),
+		"Round_Win":          enums.RoundEventTypes.RoundWon(),
+		"Extraction_Success": enums.RoundEventTypes.RoundWon(),
+		"Round_Lose":         enums.RoundEventTypes.RoundLost(),
+		"Round_Fail":         enums.RoundEventTypes.RoundLost(),
+		"nmrih_reset_map":    enums.RoundEventTypes.RoundLost(),
+	}
+	playerRoundTriggers = map[string]enums.RoundEventType{
+		"extracted":        enums.RoundEventTypes.PlayerExtracted(),
+		"player_extracted": enums.RoundEventTypes.PlayerExtracted(),
+	}
+)
+
+// processRoundLine extracts map changes and round lifecycle events. It reports whether the line was one of them.
+func (s *Service) processRoundLine(line sourceLine, window timeWindow, sink *lineSink) bool {
+	roundEvent := dto.RoundEvent{}
+
+	if matches := tools.MapStartedRegex.FindStringSubmatch(line.text); len(matches) > 1 {
+		roundEvent.Type = enums.RoundEventTypes.MapStarted()
+		roundEvent.Map = matches[1]
+	} else if matches := tools.WorldTriggeredRegex.FindStringSubmatch(line.text); len(matches) > 1 {
+		eventType, ok := worldRoundTriggers[matches[1]]
+		if !ok {
+			return false
+		}
+		roundEvent.Type = eventType
+	} else if matches := tools.PlayerTriggeredRegex.FindStringSubmatch(line.text); len(matches) > 3 {
+		eventType, ok := playerRoundTriggers[matches[3]]
+		if !ok {
+			return false
+		}
+		roundEvent.Type = eventType
+		roundEvent.NickName = matches[1]
+		if steamIDMatches := tools.SteamIDRegex.FindStringSubmatch("<" + matches[2] + ">"); len(steamIDMatches) > 1 {
+			roundEvent.SteamID = steamIDMatches[1]
+		}
+	} else {
+		return false
+	}
+
+	timeStamp, ok := s.extractTimeStamp(line, sink)
+	if !ok || !window.includes(timeStamp) {
+		return true
+	}
+	roundEvent.TimeStamp = timeStamp
+
+	sink.roundEvents <- roundEvent
+	return true
+}
@@ -3,47 +3,197 @@ package logparser
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"regexp"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/app/logging"
 	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/dto"
 	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/enums"
 	"github.com/dmitriitimoshenko/nmrih/log_api/internal/tools"
 )
 
 const (
-	maxConcurrentGoroutines = 100
-	minNickEnd              = 26
-	loggingTimeFormat       = "2006-01-02 15:04:05"
+	sinkBufferSize    = 100
+	minNickEnd        = 26
+	loggingTimeFormat = "2006-01-02 15:04:05"
+	sourceTimeFormat  = "01/02/2006 - 15:04:05"
 )
 
+// ErrShuttingDown is returned by the parses requested once Shutdown has begun.
+var ErrShuttingDown = errors.New("log parser is shutting down")
+
 type Service struct {
-	logRepository logRepository
-	csvGenerator  csvGenerator
-	csvRepository csvRepository
-	ipAPIClient   ipAPIClient
+	config           config
+	logRepository    logRepository
+	csvGenerator     csvGenerator
+	csvRepository    csvRepository
+	ipAPIClient      ipAPIClient
+	ipAnonymiser     ipAnonymiser
+	reportRepository reportRepository
+	indexers         []indexer
+	// mu serializes parses, as on-demand parsing and the log watcher may run at the same time
+	mu     sync.Mutex
+	closed bool
 }
 
 func NewService(
+	config config,
 	logRepository logRepository,
 	csvGenerator csvGenerator,
 	csvRepository csvRepository,
 	ipAPIClient ipAPIClient,
+	ipAnonymiser ipAnonymiser,
+	reportRepository reportRepository,
+	indexers ...indexer,
 ) *Service {
 	return &Service{
-		logRepository: logRepository,
-		csvGenerator:  csvGenerator,
-		csvRepository: csvRepository,
-		ipAPIClient:   ipAPIClient,
+		config:           config,
+		logRepository:    logRepository,
+		csvGenerator:     csvGenerator,
+		csvRepository:    csvRepository,
+		ipAPIClient:      ipAPIClient,
+		ipAnonymiser:     ipAnonymiser,
+		reportRepository: reportRepository,
+		indexers:         indexers,
+	}
+}
+
+// Parse ingests the logs written since the last parse and reports what was (and was not) understood.
+// The report is saved even when the parse fails.
+func (s *Service) Parse(ctx context.Context, requestTimeStamp time.Time) (*dto.ParseReport, error) {
+	return s.withReport(ctx, requestTimeStamp, func(ctx context.Context, report *dto.ParseReport) error {
+		logs, err := s.logRepository.GetLogs(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to get logs: %w", err)
+		}
+
+		slog.InfoContext(ctx, "Found logs", "files", len(logs))
+
+		// The last line of a file may still be being written
+		return s.parse(ctx, logs, true, requestTimeStamp, report)
+	})
+}
+
+// ParseLines ingests chunks of complete lines appended to the log files, as streamed by the log watcher.
+// Line numbers in the report are relative to the chunk.
+func (s *Service) ParseLines(
+	ctx context.Context,
+	logs map[string][]byte,
+	requestTimeStamp time.Time,
+) (*dto.ParseReport, error) {
+	return s.withReport(ctx, requestTimeStamp, func(ctx context.Context, report *dto.ParseReport) error {
+		return s.parse(ctx, logs, false, requestTimeStamp, report)
+	})
+}
+
+// ParseRange ingests the log entries from `from` to `to`, both inclusive, regardless of the CSV store checkpoint.
+// It backs offline backfills and re-parses, so keeping the range clear of already stored entries is up to the caller.
+func (s *Service) ParseRange(
+	ctx context.Context,
+	logs map[string][]byte,
+	from time.Time,
+	to time.Time,
+	requestTimeStamp time.Time,
+) (*dto.ParseReport, error) {
+	return s.withReport(ctx, requestTimeStamp, func(ctx context.Context, report *dto.ParseReport) error {
+		slog.InfoContext(
+			ctx, "Parsing logs in range",
+			"from", from.Format(loggingTimeFormat), "to", to.Format(loggingTimeFormat),
+		)
+		// Source timestamps have a one second resolution
+		return s.parseWindow(ctx, logs, timeWindow{after: from.Add(-time.Nanosecond), until: to}, false, requestTimeStamp, report)
+	})
+}
+
+// Shutdown waits for the running parse, if any, to finish writing and refuses the later ones.
+// It gives up when ctx is done; the parse then keeps running but no other starts.
+func (s *Service) Shutdown(ctx context.Context) error {
+	idle := make(chan struct{})
+	go func() {
+		s.mu.Lock()
+		s.closed = true
+		s.mu.Unlock()
+		close(idle)
+	}()
+
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("failed to wait for the running parse: %w", ctx.Err())
+	}
+}
+
+// withReport runs the parse under a new parse ID, which every line it logs and its report carry.
+// Reading and mapping the logs stop once ctx is done or the configured timeout passed; a parse that started
+// saving its batch runs to its end, so the CSV store and the indexes never disagree.
+func (s *Service) withReport(
+	ctx context.Context,
+	requestTimeStamp time.Time,
+	parse func(ctx context.Context, report *dto.ParseReport) error,
+) (*dto.ParseReport, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return nil, ErrShuttingDown
+	}
+
+	report := newParseReport(s.config.BestEffort)
+	report.ID = logging.NewID()
+	report.StartedAt = requestTimeStamp
+	ctx = logging.WithParseID(ctx, report.ID)
+
+	parseCtx := ctx
+	if s.config.Timeout > 0 {
+		var cancel context.CancelFunc
+		parseCtx, cancel = context.WithTimeout(ctx, s.config.Timeout)
+		defer cancel()
 	}
+
+	err := parse(parseCtx, report)
+	report.FinishedAt = time.Now()
+	if err != nil {
+		report.Error = err.Error()
+	}
+
+	if saveErr := s.reportRepository.Save(report); saveErr != nil {
+		saveErr = fmt.Errorf("failed to save parse report: %w", saveErr)
+		slog.ErrorContext(ctx, "Failed to save the parse report", "error", saveErr)
+		if err == nil {
+			err = saveErr
+		}
+	}
+
+	attrs := []any{
+		"files", report.FilesCount,
+		"lines", report.LinesCount,
+		"parsed", report.ParsedCount,
+		"diagnostics", report.DiagnosticsCount,
+		"duration_ms", report.FinishedAt.Sub(report.StartedAt).Milliseconds(),
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "Parse failed", append(attrs, "error", err)...)
+	} else {
+		slog.InfoContext(ctx, "Parse finished", attrs...)
+	}
+
+	return report, err
 }
 
-func (s *Service) Parse(requestTimeStamp time.Time) error {
+func (s *Service) parse(
+	ctx context.Context,
+	logs map[string][]byte,
+	skipLastLine bool,
+	requestTimeStamp time.Time,
+	report *dto.ParseReport,
+) error {
 	dateFromPtr, err := s.csvRepository.GetLastSavedDate()
 	if err != nil {
 		return fmt.Errorf("failed to get last saved date: %w", err)
@@ -52,163 +202,403 @@ func (s *Service) Parse(requestTimeStamp time.Time) error {
 		dateFromPtr = tools.ToPtr(time.Date(2025, time.March, 1, 0, 0, 0, 0, time.Local))
 	}
 
-	log.Printf("[LogParseService] Parsing logs from %s\n", dateFromPtr.Format(loggingTimeFormat))
+	slog.InfoContext(ctx, "Parsing logs", "from", dateFromPtr.Format(loggingTimeFormat))
+
+	return s.parseWindow(ctx, logs, timeWindow{after: *dateFromPtr}, skipLastLine, requestTimeStamp, report)
+}
 
-	logs, err := s.logRepository.GetLogs()
+func (s *Service) parseWindow(
+	ctx context.Context,
+	logs map[string][]byte,
+	window timeWindow,
+	skipLastLine bool,
+	requestTimeStamp time.Time,
+	report *dto.ParseReport,
+) error {
+	batch, err := s.mapLogs(ctx, logs, window, skipLastLine, report)
 	if err != nil {
-		return fmt.Errorf("failed to get logs: %w", err)
+		return fmt.Errorf("failed to structurize the logs: %w", err)
 	}
 
-	log.Printf("[LogParseService] Found %d logs\n", len(logs))
+	slog.InfoContext(
+		ctx, "Mapped logs",
+		"logs", len(batch.Logs),
+		"chat_messages", len(batch.Chat),
+		"round_events", len(batch.RoundEvents),
+		"kills", len(batch.Kills),
+		"diagnostics", report.DiagnosticsCount,
+	)
 
-	mappedLogs, err := s.mapLogs(logs, *dateFromPtr)
-	if err != nil {
-		err = fmt.Errorf("failed to structurize the logs: %w", err)
-		log.Println(err)
-		return err
+	if report.ParsedCount == 0 {
+		return nil
 	}
 
-	log.Printf("[LogParseService] Mapped %d logs\n", len(mappedLogs))
+	ctx = context.WithoutCancel(ctx)
+	if len(batch.Logs) > 0 {
+		if err := s.saveCSV(ctx, batch.Logs, requestTimeStamp); err != nil {
+			return err
+		}
+	}
 
-	if len(mappedLogs) == 0 {
-		return nil
+	for _, idx := range s.indexers {
+		if err := idx.Index(ctx, batch); err != nil {
+			return fmt.Errorf("failed to update index: %w", err)
+		}
 	}
 
-	log.Print("[LogParseService] Mapped logs are going to get into CSV\n")
+	slog.DebugContext(ctx, "Updated indexes", "indexes", len(s.indexers))
 
+	return nil
+}
+
+func (s *Service) saveCSV(ctx context.Context, mappedLogs []dto.LogData, requestTimeStamp time.Time) error {
 	csvBytes, lastLogTime, err := s.csvGenerator.Generate(mappedLogs)
 	if err != nil {
 		return fmt.Errorf("failed to generate CSV: %w", err)
 	}
 
-	log.Printf(
-		"[LogParseService] Generated CSV with data (not null: %v)\n",
-		csvBytes != nil,
-	)
-	if lastLogTime == nil {
-		log.Print("[LogParseService] Last log time is nil\n")
-	} else {
-		log.Printf("[LogParseService] Last log time is %s\n", lastLogTime.Format(loggingTimeFormat))
-	}
-
 	if lastLogTime == nil || lastLogTime.IsZero() {
+		slog.DebugContext(ctx, "Generated CSV has no last log time, using the request time")
 		lastLogTime = &requestTimeStamp
 	}
 
-	log.Printf("[LogParseService] Last log time is ANYWAY %s\n", lastLogTime.Format(loggingTimeFormat))
-
-	if err := s.csvRepository.Save(csvBytes, *lastLogTime); err != nil {
+	s.csvRepository.Lock()
+	err = s.csvRepository.Save(ctx, csvBytes, *lastLogTime)
+	s.csvRepository.Unlock()
+	if err != nil {
 		return fmt.Errorf("failed to save mapped logs as CSV: %w", err)
 	}
 
-	log.Print("[LogParseService] Saved CSV\n")
+	slog.InfoContext(ctx, "Saved CSV", "entries", len(mappedLogs), "last_log_time", lastLogTime.Format(loggingTimeFormat))
 
 	return nil
 }
 
-func (s *Service) mapLogs(logs map[string][]byte, dateFrom time.Time) ([]dto.LogData, error) {
+// mapLogs extracts a batch from the log files and fills the report. The files are parsed by a pool of workers,
+// then the countries of the connections are looked up in a step of their own.
+// Failing lines are recorded as diagnostics; unless the parser runs in best-effort mode, any of them rejects the batch.
+func (s *Service) mapLogs(
+	ctx context.Context,
+	logs map[string][]byte,
+	window timeWindow,
+	skipLastLine bool,
+	report *dto.ParseReport,
+) (*dto.ParseBatch, error) {
 	var (
-		logData []dto.LogData
-		wg      sync.WaitGroup
+		batch       dto.ParseBatch
+		connections []connection
+		wg          sync.WaitGroup
 	)
 
-	errChan := make(chan error, maxConcurrentGoroutines)
-	logDataChan := make(chan dto.LogData, maxConcurrentGoroutines)
-
-	for fileName, page := range logs {
-		wg.Add(1)
-		go func(fileName string, page []byte, dateFrom time.Time, errChan chan error, logDataChan chan dto.LogData) {
-			defer wg.Done()
+	sink := newLineSink()
+	report.FilesCount = len(logs)
 
-			linesCount := s.countLines(page)
-			if linesCount == 0 {
+	fileNames := make(chan string)
+	go func() {
+		defer close(fileNames)
+		for fileName := range logs {
+			select {
+			case fileNames <- fileName:
+			case <-ctx.Done():
 				return
 			}
+		}
+	}()
 
-			i := 0
-			scanner := bufio.NewScanner(bytes.NewReader(page))
-			for scanner.Scan() {
-				line := scanner.Text()
-				i++
-				if linesCount <= i {
-					break
-				}
-				s.processLine(fileName, line, dateFrom, logDataChan, errChan)
-			}
-
-			if err := scanner.Err(); err != nil {
-				errChan <- fmt.Errorf("error reading log extracted from file \"%s\": %w", fileName, err)
+	for range min(s.config.Workers, len(logs)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for fileName := range fileNames {
+				s.mapFile(ctx, sourceFile{name: fileName, page: logs[fileName]}, window, skipLastLine, sink)
 			}
-		}(fileName, page, dateFrom, errChan, logDataChan)
+		}()
 	}
 
-	go func(errChan chan error, logDataChan chan dto.LogData) {
+	go func(sink *lineSink) {
 		wg.Wait()
-		close(logDataChan)
-		close(errChan)
-	}(errChan, logDataChan)
+		sink.close()
+	}(sink)
 
-	var errs []error
-	for logDataChan != nil || errChan != nil {
+	var (
+		logDataChan      = sink.logData
+		connectionChan   = sink.connections
+		chatChan         = sink.chat
+		roundChan        = sink.roundEvents
+		killChan         = sink.kills
+		diagnosticsChan  = sink.diagnostics
+		unrecognisedChan = sink.unrecognised
+	)
+	for logDataChan != nil || connectionChan != nil || chatChan != nil || roundChan != nil || killChan != nil ||
+		diagnosticsChan != nil || unrecognisedChan != nil {
 		select {
 		case data, opened := <-logDataChan:
 			if !opened {
 				logDataChan = nil
 				continue
 			}
-			logData = append(logData, data)
-		case err, opened := <-errChan:
+			batch.Logs = append(batch.Logs, data)
+			report.ActionCounts[data.Action.String()]++
+		case conn, opened := <-connectionChan:
+			if !opened {
+				connectionChan = nil
+				continue
+			}
+			connections = append(connections, conn)
+		case chatMessage, opened := <-chatChan:
 			if !opened {
-				errChan = nil
+				chatChan = nil
 				continue
 			}
-			errs = append(errs, err)
+			batch.Chat = append(batch.Chat, chatMessage)
+			report.ActionCounts[chatActionKey]++
+		case roundEvent, opened := <-roundChan:
+			if !opened {
+				roundChan = nil
+				continue
+			}
+			batch.RoundEvents = append(batch.RoundEvents, roundEvent)
+			report.ActionCounts[roundEvent.Type.String()]++
+		case kill, opened := <-killChan:
+			if !opened {
+				killChan = nil
+				continue
+			}
+			batch.Kills = append(batch.Kills, kill)
+			report.ActionCounts[killActionKey]++
+		case diagnostic, opened := <-diagnosticsChan:
+			if !opened {
+				diagnosticsChan = nil
+				continue
+			}
+			s.addDiagnostic(ctx, report, diagnostic)
+		case line, opened := <-unrecognisedChan:
+			if !opened {
+				unrecognisedChan = nil
+				continue
+			}
+			addUnrecognised(report, line)
 		}
 	}
 
-	if len(errs) > 0 {
-		return nil, errors.Join(errs...)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if err := s.enrich(ctx, &batch, connections, report); err != nil {
+		return nil, err
+	}
+
+	report.LinesCount = int(sink.linesCount.Load())
+	report.ParsedCount = len(batch.Logs) + len(batch.Chat) + len(batch.RoundEvents) + len(batch.Kills)
+
+	if report.DiagnosticsCount > 0 && !s.config.BestEffort {
+		return nil, diagnosticsError(report.Diagnostics)
 	}
-	return logData, nil
+	return &batch, nil
 }
 
-func (s *Service) processLine(
-	fileName, line string,
-	dateFrom time.Time,
-	logDataChan chan dto.LogData,
-	errChan chan error,
-) {
-	if line == "" {
+// mapFile sends what the lines of a log file hold to the sink.
+func (s *Service) mapFile(ctx context.Context, file sourceFile, window timeWindow, skipLastLine bool, sink *lineSink) {
+	linesCount := s.countLines(file.page)
+	if linesCount == 0 {
+		return
+	}
+
+	i := 0
+	scanner := bufio.NewScanner(bytes.NewReader(file.page))
+	for scanner.Scan() {
+		if ctx.Err() != nil {
+			return
+		}
+		line := scanner.Text()
+		i++
+		if skipLastLine && linesCount <= i {
+			break
+		}
+		sink.linesCount.Add(1)
+		s.processLine(ctx, sourceLine{fileName: file.name, number: i, text: line}, window, sink)
+	}
+
+	if err := scanner.Err(); err != nil {
+		sink.diagnose(
+			sourceLine{fileName: file.name, number: i + 1},
+			"error reading log extracted from file: %s", err,
+		)
+	}
+}
+
+// enrich looks up the countries of the connections, then reduces their addresses to what the privacy
+// configuration keeps. A failed lookup is reported for each of its lines, the entries are kept without a country.
+func (s *Service) enrich(
+	ctx context.Context,
+	batch *dto.ParseBatch,
+	connections []connection,
+	report *dto.ParseReport,
+) error {
+	if len(connections) == 0 {
+		return nil
+	}
+
+	lookups := newCountryLookups(ctx, s.ipAPIClient, s.config.LookupWorkers)
+	for _, conn := range connections {
+		lookups.enqueue(ctx, conn.ip)
+	}
+	results := lookups.wait()
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	for _, conn := range connections {
+		if err := results[conn.ip].err; err != nil {
+			s.addDiagnostic(ctx, report, dto.ParseDiagnostic{
+				File:   conn.line.fileName,
+				Line:   conn.line.number,
+				Reason: fmt.Sprintf("failed to get country by IP [%s]: %s", conn.ip, err),
+				Raw:    conn.line.text,
+			})
+		}
+	}
+	for i := range batch.Logs {
+		logDataEntry := &batch.Logs[i]
+		if logDataEntry.IPAddress == "" {
+			continue
+		}
+		logDataEntry.Country = results[logDataEntry.IPAddress].countryCode
+		logDataEntry.IPAddress = s.ipAnonymiser.AnonymiseIP(logDataEntry.IPAddress)
+	}
+
+	slog.DebugContext(ctx, "Looked up countries", "connections", len(connections), "addresses", len(results))
+	return nil
+}
+
+// addDiagnostic logs a failing line and records it in the report.
+func (s *Service) addDiagnostic(ctx context.Context, report *dto.ParseReport, diagnostic dto.ParseDiagnostic) {
+	// Raw lines and lookup failures carry the addresses the privacy configuration does not keep
+	diagnostic.Reason = s.ipAnonymiser.AnonymiseText(diagnostic.Reason)
+	diagnostic.Raw = s.ipAnonymiser.AnonymiseText(diagnostic.Raw)
+	slog.WarnContext(
+		ctx, "Failed to parse a log line",
+		"file", diagnostic.File, "line", diagnostic.Line, "reason", diagnostic.Reason,
+	)
+	addDiagnostic(report, diagnostic)
+}
+
+func (s *Service) processLine(ctx context.Context, line sourceLine, window timeWindow, sink *lineSink) {
+	if line.text == "" {
+		return
+	}
+
+	// Chat goes first: a message like "I got disconnected" must not be taken for an action
+	if chatMatches := tools.ChatRegex.FindStringSubmatch(line.text); len(chatMatches) > 0 {
+		s.processChatLine(line, chatMatches, window, sink)
+		return
+	}
+	if killMatches := tools.KillRegex.FindStringSubmatch(line.text); len(killMatches) > 0 {
+		s.processKillLine(line, killMatches, window, sink)
+		return
+	}
+	if ok := s.processRoundLine(line, window, sink); ok {
 		return
 	}
 
 	logDataEntry := dto.LogData{}
 
 	switch {
-	case strings.Contains(line, enums.Actions.Disconnected().String()):
+	case strings.Contains(line.text, enums.Actions.Disconnected().String()):
 		logDataEntry.Action = enums.Actions.Disconnected()
-	case strings.Contains(line, enums.Actions.Connected().String()):
+	case strings.Contains(line.text, enums.Actions.Connected().String()):
 		logDataEntry.Action = enums.Actions.Connected()
-	case strings.Contains(line, enums.Actions.Entered().String()):
+	case strings.Contains(line.text, enums.Actions.Entered().String()):
 		logDataEntry.Action = enums.Actions.Entered()
-	case strings.Contains(line, enums.Actions.CommittedSuicide().String()):
+	case strings.Contains(line.text, enums.Actions.CommittedSuicide().String()):
 		logDataEntry.Action = enums.Actions.CommittedSuicide()
 	default:
+		s.processUnrecognisedLine(line, window, sink)
 		return
 	}
 
-	if ok := s.addNickAndTimeStamp(fileName, line, &logDataEntry, dateFrom, errChan); !ok {
+	if ok := s.addNickAndTimeStamp(line, &logDataEntry, window, sink); !ok {
 		return
 	}
 	if logDataEntry.Action == enums.Actions.Connected() {
-		s.addCountryIfIPAvailable(fileName, line, &logDataEntry, errChan)
+		s.addIPIfAvailable(ctx, line, &logDataEntry)
 	}
 
 	if err := logDataEntry.Validate(); err != nil {
-		errChan <- fmt.Errorf("failed to validate log data entry on line [%s]: %w", line, err)
+		sink.diagnose(line, "failed to validate log data entry: %s", err)
+		return
+	}
+	// The country is looked up once the whole batch is mapped, the full address is kept until then
+	if logDataEntry.IPAddress != "" {
+		sink.connections <- connection{line: line, ip: logDataEntry.IPAddress}
+	}
+	sink.logData <- logDataEntry
+}
+
+// processUnrecognisedLine reports lines no rule matched, unless they fall outside the parse window.
+func (s *Service) processUnrecognisedLine(line sourceLine, window timeWindow, sink *lineSink) {
+	if timeStampMatches := tools.DateTimeRegex.FindStringSubmatch(line.text); len(timeStampMatches) > 1 {
+		parsedTime, err := time.Parse(sourceTimeFormat, timeStampMatches[1])
+		if err == nil && !window.includes(parsedTime) {
+			return
+		}
+	}
+	sink.unrecognised <- line.text
+}
+
+func (s *Service) processChatLine(
+	line sourceLine,
+	chatMatches []string,
+	window timeWindow,
+	sink *lineSink,
+) {
+	timeStamp, ok := s.extractTimeStamp(line, sink)
+	if !ok || !window.includes(timeStamp) {
 		return
 	}
-	logDataChan <- logDataEntry
+
+	chatMessage := dto.ChatMessage{
+		TimeStamp: timeStamp,
+		NickName:  chatMatches[1],
+		TeamOnly:  chatMatches[3] == "say_team",
+		Message:   chatMatches[4],
+	}
+	if steamIDMatches := tools.SteamIDRegex.FindStringSubmatch("<" + chatMatches[2] + ">"); len(steamIDMatches) > 1 {
+		chatMessage.SteamID = steamIDMatches[1]
+	}
+	if chatMessage.NickName == "" {
+		// Messages from the server console have no player behind them
+		return
+	}
+
+	sink.chat <- chatMessage
+}
+
+func (s *Service) processKillLine(
+	line sourceLine,
+	killMatches []string,
+	window timeWindow,
+	sink *lineSink,
+) {
+	timeStamp, ok := s.extractTimeStamp(line, sink)
+	if !ok || !window.includes(timeStamp) {
+		return
+	}
+
+	kill := dto.Kill{
+		TimeStamp: timeStamp,
+		Killer:    killMatches[1],
+		Victim:    killMatches[3],
+		Weapon:    killMatches[5],
+	}
+	if steamIDMatches := tools.SteamIDRegex.FindStringSubmatch("<" + killMatches[2] + ">"); len(steamIDMatches) > 1 {
+		kill.KillerSteamID = steamIDMatches[1]
+	}
+	if steamIDMatches := tools.SteamIDRegex.FindStringSubmatch("<" + killMatches[4] + ">"); len(steamIDMatches) > 1 {
+		kill.VictimSteamID = steamIDMatches[1]
+	}
+
+	sink.kills <- kill
 }
 
 func (s *Service) countLines(data []byte) int {
@@ -221,25 +611,13 @@ func (s *Service) countLines(data []byte) int {
 }
 
 func (s *Service) addNickAndTimeStamp(
-	fileName, line string,
+	line sourceLine,
 	logDataEntry *dto.LogData,
-	dateFrom time.Time,
-	errChan chan error,
+	window timeWindow,
+	sink *lineSink,
 ) bool {
-	timeStampMatches := tools.DateTimeRegex.FindStringSubmatch(line)
-	if len(timeStampMatches) <= 1 {
-		log.Println("[WARN] Found no TimeStamp in file [", fileName, "]")
-		errChan <- fmt.Errorf("failed to extract timeStamp from log line [%s]", line)
-		return false
-	}
-	timeStampStr := timeStampMatches[1] // e.g. "03/15/2025 - 15:14:03"
-
-	parsedTime, err := time.Parse("01/02/2006 - 15:04:05", timeStampStr)
-	if err != nil {
-		errChan <- fmt.Errorf("failed to parse timeStamp from extracted log: %w", err)
-		return false
-	}
-	if !parsedTime.After(dateFrom) {
+	parsedTime, ok := s.extractTimeStamp(line, sink)
+	if !ok || !window.includes(parsedTime) {
 		return false
 	}
 
@@ -248,45 +626,44 @@ func (s *Service) addNickAndTimeStamp(
 	// Используем regex для извлечения ника
 	nickMatches := regexp.
 		MustCompile(`:\s*"(.*?)(?:<\d+|<\[|<>|")`).
-		FindStringSubmatch(line)
+		FindStringSubmatch(line.text)
 	if len(nickMatches) < 1 {
-		errChan <- fmt.Errorf(
-			"failed to get nickname from line [%s] of file [%s]: %+v",
-			line, fileName, nickMatches,
-		)
+		sink.diagnose(line, "failed to get nickname: %+v", nickMatches)
 		return false
 	}
 	logDataEntry.NickName = nickMatches[len(nickMatches)-1]
+
+	if steamIDMatches := tools.SteamIDRegex.FindStringSubmatch(line.text); len(steamIDMatches) > 1 {
+		logDataEntry.SteamID = steamIDMatches[1]
+	}
 	return true
 }
 
-func (s *Service) addCountryIfIPAvailable(
-	fileName, line string,
-	logDataEntry *dto.LogData,
-	errChan chan error,
-) {
-	ipMatches := tools.IPRegex.FindAllString(line, -1)
+func (s *Service) extractTimeStamp(line sourceLine, sink *lineSink) (time.Time, bool) {
+	timeStampMatches := tools.DateTimeRegex.FindStringSubmatch(line.text)
+	if len(timeStampMatches) <= 1 {
+		sink.diagnose(line, "failed to extract timeStamp")
+		return time.Time{}, false
+	}
+	timeStampStr := timeStampMatches[1] // e.g. "03/15/2025 - 15:14:03"
+
+	parsedTime, err := time.Parse(sourceTimeFormat, timeStampStr)
+	if err != nil {
+		sink.diagnose(line, "failed to parse timeStamp: %s", err)
+		return time.Time{}, false
+	}
+	return parsedTime, true
+}
+
+// addIPIfAvailable sets the address the player connected from, the last one of the line.
+func (s *Service) addIPIfAvailable(ctx context.Context, line sourceLine, logDataEntry *dto.LogData) {
+	ipMatches := tools.IPRegex.FindAllString(line.text, -1)
 	if len(ipMatches) > 1 {
-		log.Println(
-			"[WARN] Found more than one IP address in file [",
-			fileName,
-			"]",
-		)
+		slog.WarnContext(ctx, "Found more than one IP address", "file", line.fileName, "line", line.number)
 	}
 	if len(ipMatches) == 0 {
-		log.Println(
-			"[WARN] Found no IP address in file [",
-			fileName,
-			"]",
-		)
-	} else {
-		ip := ipMatches[len(ipMatches)-1]
-		logDataEntry.IPAddress = ip
-		ipInfo, err := s.ipAPIClient.GetCountryByIP(ip)
-		if err != nil {
-			errChan <- fmt.Errorf("failed to get country by IP [%s]: %w", ip, err)
-			return
-		}
-		logDataEntry.Country = ipInfo.CountryCode
+		slog.WarnContext(ctx, "Found no IP address", "file", line.fileName, "line", line.number)
+		return
 	}
+	logDataEntry.IPAddress = ipMatches[len(ipMatches)-1]
 }
@@ -0,0 +1,332 @@
+package logparser_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/dto"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/enums"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/services/logparser"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/services/privacy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type logRepositoryStub struct {
+	logs map[string][]byte
+}
+
+func (r *logRepositoryStub) GetLogs(_ context.Context) (map[string][]byte, error) {
+	return r.logs, nil
+}
+
+type csvGeneratorStub struct{}
+
+func (g *csvGeneratorStub) Generate(_ []dto.LogData) ([]byte, *time.Time, error) {
+	return []byte("csv"), nil, nil
+}
+
+type csvRepositoryStub struct {
+	sync.Mutex
+	saved int
+}
+
+func (r *csvRepositoryStub) Save(_ context.Context, _ []byte, _ time.Time) error {
+	r.saved++
+	return nil
+}
+
+func (r *csvRepositoryStub) GetLastSavedDate() (*time.Time, error) {
+	return nil, nil
+}
+
+type ipAPIClientStub struct{}
+
+func (c *ipAPIClientStub) GetCountryByIP(_ context.Context, ip string) (*dto.IPInfo, error) {
+	if ip == "10.0.0.1" {
+		return nil, errors.New("lookup failed")
+	}
+	return &dto.IPInfo{Country: "Germany"}, nil
+}
+
+// countingIPAPIClient records the lookups per address and the most of them in flight at once.
+type countingIPAPIClient struct {
+	mu          sync.Mutex
+	calls       map[string]int
+	inFlight    int
+	maxInFlight int
+}
+
+func (c *countingIPAPIClient) GetCountryByIP(_ context.Context, ip string) (*dto.IPInfo, error) {
+	c.mu.Lock()
+	c.calls[ip]++
+	c.inFlight++
+	c.maxInFlight = max(c.maxInFlight, c.inFlight)
+	c.mu.Unlock()
+
+	time.Sleep(time.Millisecond)
+
+	c.mu.Lock()
+	c.inFlight--
+	c.mu.Unlock()
+	return &dto.IPInfo{CountryCode: "DE"}, nil
+}
+
+type reportRepositoryStub struct {
+	report *dto.ParseReport
+}
+
+func (r *reportRepositoryStub) Save(report *dto.ParseReport) error {
+	r.report = report
+	return nil
+}
+
+type indexerStub struct {
+	batch *dto.ParseBatch
+}
+
+func (i *indexerStub) Index(_ context.Context, batch *dto.ParseBatch) error {
+	i.batch = batch
+	return nil
+}
+
+// The last line of a file is never parsed as it may still be written to, hence the trailing line.
+const testLog = `L 03/15/2025 - 15:14:03: "Alice<2><[U:1:1]><>" connected, address "1.2.3.4:27005"
+L 03/15/2025 - 15:14:05: "Bob<3><[U:1:2]><>" connected, address "10.0.0.1:27005"
+L 03/15/2025 - 15:14:10: [META] Loaded 0 plugins (1 already loaded)
+L 03/15/2025 - 15:14:20: "Alice<2><[U:1:1]><>" say "hi"
+L 03/15/2025 - 15:14:30: Server disconnected from Steam
+L 03/15/2025 - 15:15:00: "Alice<2><[U:1:1]><>" disconnected (reason "Disconnect by user.")
+L 03/15/2025 - 15:16:00: "Bob<3><[U:1:2]><>" disconnected (reason "Disconnect by user.")
+`
+
+func TestService_Parse(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name       string
+		bestEffort bool
+		assert     func(
+			t *testing.T,
+			report *dto.ParseReport,
+			err error,
+			csvRepository *csvRepositoryStub,
+			indexer *indexerStub,
+		)
+	}{
+		{
+			name:       "error: strict mode rejects the batch on a bad line",
+			bestEffort: false,
+			assert: func(
+				t *testing.T,
+				report *dto.ParseReport,
+				err error,
+				csvRepository *csvRepositoryStub,
+				indexer *indexerStub,
+			) {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), "server.log:5: failed to get nickname")
+				assert.Equal(t, err.Error(), report.Error)
+				assert.Equal(t, 2, report.DiagnosticsCount)
+				assert.Zero(t, csvRepository.saved)
+				assert.Nil(t, indexer.batch)
+			},
+		},
+		{
+			name:       "success: best-effort mode keeps the good lines",
+			bestEffort: true,
+			assert: func(
+				t *testing.T,
+				report *dto.ParseReport,
+				err error,
+				csvRepository *csvRepositoryStub,
+				indexer *indexerStub,
+			) {
+				require.NoError(t, err)
+				assert.Empty(t, report.Error)
+				assert.Equal(t, 1, report.FilesCount)
+				assert.Equal(t, 6, report.LinesCount)
+				assert.Equal(t, 4, report.ParsedCount)
+				assert.Equal(t, map[string]int{
+					enums.Actions.Connected().String():    2,
+					"chat":                                1,
+					enums.Actions.Disconnected().String(): 1,
+				}, report.ActionCounts)
+				assert.Equal(t, map[string]int{"[META] Loaded N plugins (N already loaded)": 1}, report.UnrecognisedPatterns)
+				require.Len(t, report.Diagnostics, 2)
+				assert.ElementsMatch(t, []int{2, 5}, []int{report.Diagnostics[0].Line, report.Diagnostics[1].Line})
+				assert.Equal(t, 1, csvRepository.saved)
+				require.NotNil(t, indexer.batch)
+				assert.Len(t, indexer.batch.Logs, 3)
+				assert.Len(t, indexer.batch.Chat, 1)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			csvRepository := &csvRepositoryStub{}
+			reportRepository := &reportRepositoryStub{}
+			indexer := &indexerStub{}
+			service := logparser.NewService(
+				*logparser.NewConfig(tt.bestEffort, 0, 2, 2),
+				&logRepositoryStub{logs: map[string][]byte{"server.log": []byte(testLog)}},
+				&csvGeneratorStub{},
+				csvRepository,
+				&ipAPIClientStub{},
+				privacy.NewService(*privacy.NewConfig(enums.IPModes.Plain(), "")),
+				reportRepository,
+				indexer,
+			)
+
+			report, err := service.Parse(context.Background(), time.Now())
+			assert.Same(t, report, reportRepository.report)
+			assert.NotEmpty(t, report.ID)
+			tt.assert(t, report, err, csvRepository, indexer)
+		})
+	}
+}
+
+func TestService_ParseRange(t *testing.T) {
+	t.Parallel()
+	csvRepository := &csvRepositoryStub{}
+	indexer := &indexerStub{}
+	service := logparser.NewService(
+		*logparser.NewConfig(true, 0, 2, 2),
+		&logRepositoryStub{},
+		&csvGeneratorStub{},
+		csvRepository,
+		&ipAPIClientStub{},
+		privacy.NewService(*privacy.NewConfig(enums.IPModes.Plain(), "")),
+		&reportRepositoryStub{},
+		indexer,
+	)
+
+	// Both bounds are inclusive, and the last line is complete in offline logs
+	report, err := service.ParseRange(
+		context.Background(),
+		map[string][]byte{"server.log": []byte(testLog)},
+		time.Date(2025, 3, 15, 15, 14, 5, 0, time.UTC),
+		time.Date(2025, 3, 15, 15, 16, 0, 0, time.UTC),
+		time.Now(),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, 7, report.LinesCount)
+	assert.Equal(t, 1, csvRepository.saved)
+	require.NotNil(t, indexer.batch)
+	nickNames := make([]string, 0, len(indexer.batch.Logs))
+	for _, logData := range indexer.batch.Logs {
+		nickNames = append(nickNames, logData.NickName+" "+logData.Action.String())
+	}
+	assert.ElementsMatch(t, []string{"Bob connected", "Alice disconnected", "Bob disconnected"}, nickNames)
+}
+
+func TestService_ParseAnonymisesIPs(t *testing.T) {
+	t.Parallel()
+	indexer := &indexerStub{}
+	service := logparser.NewService(
+		*logparser.NewConfig(true, 0, 2, 2),
+		&logRepositoryStub{logs: map[string][]byte{"server.log": []byte(testLog)}},
+		&csvGeneratorStub{},
+		&csvRepositoryStub{},
+		&ipAPIClientStub{},
+		privacy.NewService(*privacy.NewConfig(enums.IPModes.Truncate(), "")),
+		&reportRepositoryStub{},
+		indexer,
+	)
+
+	report, err := service.Parse(context.Background(), time.Now())
+	require.NoError(t, err)
+	require.NotNil(t, indexer.batch)
+
+	ipAddresses := make([]string, 0, len(indexer.batch.Logs))
+	for _, logData := range indexer.batch.Logs {
+		if logData.Action == enums.Actions.Connected() {
+			ipAddresses = append(ipAddresses, logData.IPAddress)
+		}
+	}
+	// the lookup is still done with the full address, only the stored one is truncated
+	assert.ElementsMatch(t, []string{"1.2.3.0", "10.0.0.0"}, ipAddresses)
+	for _, diagnostic := range report.Diagnostics {
+		assert.NotContains(t, diagnostic.Reason, "10.0.0.1")
+		assert.NotContains(t, diagnostic.Raw, "10.0.0.1")
+	}
+}
+
+func TestService_ParseCancelled(t *testing.T) {
+	t.Parallel()
+	csvRepository := &csvRepositoryStub{}
+	reportRepository := &reportRepositoryStub{}
+	indexer := &indexerStub{}
+	service := logparser.NewService(
+		*logparser.NewConfig(true, time.Minute, 2, 2),
+		&logRepositoryStub{logs: map[string][]byte{"server.log": []byte(testLog)}},
+		&csvGeneratorStub{},
+		csvRepository,
+		&ipAPIClientStub{},
+		privacy.NewService(*privacy.NewConfig(enums.IPModes.Plain(), "")),
+		reportRepository,
+		indexer,
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	report, err := service.Parse(ctx, time.Now())
+	require.ErrorIs(t, err, context.Canceled)
+	assert.Same(t, report, reportRepository.report)
+	assert.NotEmpty(t, report.Error)
+	assert.Zero(t, csvRepository.saved)
+	assert.Nil(t, indexer.batch)
+}
+
+func TestService_ParseLooksUpEachAddressOnce(t *testing.T) {
+	t.Parallel()
+	const (
+		filesCount     = 20
+		addressesCount = 10
+		lookupWorkers  = 3
+	)
+
+	// Every file has every player connect, so each address shows up in every file
+	logs := make(map[string][]byte, filesCount)
+	for file := range filesCount {
+		var page string
+		for player := range addressesCount {
+			page += fmt.Sprintf(
+				"L 03/15/2025 - 15:%02d:%02d: \"P%d<%d><[U:1:%d]><>\" connected, address \"1.2.3.%d:27005\"\n",
+				file, player, player, player, player, player,
+			)
+		}
+		logs[fmt.Sprintf("server%d.log", file)] = []byte(page)
+	}
+
+	ipAPIClient := &countingIPAPIClient{calls: make(map[string]int)}
+	indexer := &indexerStub{}
+	service := logparser.NewService(
+		*logparser.NewConfig(false, 0, 4, lookupWorkers),
+		&logRepositoryStub{},
+		&csvGeneratorStub{},
+		&csvRepositoryStub{},
+		ipAPIClient,
+		privacy.NewService(*privacy.NewConfig(enums.IPModes.Plain(), "")),
+		&reportRepositoryStub{},
+		indexer,
+	)
+
+	report, err := service.ParseLines(context.Background(), logs, time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, filesCount*addressesCount, report.ParsedCount)
+	assert.Len(t, ipAPIClient.calls, addressesCount)
+	for ip, calls := range ipAPIClient.calls {
+		assert.Equal(t, 1, calls, ip)
+	}
+	assert.LessOrEqual(t, ipAPIClient.maxInFlight, lookupWorkers)
+	for _, logData := range indexer.batch.Logs {
+		assert.Equal(t, "DE", logData.Country)
+	}
+}
@@ -0,0 +1,64 @@
+package logparser
+
+import (
+	"fmt"
+	"sync/atomic"
+
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/dto"
+)
+
+// sourceLine is a raw log line together with its position.
+type sourceLine struct {
+	fileName string
+	number   int
+	text     string
+}
+
+// sourceFile is the content of a log file, to be parsed by a worker.
+type sourceFile struct {
+	name string
+	page []byte
+}
+
+// lineSink collects everything the file workers extract from log lines.
+type lineSink struct {
+	logData      chan dto.LogData
+	connections  chan connection
+	chat         chan dto.ChatMessage
+	roundEvents  chan dto.RoundEvent
+	kills        chan dto.Kill
+	diagnostics  chan dto.ParseDiagnostic
+	unrecognised chan string
+	linesCount   atomic.Int64
+}
+
+func newLineSink() *lineSink {
+	return &lineSink{
+		logData:      make(chan dto.LogData, sinkBufferSize),
+		connections:  make(chan connection, sinkBufferSize),
+		chat:         make(chan dto.ChatMessage, sinkBufferSize),
+		roundEvents:  make(chan dto.RoundEvent, sinkBufferSize),
+		kills:        make(chan dto.Kill, sinkBufferSize),
+		diagnostics:  make(chan dto.ParseDiagnostic, sinkBufferSize),
+		unrecognised: make(chan string, sinkBufferSize),
+	}
+}
+
+func (s *lineSink) diagnose(line sourceLine, format string, args ...any) {
+	s.diagnostics <- dto.ParseDiagnostic{
+		File:   line.fileName,
+		Line:   line.number,
+		Reason: fmt.Sprintf(format, args...),
+		Raw:    line.text,
+	}
+}
+
+func (s *lineSink) close() {
+	close(s.logData)
+	close(s.connections)
+	close(s.chat)
+	close(s.roundEvents)
+	close(s.kills)
+	close(s.diagnostics)
+	close(s.unrecognised)
+}
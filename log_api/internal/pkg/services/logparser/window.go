@@ -0,0 +1,13 @@
+package logparser
+
+import "time"
+
+// timeWindow bounds the log entries a parse takes in: after `after`, up to and including `until` when it is set.
+type timeWindow struct {
+	after time.Time
+	until time.Time
+}
+
+func (w timeWindow) includes(timeStamp time.Time) bool {
+	return timeStamp.After(w.after) && (w.until.IsZero() || !timeStamp.After(w.until))
+}
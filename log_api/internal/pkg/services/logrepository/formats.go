@@ -0,0 +1,119 @@
+package logrepository
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+const (
+	gzipSuffix = ".gz"
+	zstdSuffix = ".zst"
+	tarSuffix  = ".tar"
+	tgzSuffix  = ".tgz"
+)
+
+// logIdentity names a log the same whether it is plain or compressed: L0315000.log.gz is L0315000.log.
+// Offsets and parse diagnostics refer to logs by their identity.
+func logIdentity(path string) string {
+	for _, suffix := range []string{gzipSuffix, zstdSuffix} {
+		if trimmed, ok := strings.CutSuffix(path, suffix); ok {
+			return trimmed
+		}
+	}
+	return path
+}
+
+// isTarArchive tells bundles of logs (.tar, .tar.gz, .tgz, .tar.zst) from single logs.
+func isTarArchive(path string) bool {
+	return strings.HasSuffix(logIdentity(path), tarSuffix) || strings.HasSuffix(path, tgzSuffix)
+}
+
+// tarEntryIdentity names a bundled log as if it was still next to its archive.
+func tarEntryIdentity(archivePath string, entryName string) string {
+	return filepath.Join(filepath.Dir(archivePath), filepath.Base(entryName))
+}
+
+func matchesPattern(pattern string, path string) bool {
+	ok, err := filepath.Match(pattern, filepath.Base(path))
+	return err == nil && ok
+}
+
+type decompressedFile struct {
+	io.Reader
+	closers []func() error
+}
+
+func (f *decompressedFile) Close() error {
+	var errs []error
+	for i := len(f.closers) - 1; i >= 0; i-- {
+		errs = append(errs, f.closers[i]())
+	}
+	return errors.Join(errs...)
+}
+
+// openDecompressed opens a file and decompresses it on the fly according to its suffix.
+func openDecompressed(path string) (io.ReadCloser, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case strings.HasSuffix(path, gzipSuffix), strings.HasSuffix(path, tgzSuffix):
+		gzipReader, err := gzip.NewReader(f)
+		if err != nil {
+			f.Close()
+			return nil, fmt.Errorf("failed to read gzip stream of %s: %w", path, err)
+		}
+		return &decompressedFile{Reader: gzipReader, closers: []func() error{f.Close, gzipReader.Close}}, nil
+	case strings.HasSuffix(path, zstdSuffix):
+		zstdReader, err := zstd.NewReader(f, zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			f.Close()
+			return nil, fmt.Errorf("failed to read zstd stream of %s: %w", path, err)
+		}
+		return &decompressedFile{
+			Reader: zstdReader,
+			closers: []func() error{f.Close, func() error {
+				zstdReader.Close()
+				return nil
+			}},
+		}, nil
+	default:
+		return f, nil
+	}
+}
+
+// eachTarEntry streams the regular files of a tar archive.
+func eachTarEntry(archivePath string, fn func(name string, r io.Reader) error) error {
+	archive, err := openDecompressed(archivePath)
+	if err != nil {
+		return fmt.Errorf("failed to open archive %s: %w", archivePath, err)
+	}
+	defer archive.Close()
+
+	tarReader := tar.NewReader(archive)
+	for {
+		header, err := tarReader.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read archive %s: %w", archivePath, err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		if err := fn(header.Name, tarReader); err != nil {
+			return err
+		}
+	}
+}
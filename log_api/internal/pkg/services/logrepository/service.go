@@ -1,10 +1,10 @@
 package logrepository
 
 import (
-	"fmt"
-	"log"
-	"os"
-	"path/filepath"
+	"context"
+	"log/slog"
+	"maps"
+	"slices"
 )
 
 type Service struct {
@@ -15,29 +15,20 @@ func NewService(config config) *Service {
 	return &Service{config: config}
 }
 
-func (s *Service) GetLogs() (map[string][]byte, error) {
-	pattern := filepath.Join(s.config.LogDirectory, s.config.LogFilesPattern)
-	files, err := filepath.Glob(pattern)
-	if err != nil {
-		return nil, fmt.Errorf("failed to search for log files: %w", err)
+// GetLogs reads the log files of the log directory, compressed and archived ones included.
+// Reading stops with the context error once ctx is done.
+func (s *Service) GetLogs(ctx context.Context) (map[string][]byte, error) {
+	// map [ file name ] -> content
+	logs := make(map[string][]byte)
+	if err := s.eachLogIn(ctx, s.config.LogDirectory, false, collect(logs)); err != nil {
+		return nil, err
 	}
 
-	if files == nil {
+	if len(logs) == 0 {
 		return nil, nil
 	}
 
-	// map [ file name ] -> content
-	logs := make(map[string][]byte)
-
-	log.Println(files)
-
-	for _, file := range files {
-		data, err := os.ReadFile(file)
-		if err != nil {
-			return nil, fmt.Errorf("reading logs error: %s: %w", file, err)
-		}
-		logs[file] = data
-	}
+	slog.Debug("Read log files", "files", slices.Sorted(maps.Keys(logs)))
 
 	return logs, nil
 }
@@ -1,11 +1,10 @@
 package logrepository_test
 
 import (
-	"os"
+	"context"
 	"testing"
 
 	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/services/logrepository"
-	"github.com/dmitriitimoshenko/nmrih/log_api/internal/tools/testhelper"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -28,12 +27,9 @@ func TestService_GetLogs(t *testing.T) {
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
 			t.Parallel()
-			th := testhelper.NewTestHelper(t)
-			th.UseTestEnv()
-
-			cfg := logrepository.NewConfig(os.Getenv("LOGS_STORAGE_DIRECTORY"), os.Getenv("LOGS_FILE_PATTERN"))
+			cfg := logrepository.NewConfig("./internal/data/", "*.log")
 			service := logrepository.NewService(*cfg)
-			logs, err := service.GetLogs()
+			logs, err := service.GetLogs(context.Background())
 			test.assert(t, logs, err)
 		})
 	}
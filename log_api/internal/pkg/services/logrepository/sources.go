@@ -0,0 +1,101 @@
+package logrepository
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/tools"
+)
+
+// GetLogsFrom reads logs from the given sources: log files, plain or compressed with gzip or zstd,
+// directories (matched by the configured pattern) and tar archives of logs. Logs are keyed by their identity.
+func (s *Service) GetLogsFrom(ctx context.Context, paths ...string) (map[string][]byte, error) {
+	// map [ file name ] -> content
+	logs := make(map[string][]byte)
+
+	for _, path := range paths {
+		if err := s.eachLogIn(ctx, path, true, collect(logs)); err != nil {
+			return nil, err
+		}
+	}
+
+	return logs, nil
+}
+
+// EachLog streams the logs of the given sources one at a time, decompressing them on the fly.
+func (s *Service) EachLog(ctx context.Context, paths []string, fn func(identity string, r io.Reader) error) error {
+	for _, path := range paths {
+		if err := s.eachLogIn(ctx, path, true, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// eachLogIn streams the logs of a source. Files found in a directory must match the pattern;
+// explicitly given ones are taken as they are. Every log is read through ctx, which stops it once done.
+func (s *Service) eachLogIn(
+	ctx context.Context,
+	path string,
+	explicit bool,
+	fn func(identity string, r io.Reader) error,
+) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("failed to read log source: %w", err)
+	}
+
+	switch {
+	case info.IsDir():
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return fmt.Errorf("failed to search for log files: %w", err)
+		}
+		for _, entry := range entries {
+			if !entry.Type().IsRegular() {
+				continue
+			}
+			if err := s.eachLogIn(ctx, filepath.Join(path, entry.Name()), false, fn); err != nil {
+				return err
+			}
+		}
+		return nil
+	case isTarArchive(path):
+		return eachTarEntry(path, func(name string, r io.Reader) error {
+			if !matchesPattern(s.config.LogFilesPattern, name) {
+				return nil
+			}
+			return fn(tarEntryIdentity(path, name), tools.NewContextReader(ctx, r))
+		})
+	case !explicit && !matchesPattern(s.config.LogFilesPattern, logIdentity(path)):
+		return nil
+	default:
+		f, err := openDecompressed(path)
+		if err != nil {
+			return fmt.Errorf("reading logs error: %s: %w", path, err)
+		}
+		defer f.Close()
+		return fn(logIdentity(path), tools.NewContextReader(ctx, f))
+	}
+}
+
+// collect reads the streamed logs into memory. When a log comes in several copies, e.g. a plain file
+// next to its archived copy, the longest one wins.
+func collect(logs map[string][]byte) func(identity string, r io.Reader) error {
+	return func(identity string, r io.Reader) error {
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return fmt.Errorf("reading logs error: %s: %w", identity, err)
+		}
+		if len(data) >= len(logs[identity]) {
+			logs[identity] = data
+		}
+		return nil
+	}
+}
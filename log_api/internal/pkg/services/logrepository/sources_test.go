@@ -0,0 +1,137 @@
+package logrepository_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/services/logrepository"
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func gzipped(t *testing.T, data []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	_, err := writer.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+	return buf.Bytes()
+}
+
+func zstdCompressed(t *testing.T, data []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	writer, err := zstd.NewWriter(&buf)
+	require.NoError(t, err)
+	_, err = writer.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+	return buf.Bytes()
+}
+
+func tarred(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	writer := tar.NewWriter(&buf)
+	for name, content := range files {
+		require.NoError(t, writer.WriteHeader(&tar.Header{
+			Name:     name,
+			Mode:     0o600,
+			Size:     int64(len(content)),
+			Typeflag: tar.TypeReg,
+		}))
+		_, err := writer.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, writer.Close())
+	return buf.Bytes()
+}
+
+func TestService_GetLogs_CompressedAndArchived(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	for name, content := range map[string][]byte{
+		"L0301000.log":     []byte("plain"),
+		"L0302000.log.gz":  gzipped(t, []byte("gzip")),
+		"L0303000.log.zst": zstdCompressed(t, []byte("zstd")),
+		// A copy that is still being written next to its compressed snapshot: the longer one wins
+		"L0304000.log":    []byte("still written"),
+		"L0304000.log.gz": gzipped(t, []byte("still")),
+		"2025-02.tar.gz": gzipped(t, tarred(t, map[string]string{
+			"logs/L0201000.log": "tar.gz",
+			"logs/readme.txt":   "skipped",
+		})),
+		"2025-01.tar.zst": zstdCompressed(t, tarred(t, map[string]string{"L0101000.log": "tar.zst"})),
+		"2024-12.tar":     tarred(t, map[string]string{"L1201000.log": "tar"}),
+		"notes.txt.gz":    gzipped(t, []byte("skipped")),
+	} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), content, 0o600))
+	}
+
+	service := logrepository.NewService(*logrepository.NewConfig(dir, "L*.log"))
+	logs, err := service.GetLogs(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]byte{
+		filepath.Join(dir, "L0301000.log"): []byte("plain"),
+		filepath.Join(dir, "L0302000.log"): []byte("gzip"),
+		filepath.Join(dir, "L0303000.log"): []byte("zstd"),
+		filepath.Join(dir, "L0304000.log"): []byte("still written"),
+		filepath.Join(dir, "L0201000.log"): []byte("tar.gz"),
+		filepath.Join(dir, "L0101000.log"): []byte("tar.zst"),
+		filepath.Join(dir, "L1201000.log"): []byte("tar"),
+	}, logs)
+}
+
+func TestService_GetLogsFrom(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	logsDir := filepath.Join(dir, "logs")
+	require.NoError(t, os.Mkdir(logsDir, 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(logsDir, "L0315000.log"), []byte("dir"), 0o600))
+	// Explicitly given files do not have to match the pattern
+	single := filepath.Join(dir, "single.txt.gz")
+	require.NoError(t, os.WriteFile(single, gzipped(t, []byte("file")), 0o600))
+	broken := filepath.Join(dir, "broken.log.gz")
+	require.NoError(t, os.WriteFile(broken, []byte("not gzip"), 0o600))
+
+	service := logrepository.NewService(*logrepository.NewConfig("", "*.log"))
+	logs, err := service.GetLogsFrom(context.Background(), logsDir, single)
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]byte{
+		filepath.Join(logsDir, "L0315000.log"): []byte("dir"),
+		filepath.Join(dir, "single.txt"):       []byte("file"),
+	}, logs)
+
+	_, err = service.GetLogsFrom(context.Background(), filepath.Join(dir, "missing.log"))
+	require.Error(t, err)
+	_, err = service.GetLogsFrom(context.Background(), broken)
+	require.Error(t, err)
+}
+
+func TestService_EachLog_Streams(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	archive := filepath.Join(dir, "2025-02.tar.zst")
+	require.NoError(t, os.WriteFile(archive, zstdCompressed(t, tarred(t, map[string]string{
+		"L0201000.log": "first",
+	})), 0o600))
+
+	service := logrepository.NewService(*logrepository.NewConfig(dir, "*.log"))
+	var identities []string
+	err := service.EachLog(context.Background(), []string{archive}, func(identity string, r io.Reader) error {
+		data, err := io.ReadAll(r)
+		require.NoError(t, err)
+		identities = append(identities, identity+"="+string(data))
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(dir, "L0201000.log") + "=first"}, identities)
+}
@@ -0,0 +1,21 @@
+package logrepository
+
+import "time"
+
+type watchConfig struct {
+	// OffsetsDirectory keeps the read offset of every log file, to resume after a restart
+	OffsetsDirectory string
+	// Debounce is the quiet period after the last write before new lines are flushed
+	Debounce time.Duration
+	// MaxDelay bounds how long lines can wait when writes never go quiet
+	MaxDelay time.Duration
+}
+
+//nolint:revive // no sense in export here
+func NewWatchConfig(offsetsDirectory string, debounce, maxDelay time.Duration) *watchConfig {
+	return &watchConfig{
+		OffsetsDirectory: offsetsDirectory,
+		Debounce:         debounce,
+		MaxDelay:         maxDelay,
+	}
+}
@@ -0,0 +1,303 @@
+package logrepository
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/tools"
+	"github.com/fsnotify/fsnotify"
+)
+
+const offsetsFileName = "log_offsets.json"
+
+// Watcher tails the log directory and hands over the complete lines appended to the log files.
+type Watcher struct {
+	config      config
+	watchConfig watchConfig
+	// offsets maps a log identity to the number of its (decompressed) bytes already handed over
+	offsets map[string]int64
+}
+
+func NewWatcher(config config, watchConfig watchConfig) *Watcher {
+	return &Watcher{
+		config:      config,
+		watchConfig: watchConfig,
+	}
+}
+
+// Watch calls handle with the new lines of every changed log file until the context is done.
+// Offsets only move forward once handle succeeds, so failed lines are handed over again on the next change.
+// Files without a known offset are read from the start: the parser skips what it has already ingested.
+func (w *Watcher) Watch(ctx context.Context, handle func(logs map[string][]byte) error) error {
+	offsets, err := w.loadOffsets()
+	if err != nil {
+		return err
+	}
+	w.offsets = offsets
+
+	fsWatcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to create log watcher: %w", err)
+	}
+	defer fsWatcher.Close()
+
+	if err := fsWatcher.Add(w.config.LogDirectory); err != nil {
+		return fmt.Errorf("failed to watch log directory: %w", err)
+	}
+
+	slog.InfoContext(ctx, "Watching logs", "pattern", filepath.Join(w.config.LogDirectory, w.config.LogFilesPattern))
+
+	// Catch up with what was written while we were not watching
+	if err := w.flush(nil, handle); err != nil {
+		slog.ErrorContext(ctx, "Failed to catch up with the logs", "error", err)
+	}
+
+	var (
+		dirty      = make(map[string]struct{})
+		firstDirty time.Time
+		timer      = time.NewTimer(w.watchConfig.Debounce)
+	)
+	timer.Stop()
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err, ok := <-fsWatcher.Errors:
+			if !ok {
+				return nil
+			}
+			slog.ErrorContext(ctx, "Log watch error", "error", err)
+		case event, ok := <-fsWatcher.Events:
+			if !ok {
+				return nil
+			}
+			if !w.matches(event.Name) {
+				continue
+			}
+			if event.Has(fsnotify.Remove) || event.Has(fsnotify.Rename) {
+				// Rotated away: a file coming back under this name starts over, unless a compressed copy is left
+				if identity := logIdentity(event.Name); !w.hasCopy(identity) {
+					delete(w.offsets, identity)
+				}
+				continue
+			}
+			if !event.Has(fsnotify.Write) && !event.Has(fsnotify.Create) {
+				continue
+			}
+			if len(dirty) == 0 {
+				firstDirty = time.Now()
+			}
+			dirty[event.Name] = struct{}{}
+			timer.Reset(w.nextFlushIn(firstDirty))
+		case <-timer.C:
+			if err := w.flush(dirty, handle); err != nil {
+				slog.ErrorContext(ctx, "Failed to handle new log lines", "error", err)
+			}
+			dirty = make(map[string]struct{})
+		}
+	}
+}
+
+// nextFlushIn debounces writes, but never past MaxDelay since the first pending write.
+func (w *Watcher) nextFlushIn(firstDirty time.Time) time.Duration {
+	if w.watchConfig.MaxDelay <= 0 {
+		return w.watchConfig.Debounce
+	}
+	left := w.watchConfig.MaxDelay - time.Since(firstDirty)
+	return max(min(w.watchConfig.Debounce, left), 0)
+}
+
+// flush hands over the new lines of the given files, or of all log files if files is nil.
+// Copies of a log, e.g. a plain file and its compressed snapshot, share one offset.
+func (w *Watcher) flush(files map[string]struct{}, handle func(logs map[string][]byte) error) error {
+	if files == nil {
+		entries, err := os.ReadDir(w.config.LogDirectory)
+		if err != nil {
+			return fmt.Errorf("failed to search for log files: %w", err)
+		}
+		files = make(map[string]struct{}, len(entries))
+		for _, entry := range entries {
+			if file := filepath.Join(w.config.LogDirectory, entry.Name()); w.matches(file) {
+				files[file] = struct{}{}
+			}
+		}
+	}
+
+	logs := make(map[string][]byte)
+	offsets := make(map[string]int64)
+	// Plain files sort before their compressed copies
+	for _, file := range slices.Sorted(maps.Keys(files)) {
+		identity := logIdentity(file)
+		offset, ok := offsets[identity]
+		if !ok {
+			offset = w.offsets[identity]
+		}
+		chunk, offset, err := w.readNewLines(file, offset)
+		if err != nil {
+			return err
+		}
+		offsets[identity] = offset
+		if len(chunk) > 0 {
+			logs[identity] = append(logs[identity], chunk...)
+		}
+	}
+
+	if len(logs) > 0 {
+		if err := handle(logs); err != nil {
+			return err
+		}
+	}
+
+	for file, offset := range offsets {
+		w.offsets[file] = offset
+	}
+	return w.saveOffsets()
+}
+
+// readNewLines reads the complete lines written after the offset and returns them with the new offset.
+// A file shorter than its offset was truncated and is read from the start.
+func (w *Watcher) readNewLines(file string, offset int64) ([]byte, int64, error) {
+	if logIdentity(file) != file {
+		return w.readNewCompressedLines(file, offset)
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, 0, nil
+		}
+		return nil, offset, fmt.Errorf("failed to open log file %s: %w", file, err)
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, offset, fmt.Errorf("failed to stat log file %s: %w", file, err)
+	}
+	if info.Size() < offset {
+		slog.Info("Log file was truncated, reading it from the start", "file", file)
+		offset = 0
+	}
+	if info.Size() == offset {
+		return nil, offset, nil
+	}
+
+	data, err := io.ReadAll(io.NewSectionReader(f, offset, info.Size()-offset))
+	if err != nil {
+		return nil, offset, fmt.Errorf("failed to read log file %s: %w", file, err)
+	}
+
+	return completeLines(data, offset)
+}
+
+// readNewCompressedLines decompresses a log and skips the bytes before the offset, as compressed streams
+// cannot be sought.
+func (w *Watcher) readNewCompressedLines(file string, offset int64) ([]byte, int64, error) {
+	read := func(offset int64) ([]byte, int64, error) {
+		f, err := openDecompressed(file)
+		if err != nil {
+			return nil, 0, err
+		}
+		defer f.Close()
+
+		skipped, err := io.CopyN(io.Discard, f, offset)
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, 0, err
+		}
+		if skipped < offset {
+			return nil, skipped, nil
+		}
+		data, err := io.ReadAll(f)
+		return data, skipped, err
+	}
+
+	data, skipped, err := read(offset)
+	if err == nil && skipped < offset {
+		slog.Info("Log file is shorter than its offset, reading it from the start", "file", file)
+		offset = 0
+		data, _, err = read(offset)
+	}
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, 0, nil
+		}
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			// Still being compressed, the next write brings the rest
+			return nil, offset, nil
+		}
+		return nil, offset, fmt.Errorf("failed to read log file %s: %w", file, err)
+	}
+
+	return completeLines(data, offset)
+}
+
+// completeLines leaves a partial last line for the next read.
+func completeLines(data []byte, offset int64) ([]byte, int64, error) {
+	end := bytes.LastIndexByte(data, '\n')
+	if end < 0 {
+		return nil, offset, nil
+	}
+	return data[:end+1], offset + int64(end+1), nil
+}
+
+// hasCopy tells whether a plain or compressed copy of the log is still in the directory.
+func (w *Watcher) hasCopy(identity string) bool {
+	for _, file := range []string{identity, identity + gzipSuffix, identity + zstdSuffix} {
+		if _, err := os.Stat(file); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// matches accepts log files of the directory, plain or compressed. Tar archives are left to GetLogs:
+// bundles are written once and hold logs that have already been tailed.
+func (w *Watcher) matches(file string) bool {
+	if filepath.Dir(file) != filepath.Clean(w.config.LogDirectory) || isTarArchive(file) {
+		return false
+	}
+	return matchesPattern(w.config.LogFilesPattern, logIdentity(file))
+}
+
+func (w *Watcher) loadOffsets() (map[string]int64, error) {
+	offsets := make(map[string]int64)
+
+	content, err := os.ReadFile(w.offsetsFilePath())
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return offsets, nil
+		}
+		return nil, fmt.Errorf("failed to read log offsets: %w", err)
+	}
+	if err := json.Unmarshal(content, &offsets); err != nil {
+		return nil, fmt.Errorf("failed to decode log offsets: %w", err)
+	}
+	return offsets, nil
+}
+
+func (w *Watcher) saveOffsets() error {
+	content, err := json.Marshal(w.offsets)
+	if err != nil {
+		return fmt.Errorf("failed to encode log offsets: %w", err)
+	}
+	if err := tools.WriteFileAtomic(w.offsetsFilePath(), content, 0o600); err != nil {
+		return fmt.Errorf("failed to save log offsets: %w", err)
+	}
+	return nil
+}
+
+func (w *Watcher) offsetsFilePath() string {
+	return filepath.Join(w.watchConfig.OffsetsDirectory, offsetsFileName)
+}
@@ -0,0 +1,176 @@
+package logrepository_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/services/logrepository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	watchDebounce = 50 * time.Millisecond
+	watchTimeout  = 5 * time.Second
+)
+
+func TestWatcher_Watch(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name string
+		// existing is the content of l0001.log before watching starts
+		existing string
+		run      func(t *testing.T, logDirectory string, chunks <-chan map[string][]byte)
+	}{
+		{
+			name:     "success: catches up, then streams appended complete lines",
+			existing: "line 1\nline 2\n",
+			run: func(t *testing.T, logDirectory string, chunks <-chan map[string][]byte) {
+				file := filepath.Join(logDirectory, "l0001.log")
+				assert.Equal(t, map[string][]byte{file: []byte("line 1\nline 2\n")}, receive(t, chunks))
+
+				appendFile(t, file, "line 3\nline ")
+				assert.Equal(t, map[string][]byte{file: []byte("line 3\n")}, receive(t, chunks))
+
+				appendFile(t, file, "4\n")
+				assert.Equal(t, map[string][]byte{file: []byte("line 4\n")}, receive(t, chunks))
+			},
+		},
+		{
+			name:     "success: rotated and truncated files are read from the start",
+			existing: "old line\n",
+			run: func(t *testing.T, logDirectory string, chunks <-chan map[string][]byte) {
+				file := filepath.Join(logDirectory, "l0001.log")
+				assert.Equal(t, map[string][]byte{file: []byte("old line\n")}, receive(t, chunks))
+
+				rotated := filepath.Join(logDirectory, "l0002.log")
+				require.NoError(t, os.WriteFile(rotated, []byte("new file\n"), 0o600))
+				assert.Equal(t, map[string][]byte{rotated: []byte("new file\n")}, receive(t, chunks))
+
+				require.NoError(t, os.WriteFile(file, []byte("short\n"), 0o600))
+				assert.Equal(t, map[string][]byte{file: []byte("short\n")}, receive(t, chunks))
+			},
+		},
+		{
+			name:     "success: a compressed log continues from the offset of its plain copy",
+			existing: "line 1\n",
+			run: func(t *testing.T, logDirectory string, chunks <-chan map[string][]byte) {
+				file := filepath.Join(logDirectory, "l0001.log")
+				assert.Equal(t, map[string][]byte{file: []byte("line 1\n")}, receive(t, chunks))
+
+				// Rotated away and compressed, with a line written in between
+				placeFile(t, file+".gz", gzipped(t, []byte("line 1\nline 2\n")))
+				require.NoError(t, os.Remove(file))
+				assert.Equal(t, map[string][]byte{file: []byte("line 2\n")}, receive(t, chunks))
+
+				next := filepath.Join(logDirectory, "l0002.log")
+				placeFile(t, next+".zst", zstdCompressed(t, []byte("new file\n")))
+				assert.Equal(t, map[string][]byte{next: []byte("new file\n")}, receive(t, chunks))
+			},
+		},
+		{
+			name:     "success: files not matching the pattern are ignored",
+			existing: "line 1\n",
+			run: func(t *testing.T, logDirectory string, chunks <-chan map[string][]byte) {
+				file := filepath.Join(logDirectory, "l0001.log")
+				assert.Equal(t, map[string][]byte{file: []byte("line 1\n")}, receive(t, chunks))
+
+				require.NoError(t, os.WriteFile(filepath.Join(logDirectory, "other.txt"), []byte("x\n"), 0o600))
+				appendFile(t, file, "line 2\n")
+				assert.Equal(t, map[string][]byte{file: []byte("line 2\n")}, receive(t, chunks))
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			logDirectory := t.TempDir()
+			require.NoError(t, os.WriteFile(filepath.Join(logDirectory, "l0001.log"), []byte(tt.existing), 0o600))
+
+			watcher := logrepository.NewWatcher(
+				*logrepository.NewConfig(logDirectory, "l*.log"),
+				*logrepository.NewWatchConfig(t.TempDir(), watchDebounce, 10*watchDebounce),
+			)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			chunks := make(chan map[string][]byte)
+			done := make(chan error)
+			go func() {
+				done <- watcher.Watch(ctx, func(logs map[string][]byte) error {
+					chunks <- logs
+					return nil
+				})
+			}()
+
+			tt.run(t, logDirectory, chunks)
+
+			cancel()
+			assert.NoError(t, <-done)
+		})
+	}
+}
+
+func TestWatcher_Watch_ResumesFromOffsets(t *testing.T) {
+	t.Parallel()
+	logDirectory := t.TempDir()
+	offsetsDirectory := t.TempDir()
+	file := filepath.Join(logDirectory, "l0001.log")
+	require.NoError(t, os.WriteFile(file, []byte("line 1\n"), 0o600))
+
+	watch := func() map[string][]byte {
+		watcher := logrepository.NewWatcher(
+			*logrepository.NewConfig(logDirectory, "l*.log"),
+			*logrepository.NewWatchConfig(offsetsDirectory, watchDebounce, 0),
+		)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		chunks := make(chan map[string][]byte, 1)
+		done := make(chan error)
+		go func() {
+			done <- watcher.Watch(ctx, func(logs map[string][]byte) error {
+				chunks <- logs
+				cancel()
+				return nil
+			})
+		}()
+		chunk := receive(t, chunks)
+		require.NoError(t, <-done)
+		return chunk
+	}
+
+	assert.Equal(t, map[string][]byte{file: []byte("line 1\n")}, watch())
+	appendFile(t, file, "line 2\n")
+	assert.Equal(t, map[string][]byte{file: []byte("line 2\n")}, watch())
+}
+
+func receive(t *testing.T, chunks <-chan map[string][]byte) map[string][]byte {
+	t.Helper()
+	select {
+	case chunk := <-chunks:
+		return chunk
+	case <-time.After(watchTimeout):
+		t.Fatal("timed out waiting for new log lines")
+		return nil
+	}
+}
+
+// placeFile moves the complete file into place, as the watcher would otherwise see it half written.
+func placeFile(t *testing.T, file string, content []byte) {
+	t.Helper()
+	temporary := filepath.Join(t.TempDir(), filepath.Base(file))
+	require.NoError(t, os.WriteFile(temporary, content, 0o600))
+	require.NoError(t, os.Rename(temporary, file))
+}
+
+func appendFile(t *testing.T, file, content string) {
+	t.Helper()
+	f, err := os.OpenFile(file, os.O_APPEND|os.O_WRONLY, 0o600)
+	require.NoError(t, err)
+	_, err = f.WriteString(content)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+}
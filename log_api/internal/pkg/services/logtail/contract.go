@@ -0,0 +1,20 @@
+package logtail
+
+import (
+	"context"
+	"time"
+
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/dto"
+)
+
+type watcher interface {
+	Watch(ctx context.Context, handle func(logs map[string][]byte) error) error
+}
+
+type parser interface {
+	ParseLines(ctx context.Context, logs map[string][]byte, requestTimeStamp time.Time) (*dto.ParseReport, error)
+}
+
+type redisCache interface {
+	Del(ctx context.Context, keys ...string) error
+}
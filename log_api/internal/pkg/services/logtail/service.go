@@ -0,0 +1,84 @@
+package logtail
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/dto"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/enums"
+)
+
+// Service keeps the dashboard live: lines appended to the logs are parsed as they come,
+// and only the graph caches they affect are dropped.
+type Service struct {
+	watcher    watcher
+	parser     parser
+	redisCache redisCache
+}
+
+func NewService(watcher watcher, parser parser, redisCache redisCache) *Service {
+	return &Service{
+		watcher:    watcher,
+		parser:     parser,
+		redisCache: redisCache,
+	}
+}
+
+// Run tails the logs until the context is done.
+func (s *Service) Run(ctx context.Context) error {
+	return s.watcher.Watch(ctx, func(logs map[string][]byte) error {
+		report, err := s.parser.ParseLines(ctx, logs, time.Now())
+		if err != nil {
+			return fmt.Errorf("failed to parse new log lines: %w", err)
+		}
+
+		graphTypes := AffectedGraphTypes(report)
+		if len(graphTypes) == 0 {
+			return nil
+		}
+
+		keys := make([]string, 0, len(graphTypes))
+		for _, graphType := range graphTypes {
+			keys = append(keys, graphType.CacheKey())
+		}
+		if err := s.redisCache.Del(ctx, keys...); err != nil {
+			return fmt.Errorf("failed to invalidate graph caches: %w", err)
+		}
+
+		slog.InfoContext(ctx, "Parsed new log lines", "lines", report.LinesCount, "invalidated", keys)
+
+		return nil
+	})
+}
+
+// AffectedGraphTypes returns the cached graphs whose data changed with the parsed lines.
+// Player actions feed every log based graph, round events only the rounds one; chat feeds none.
+func AffectedGraphTypes(report *dto.ParseReport) []enums.GraphType {
+	var affected []enums.GraphType
+	for key, count := range report.ActionCounts {
+		if count == 0 {
+			continue
+		}
+		switch {
+		case enums.Action(key).IsValid():
+			affected = append(
+				affected,
+				enums.GraphTypes.TopTimeSpentGraphType(),
+				enums.GraphTypes.TopCountriesGraphType(),
+				enums.GraphTypes.OnlineStatisticsGraphType(),
+				enums.GraphTypes.PeakConcurrencyGraphType(),
+				enums.GraphTypes.DailyActivesGraphType(),
+				// Round participants come from who is online
+				enums.GraphTypes.RoundsGraphType(),
+			)
+		case enums.RoundEventType(key).IsValid():
+			affected = append(affected, enums.GraphTypes.RoundsGraphType())
+		}
+	}
+
+	slices.Sort(affected)
+	return slices.Compact(affected)
+}
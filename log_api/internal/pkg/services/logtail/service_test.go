@@ -0,0 +1,54 @@
+package logtail_test
+
+import (
+	"testing"
+
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/dto"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/enums"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/services/logtail"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAffectedGraphTypes(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name         string
+		actionCounts map[string]int
+		expected     []enums.GraphType
+	}{
+		{
+			name:         "success: chat only affects nothing",
+			actionCounts: map[string]int{"chat": 3},
+			expected:     nil,
+		},
+		{
+			name:         "success: round events only affect the rounds graph",
+			actionCounts: map[string]int{enums.RoundEventTypes.RoundWon().String(): 1, "chat": 1},
+			expected:     []enums.GraphType{enums.GraphTypes.RoundsGraphType()},
+		},
+		{
+			name: "success: player actions affect every log based graph once",
+			actionCounts: map[string]int{
+				enums.Actions.Connected().String():        1,
+				enums.Actions.Disconnected().String():     1,
+				enums.RoundEventTypes.RoundWon().String(): 1,
+			},
+			expected: []enums.GraphType{
+				enums.GraphTypes.DailyActivesGraphType(),
+				enums.GraphTypes.OnlineStatisticsGraphType(),
+				enums.GraphTypes.PeakConcurrencyGraphType(),
+				enums.GraphTypes.RoundsGraphType(),
+				enums.GraphTypes.TopCountriesGraphType(),
+				enums.GraphTypes.TopTimeSpentGraphType(),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			affected := logtail.AffectedGraphTypes(&dto.ParseReport{ActionCounts: tt.actionCounts})
+			assert.Equal(t, tt.expected, affected)
+		})
+	}
+}
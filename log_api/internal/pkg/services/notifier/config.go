@@ -0,0 +1,31 @@
+package notifier
+
+import (
+	"time"
+
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/dto"
+)
+
+type config struct {
+	Settings         dto.NotifierSettings
+	StorageDirectory string
+	// PollInterval is how often A2S is polled for server-down rules
+	PollInterval time.Duration
+	// RetryBackoff is the delay before the first redelivery of a failed webhook, doubled on each attempt
+	RetryBackoff time.Duration
+}
+
+//nolint:revive // no sense in export here
+func NewConfig(
+	settings dto.NotifierSettings,
+	storageDirectory string,
+	pollInterval time.Duration,
+	retryBackoff time.Duration,
+) *config {
+	return &config{
+		Settings:         settings,
+		StorageDirectory: storageDirectory,
+		PollInterval:     pollInterval,
+		RetryBackoff:     retryBackoff,
+	}
+}
@@ -0,0 +1,15 @@
+package notifier
+
+import (
+	"context"
+
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/dto"
+)
+
+type recordsRepository interface {
+	Get() (*dto.ServerRecords, error)
+}
+
+type playersInfoProvider interface {
+	PlayersInfo(ctx context.Context) (*dto.PlayersInfo, error)
+}
@@ -0,0 +1,303 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/dto"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/enums"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/tools"
+)
+
+const (
+	stateFileName = "notifier.json"
+	queueSize     = 100
+)
+
+// state is what the triggers need to remember between parses.
+type state struct {
+	// Online maps the nicknames online to their SteamIDs
+	Online map[string]string `json:"online"`
+	// PeakCount is the all-time peak already notified about, nil until the first parse
+	PeakCount *int `json:"peak_count"`
+}
+
+type delivery struct {
+	webhook      dto.WebhookSettings
+	notification dto.Notification
+}
+
+// Service turns parsed events and A2S outages into webhook notifications, according to the configured rules.
+type Service struct {
+	config              config
+	recordsRepository   recordsRepository
+	playersInfoProvider playersInfoProvider
+	httpClient          *http.Client
+	webhooks            map[string]dto.WebhookSettings
+	queue               chan delivery
+
+	mu        sync.Mutex
+	lastFired map[string]time.Time
+}
+
+func NewService(
+	config config,
+	recordsRepository recordsRepository,
+	playersInfoProvider playersInfoProvider,
+) *Service {
+	webhooks := make(map[string]dto.WebhookSettings, len(config.Settings.Webhooks))
+	for _, webhook := range config.Settings.Webhooks {
+		webhooks[webhook.Name] = webhook
+	}
+
+	return &Service{
+		config:              config,
+		recordsRepository:   recordsRepository,
+		playersInfoProvider: playersInfoProvider,
+		httpClient:          &http.Client{},
+		webhooks:            webhooks,
+		queue:               make(chan delivery, queueSize),
+		lastFired:           make(map[string]time.Time),
+	}
+}
+
+// Run delivers the notifications, and polls A2S if a server-down rule needs it, until the context is done.
+func (s *Service) Run(ctx context.Context) {
+	if s.hasRule(enums.NotificationTriggers.ServerDown()) {
+		go s.pollServer(ctx)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case d := <-s.queue:
+			if err := s.deliver(ctx, d.webhook, d.notification); err != nil && ctx.Err() == nil {
+				slog.ErrorContext(ctx, "Failed to deliver a notification", "error", err)
+			}
+		}
+	}
+}
+
+// Index fires the rules triggered by a freshly parsed batch.
+// It must run after the records index, so that the all-time peak is up to date.
+func (s *Service) Index(_ context.Context, batch *dto.ParseBatch) error {
+	if len(s.config.Settings.Rules) == 0 {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	st, err := s.load()
+	if err != nil {
+		return err
+	}
+
+	logs := slices.Clone(batch.Logs)
+	sort.SliceStable(logs, func(i, j int) bool {
+		return logs[i].TimeStamp.Before(logs[j].TimeStamp)
+	})
+	for _, logEntry := range logs {
+		switch logEntry.Action {
+		case enums.Actions.Connected():
+			s.onConnected(st, logEntry)
+		case enums.Actions.Disconnected():
+			delete(st.Online, logEntry.NickName)
+		}
+	}
+
+	if err := s.checkPeak(st); err != nil {
+		return err
+	}
+
+	return s.save(st)
+}
+
+func (s *Service) onConnected(st *state, logEntry dto.LogData) {
+	if len(st.Online) == 0 {
+		s.fire(enums.NotificationTriggers.FirstJoin(), nil, dto.Notification{
+			Title:     "Server is coming alive",
+			Message:   fmt.Sprintf("%s joined an empty server", logEntry.NickName),
+			TimeStamp: logEntry.TimeStamp,
+		})
+	}
+
+	wasOnline := len(st.Online)
+	st.Online[logEntry.NickName] = logEntry.SteamID
+	online := len(st.Online)
+
+	s.fire(
+		enums.NotificationTriggers.PlayerCount(),
+		func(rule dto.NotificationRule) bool { return wasOnline < rule.Threshold && online >= rule.Threshold },
+		dto.Notification{
+			Title:     fmt.Sprintf("%d players online", online),
+			Message:   fmt.Sprintf("%s joined, the server now has %d players", logEntry.NickName, online),
+			TimeStamp: logEntry.TimeStamp,
+		},
+	)
+
+	if steamID, err := tools.NormalizeSteamID(logEntry.SteamID); err == nil {
+		s.fire(
+			enums.NotificationTriggers.PlayerConnected(),
+			func(rule dto.NotificationRule) bool { return slices.Contains(rule.SteamIDs, steamID) },
+			dto.Notification{
+				Title:     fmt.Sprintf("%s connected", logEntry.NickName),
+				Message:   fmt.Sprintf("%s (%s) connected to the server", logEntry.NickName, steamID),
+				TimeStamp: logEntry.TimeStamp,
+				Fields:    []dto.NotificationField{{Name: "Country", Value: countryOrUnknown(logEntry.Country)}},
+			},
+		)
+	}
+}
+
+func (s *Service) checkPeak(st *state) error {
+	records, err := s.recordsRepository.Get()
+	if err != nil {
+		return fmt.Errorf("failed to get records: %w", err)
+	}
+	peak := records.PeakConcurrency
+	if peak == nil {
+		return nil
+	}
+
+	// The first parse only learns the record, it is not news
+	if st.PeakCount != nil && peak.Count > *st.PeakCount {
+		s.fire(enums.NotificationTriggers.AllTimePeak(), nil, dto.Notification{
+			Title:     "New all-time peak",
+			Message:   fmt.Sprintf("%d players online at once, the previous record was %d", peak.Count, *st.PeakCount),
+			TimeStamp: peak.TimeStamp,
+			Fields:    []dto.NotificationField{{Name: "Players", Value: strings.Join(peak.Players, ", ")}},
+		})
+	}
+	st.PeakCount = &peak.Count
+	return nil
+}
+
+// pollServer queries A2S and fires server-down rules once per outage, when their number of failed polls is reached.
+func (s *Service) pollServer(ctx context.Context) {
+	ticker := time.NewTicker(s.config.PollInterval)
+	defer ticker.Stop()
+
+	failedPolls := 0
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if _, err := s.playersInfoProvider.PlayersInfo(ctx); err == nil {
+			failedPolls = 0
+			continue
+		}
+		// A query cut short by the shutdown says nothing about the server
+		if ctx.Err() != nil {
+			return
+		}
+		failedPolls++
+
+		s.mu.Lock()
+		s.fire(
+			enums.NotificationTriggers.ServerDown(),
+			func(rule dto.NotificationRule) bool { return failedPolls == rule.FailedPolls },
+			dto.Notification{
+				Title:     "Server is down",
+				Message:   fmt.Sprintf("The server has not answered %d queries in a row", failedPolls),
+				TimeStamp: time.Now(),
+				Fields:    []dto.NotificationField{{Name: "Failed polls", Value: strconv.Itoa(failedPolls)}},
+			},
+		)
+		s.mu.Unlock()
+	}
+}
+
+// fire queues the notification for every rule of the trigger that matches and is not rate limited.
+// The caller holds the lock.
+func (s *Service) fire(
+	trigger enums.NotificationTrigger,
+	matches func(rule dto.NotificationRule) bool,
+	notification dto.Notification,
+) {
+	for _, rule := range s.config.Settings.Rules {
+		if rule.Trigger != trigger || (matches != nil && !matches(rule)) {
+			continue
+		}
+
+		now := time.Now()
+		minInterval := time.Duration(rule.MinIntervalSeconds) * time.Second
+		if last, ok := s.lastFired[rule.Name]; ok && now.Sub(last) < minInterval {
+			continue
+		}
+		s.lastFired[rule.Name] = now
+
+		notification.Rule = rule.Name
+		notification.Trigger = trigger
+		for _, name := range rule.Webhooks {
+			select {
+			case s.queue <- delivery{webhook: s.webhooks[name], notification: notification}:
+			default:
+				slog.Warn("Notification queue is full, dropped a notification", "rule", rule.Name)
+			}
+		}
+	}
+}
+
+func (s *Service) hasRule(trigger enums.NotificationTrigger) bool {
+	return slices.ContainsFunc(s.config.Settings.Rules, func(rule dto.NotificationRule) bool {
+		return rule.Trigger == trigger
+	})
+}
+
+func (s *Service) load() (*state, error) {
+	st := &state{Online: make(map[string]string)}
+
+	content, err := os.ReadFile(s.filePath())
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return st, nil
+		}
+		return nil, fmt.Errorf("failed to read notifier state: %w", err)
+	}
+	if err := json.Unmarshal(content, st); err != nil {
+		return nil, fmt.Errorf("failed to decode notifier state: %w", err)
+	}
+	if st.Online == nil {
+		st.Online = make(map[string]string)
+	}
+	return st, nil
+}
+
+func (s *Service) save(st *state) error {
+	content, err := json.Marshal(st)
+	if err != nil {
+		return fmt.Errorf("failed to encode notifier state: %w", err)
+	}
+	if err := tools.WriteFileAtomic(s.filePath(), content, 0o600); err != nil {
+		return fmt.Errorf("failed to save notifier state: %w", err)
+	}
+	return nil
+}
+
+func (s *Service) filePath() string {
+	return filepath.Join(s.config.StorageDirectory, stateFileName)
+}
+
+func countryOrUnknown(country string) string {
+	if country == "" {
+		return "unknown"
+	}
+	return country
+}
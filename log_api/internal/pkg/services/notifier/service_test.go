@@ -0,0 +1,292 @@
+package notifier_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/dto"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/enums"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/services/notifier"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testRetryBackoff = time.Millisecond
+	receiveTimeout   = 5 * time.Second
+)
+
+type recordsRepositoryStub struct {
+	records dto.ServerRecords
+}
+
+func (r *recordsRepositoryStub) Get() (*dto.ServerRecords, error) {
+	return &r.records, nil
+}
+
+type playersInfoProviderStub struct {
+	err error
+}
+
+func (p *playersInfoProviderStub) PlayersInfo(_ context.Context) (*dto.PlayersInfo, error) {
+	return &dto.PlayersInfo{}, p.err
+}
+
+// webhookStandIn records the request bodies, answering with the given statuses in turn and 200 afterwards.
+type webhookStandIn struct {
+	server   *httptest.Server
+	bodies   chan []byte
+	requests atomic.Int64
+}
+
+func newWebhookStandIn(t *testing.T, statuses ...int) *webhookStandIn {
+	t.Helper()
+	standIn := &webhookStandIn{bodies: make(chan []byte, 100)}
+	standIn.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		request := int(standIn.requests.Add(1))
+		body, _ := io.ReadAll(r.Body)
+		if request <= len(statuses) {
+			w.WriteHeader(statuses[request-1])
+			return
+		}
+		standIn.bodies <- body
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(standIn.server.Close)
+	return standIn
+}
+
+func (w *webhookStandIn) receive(t *testing.T) []byte {
+	t.Helper()
+	select {
+	case body := <-w.bodies:
+		return body
+	case <-time.After(receiveTimeout):
+		t.Fatal("timed out waiting for a webhook call")
+		return nil
+	}
+}
+
+func runService(
+	t *testing.T,
+	settings dto.NotifierSettings,
+	recordsRepository *recordsRepositoryStub,
+	playersInfoProvider *playersInfoProviderStub,
+) *notifier.Service {
+	t.Helper()
+	require.NoError(t, notifier.ValidateSettings(&settings))
+	service := notifier.NewService(
+		*notifier.NewConfig(settings, t.TempDir(), 5*time.Millisecond, testRetryBackoff),
+		recordsRepository,
+		playersInfoProvider,
+	)
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go service.Run(ctx)
+	return service
+}
+
+func TestService_Index(t *testing.T) {
+	t.Parallel()
+	standIn := newWebhookStandIn(t)
+	base := time.Date(2025, 3, 15, 12, 0, 0, 0, time.UTC)
+	records := &recordsRepositoryStub{}
+	service := runService(t, dto.NotifierSettings{
+		Webhooks: []dto.WebhookSettings{{Name: "hook", URL: standIn.server.URL, Format: enums.WebhookFormats.Generic()}},
+		Rules: []dto.NotificationRule{
+			{Name: "alive", Trigger: enums.NotificationTriggers.FirstJoin(), Webhooks: []string{"hook"}, MinIntervalSeconds: 3600},
+			{Name: "busy", Trigger: enums.NotificationTriggers.PlayerCount(), Webhooks: []string{"hook"}, Threshold: 2},
+			{Name: "friend", Trigger: enums.NotificationTriggers.PlayerConnected(), Webhooks: []string{"hook"}, SteamIDs: []string{"STEAM_0:1:1"}},
+			{Name: "peak", Trigger: enums.NotificationTriggers.AllTimePeak(), Webhooks: []string{"hook"}},
+		},
+	}, records, &playersInfoProviderStub{})
+
+	received := func() dto.Notification {
+		var notification dto.Notification
+		require.NoError(t, json.Unmarshal(standIn.receive(t), &notification))
+		return notification
+	}
+
+	// The first parse only learns the current peak
+	records.records.PeakConcurrency = &dto.ConcurrencyPeak{Count: 1}
+	require.NoError(t, service.Index(context.Background(), &dto.ParseBatch{Logs: []dto.LogData{
+		{TimeStamp: base, NickName: "Alice", Action: enums.Actions.Connected()},
+	}}))
+	notification := received()
+	assert.Equal(t, "alive", notification.Rule)
+	assert.Equal(t, "Alice joined an empty server", notification.Message)
+
+	records.records.PeakConcurrency = &dto.ConcurrencyPeak{Count: 2, Players: []string{"Alice", "Bob"}}
+	require.NoError(t, service.Index(context.Background(), &dto.ParseBatch{Logs: []dto.LogData{
+		{TimeStamp: base.Add(time.Minute), NickName: "Bob", SteamID: "[U:1:2]", Action: enums.Actions.Connected()},
+	}}))
+	assert.Equal(t, "busy", received().Rule)
+	notification = received()
+	assert.Equal(t, "peak", notification.Rule)
+	assert.Equal(t, []dto.NotificationField{{Name: "Players", Value: "Alice, Bob"}}, notification.Fields)
+
+	// Empty again, but the first-join rule is rate limited
+	require.NoError(t, service.Index(context.Background(), &dto.ParseBatch{Logs: []dto.LogData{
+		{TimeStamp: base.Add(2 * time.Minute), NickName: "Alice", Action: enums.Actions.Disconnected()},
+		{TimeStamp: base.Add(3 * time.Minute), NickName: "Bob", Action: enums.Actions.Disconnected()},
+		{TimeStamp: base.Add(4 * time.Minute), NickName: "Carl", SteamID: "[U:1:3]", Action: enums.Actions.Connected()},
+	}}))
+	notification = received()
+	assert.Equal(t, "friend", notification.Rule)
+	assert.Equal(t, "Carl connected", notification.Title)
+	assert.Empty(t, standIn.bodies)
+}
+
+func TestService_Delivery(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		format   enums.WebhookFormat
+		statuses []int
+		assert   func(t *testing.T, standIn *webhookStandIn)
+	}{
+		{
+			name:     "success: retries server errors and rate limits",
+			format:   enums.WebhookFormats.Generic(),
+			statuses: []int{http.StatusInternalServerError, http.StatusTooManyRequests, http.StatusBadGateway},
+			assert: func(t *testing.T, standIn *webhookStandIn) {
+				var notification dto.Notification
+				require.NoError(t, json.Unmarshal(standIn.receive(t), &notification))
+				assert.Equal(t, enums.NotificationTriggers.FirstJoin(), notification.Trigger)
+				assert.Equal(t, int64(4), standIn.requests.Load())
+			},
+		},
+		{
+			name:   "success: discord embed",
+			format: enums.WebhookFormats.Discord(),
+			assert: func(t *testing.T, standIn *webhookStandIn) {
+				var payload struct {
+					Embeds []struct {
+						Title       string `json:"title"`
+						Description string `json:"description"`
+						Timestamp   string `json:"timestamp"`
+					} `json:"embeds"`
+				}
+				require.NoError(t, json.Unmarshal(standIn.receive(t), &payload))
+				require.Len(t, payload.Embeds, 1)
+				assert.Equal(t, "Server is coming alive", payload.Embeds[0].Title)
+				assert.Equal(t, "Alice joined an empty server", payload.Embeds[0].Description)
+				assert.Equal(t, "2025-03-15T12:00:00Z", payload.Embeds[0].Timestamp)
+			},
+		},
+		{
+			name:     "error: client errors are not retried",
+			format:   enums.WebhookFormats.Generic(),
+			statuses: []int{http.StatusBadRequest},
+			assert: func(t *testing.T, standIn *webhookStandIn) {
+				time.Sleep(100 * testRetryBackoff)
+				assert.Equal(t, int64(1), standIn.requests.Load())
+				assert.Empty(t, standIn.bodies)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			standIn := newWebhookStandIn(t, tt.statuses...)
+			service := runService(t, dto.NotifierSettings{
+				Webhooks: []dto.WebhookSettings{{Name: "hook", URL: standIn.server.URL, Format: tt.format}},
+				Rules: []dto.NotificationRule{
+					{Name: "alive", Trigger: enums.NotificationTriggers.FirstJoin(), Webhooks: []string{"hook"}},
+				},
+			}, &recordsRepositoryStub{}, &playersInfoProviderStub{})
+
+			require.NoError(t, service.Index(context.Background(), &dto.ParseBatch{Logs: []dto.LogData{
+				{
+					TimeStamp: time.Date(2025, 3, 15, 12, 0, 0, 0, time.UTC),
+					NickName:  "Alice",
+					Action:    enums.Actions.Connected(),
+				},
+			}}))
+			tt.assert(t, standIn)
+		})
+	}
+}
+
+func TestService_ServerDown(t *testing.T) {
+	t.Parallel()
+	standIn := newWebhookStandIn(t)
+	runService(t, dto.NotifierSettings{
+		Webhooks: []dto.WebhookSettings{{Name: "hook", URL: standIn.server.URL, Format: enums.WebhookFormats.Generic()}},
+		Rules: []dto.NotificationRule{
+			{Name: "down", Trigger: enums.NotificationTriggers.ServerDown(), Webhooks: []string{"hook"}, FailedPolls: 3},
+		},
+	}, &recordsRepositoryStub{}, &playersInfoProviderStub{err: errors.New("timeout")})
+
+	var notification dto.Notification
+	require.NoError(t, json.Unmarshal(standIn.receive(t), &notification))
+	assert.Equal(t, "The server has not answered 3 queries in a row", notification.Message)
+
+	// Once per outage
+	time.Sleep(50 * time.Millisecond)
+	assert.Empty(t, standIn.bodies)
+}
+
+func TestValidateSettings(t *testing.T) {
+	t.Parallel()
+	webhooks := []dto.WebhookSettings{{Name: "hook", URL: "http://localhost", Format: enums.WebhookFormats.Discord()}}
+	tests := []struct {
+		name     string
+		settings dto.NotifierSettings
+		assert   func(t *testing.T, settings dto.NotifierSettings, err error)
+	}{
+		{
+			name: "success: steam ids are normalized",
+			settings: dto.NotifierSettings{Webhooks: webhooks, Rules: []dto.NotificationRule{
+				{Name: "friend", Trigger: enums.NotificationTriggers.PlayerConnected(), Webhooks: []string{"hook"}, SteamIDs: []string{"76561197960265731"}},
+			}},
+			assert: func(t *testing.T, settings dto.NotifierSettings, err error) {
+				require.NoError(t, err)
+				assert.Equal(t, []string{"[U:1:3]"}, settings.Rules[0].SteamIDs)
+			},
+		},
+		{
+			name: "error: unknown webhook",
+			settings: dto.NotifierSettings{Webhooks: webhooks, Rules: []dto.NotificationRule{
+				{Name: "alive", Trigger: enums.NotificationTriggers.FirstJoin(), Webhooks: []string{"other"}},
+			}},
+			assert: func(t *testing.T, _ dto.NotifierSettings, err error) {
+				assert.ErrorIs(t, err, notifier.ErrInvalidSettings)
+			},
+		},
+		{
+			name: "error: unknown trigger",
+			settings: dto.NotifierSettings{Webhooks: webhooks, Rules: []dto.NotificationRule{
+				{Name: "alive", Trigger: "sunrise", Webhooks: []string{"hook"}},
+			}},
+			assert: func(t *testing.T, _ dto.NotifierSettings, err error) {
+				assert.ErrorIs(t, err, notifier.ErrInvalidSettings)
+			},
+		},
+		{
+			name: "error: player-count rule without threshold",
+			settings: dto.NotifierSettings{Webhooks: webhooks, Rules: []dto.NotificationRule{
+				{Name: "busy", Trigger: enums.NotificationTriggers.PlayerCount(), Webhooks: []string{"hook"}},
+			}},
+			assert: func(t *testing.T, _ dto.NotifierSettings, err error) {
+				assert.ErrorIs(t, err, notifier.ErrInvalidSettings)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			err := notifier.ValidateSettings(&tt.settings)
+			tt.assert(t, tt.settings, err)
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package notifier
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/dto"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/enums"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/tools"
+)
+
+var ErrInvalidSettings = errors.New("invalid notifier settings")
+
+// LoadSettings reads and validates the notifier configuration file.
+func LoadSettings(path string) (*dto.NotifierSettings, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read notifier settings: %w", err)
+	}
+
+	var settings dto.NotifierSettings
+	if err := json.Unmarshal(content, &settings); err != nil {
+		return nil, fmt.Errorf("failed to decode notifier settings: %w", err)
+	}
+	if err := ValidateSettings(&settings); err != nil {
+		return nil, err
+	}
+	return &settings, nil
+}
+
+// ValidateSettings checks the settings and normalizes the SteamIDs of the rules.
+func ValidateSettings(settings *dto.NotifierSettings) error {
+	webhooks := make(map[string]struct{}, len(settings.Webhooks))
+	for _, webhook := range settings.Webhooks {
+		if webhook.Name == "" || webhook.URL == "" {
+			return fmt.Errorf("%w: webhook needs a name and a url", ErrInvalidSettings)
+		}
+		if !webhook.Format.IsValid() {
+			return fmt.Errorf("%w: webhook [%s] has unknown format [%s]", ErrInvalidSettings, webhook.Name, webhook.Format)
+		}
+		webhooks[webhook.Name] = struct{}{}
+	}
+
+	for i := range settings.Rules {
+		rule := &settings.Rules[i]
+		if rule.Name == "" {
+			return fmt.Errorf("%w: rule needs a name", ErrInvalidSettings)
+		}
+		if !rule.Trigger.IsValid() {
+			return fmt.Errorf("%w: rule [%s] has unknown trigger [%s]", ErrInvalidSettings, rule.Name, rule.Trigger)
+		}
+		for _, webhook := range rule.Webhooks {
+			if _, ok := webhooks[webhook]; !ok {
+				return fmt.Errorf("%w: rule [%s] posts to unknown webhook [%s]", ErrInvalidSettings, rule.Name, webhook)
+			}
+		}
+		if err := validateRuleParameters(rule); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateRuleParameters(rule *dto.NotificationRule) error {
+	switch rule.Trigger {
+	case enums.NotificationTriggers.PlayerCount():
+		if rule.Threshold <= 0 {
+			return fmt.Errorf("%w: rule [%s] needs a positive threshold", ErrInvalidSettings, rule.Name)
+		}
+	case enums.NotificationTriggers.ServerDown():
+		if rule.FailedPolls <= 0 {
+			return fmt.Errorf("%w: rule [%s] needs a positive failed_polls", ErrInvalidSettings, rule.Name)
+		}
+	case enums.NotificationTriggers.PlayerConnected():
+		if len(rule.SteamIDs) == 0 {
+			return fmt.Errorf("%w: rule [%s] needs steam_ids", ErrInvalidSettings, rule.Name)
+		}
+		for i, steamID := range rule.SteamIDs {
+			normalized, err := tools.NormalizeSteamID(steamID)
+			if err != nil {
+				return fmt.Errorf("%w: rule [%s]: %w", ErrInvalidSettings, rule.Name, err)
+			}
+			rule.SteamIDs[i] = normalized
+		}
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/dto"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/enums"
+)
+
+const (
+	maxDeliveryAttempts = 4
+	deliveryTimeout     = 10 * time.Second
+	discordUsername     = "NMRiH server"
+	discordEmbedColor   = 0xB22222
+)
+
+var errPermanentDelivery = errors.New("webhook rejected the notification")
+
+type discordPayload struct {
+	Username string         `json:"username"`
+	Embeds   []discordEmbed `json:"embeds"`
+}
+
+type discordEmbed struct {
+	Title       string              `json:"title"`
+	Description string              `json:"description"`
+	Timestamp   string              `json:"timestamp"`
+	Color       int                 `json:"color"`
+	Fields      []discordEmbedField `json:"fields,omitempty"`
+}
+
+type discordEmbedField struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Inline bool   `json:"inline"`
+}
+
+func payload(format enums.WebhookFormat, notification dto.Notification) ([]byte, error) {
+	if format != enums.WebhookFormats.Discord() {
+		return json.Marshal(notification)
+	}
+
+	embed := discordEmbed{
+		Title:       notification.Title,
+		Description: notification.Message,
+		Timestamp:   notification.TimeStamp.Format(time.RFC3339),
+		Color:       discordEmbedColor,
+	}
+	for _, field := range notification.Fields {
+		embed.Fields = append(embed.Fields, discordEmbedField{Name: field.Name, Value: field.Value, Inline: true})
+	}
+	return json.Marshal(discordPayload{Username: discordUsername, Embeds: []discordEmbed{embed}})
+}
+
+// deliver posts the notification, retrying failed attempts with an exponential backoff.
+// Rate limited attempts wait as long as the Retry-After header asks.
+func (s *Service) deliver(ctx context.Context, webhook dto.WebhookSettings, notification dto.Notification) error {
+	body, err := payload(webhook.Format, notification)
+	if err != nil {
+		return fmt.Errorf("failed to encode notification: %w", err)
+	}
+
+	backoff := s.config.RetryBackoff
+	for attempt := 1; ; attempt++ {
+		retryAfter, err := s.post(ctx, webhook.URL, body)
+		if err == nil {
+			return nil
+		}
+		if errors.Is(err, errPermanentDelivery) || attempt == maxDeliveryAttempts {
+			return fmt.Errorf("failed to deliver to webhook [%s] after %d attempts: %w", webhook.Name, attempt, err)
+		}
+
+		wait := max(backoff, retryAfter)
+		backoff *= 2
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+}
+
+// post makes a single delivery attempt and returns how long the webhook asked to wait before the next one.
+func (s *Service) post(ctx context.Context, url string, body []byte) (time.Duration, error) {
+	ctx, cancel := context.WithTimeout(ctx, deliveryTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("%w: %w", errPermanentDelivery, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= http.StatusOK && resp.StatusCode < http.StatusMultipleChoices:
+		return 0, nil
+	case resp.StatusCode == http.StatusTooManyRequests:
+		seconds, _ := strconv.ParseFloat(resp.Header.Get("Retry-After"), 64)
+		return time.Duration(seconds * float64(time.Second)), fmt.Errorf("rate limited: %s", resp.Status)
+	case resp.StatusCode >= http.StatusInternalServerError:
+		return 0, fmt.Errorf("server error: %s", resp.Status)
+	default:
+		return 0, fmt.Errorf("%w: %s", errPermanentDelivery, resp.Status)
+	}
+}
@@ -0,0 +1,114 @@
+package parsereport
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/dto"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/tools"
+)
+
+const reportFileName = "parse_report.json"
+
+var ErrNoReport = errors.New("no parse report yet")
+
+// Service keeps the report of the last parse run.
+type Service struct {
+	config config
+	mu     sync.Mutex
+}
+
+func NewService(config config) *Service {
+	return &Service{
+		config: config,
+	}
+}
+
+func (s *Service) Save(report *dto.ParseReport) error {
+	content, err := json.Marshal(report)
+	if err != nil {
+		return fmt.Errorf("failed to encode parse report: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := tools.WriteFileAtomic(s.filePath(), content, 0o600); err != nil {
+		return fmt.Errorf("failed to save parse report: %w", err)
+	}
+	return nil
+}
+
+// GetLast returns the report of the last parse, or ErrNoReport if nothing was parsed yet.
+func (s *Service) GetLast() (*dto.ParseReport, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	content, err := os.ReadFile(s.filePath())
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, ErrNoReport
+		}
+		return nil, fmt.Errorf("failed to read parse report: %w", err)
+	}
+
+	var report dto.ParseReport
+	if err := json.Unmarshal(content, &report); err != nil {
+		return nil, fmt.Errorf("failed to decode parse report: %w", err)
+	}
+	return &report, nil
+}
+
+// Erase drops the diagnostics and unrecognised line patterns of the last report that mention the player,
+// and returns how many it dropped.
+func (s *Service) Erase(_ context.Context, target dto.ErasureTarget) (int, error) {
+	report, err := s.GetLast()
+	if errors.Is(err, ErrNoReport) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	mentions := func(text string) bool {
+		if target.SteamID != "" && strings.Contains(text, target.SteamID) {
+			return true
+		}
+		return slices.ContainsFunc(target.NickNames, func(nickName string) bool {
+			return strings.Contains(text, nickName)
+		})
+	}
+
+	var erased int
+	diagnostics := report.Diagnostics[:0]
+	for _, diagnostic := range report.Diagnostics {
+		if mentions(diagnostic.Raw) || mentions(diagnostic.Reason) {
+			erased++
+			continue
+		}
+		diagnostics = append(diagnostics, diagnostic)
+	}
+	report.Diagnostics = diagnostics
+	for pattern := range report.UnrecognisedPatterns {
+		if mentions(pattern) {
+			delete(report.UnrecognisedPatterns, pattern)
+			erased++
+		}
+	}
+	if erased == 0 {
+		return 0, nil
+	}
+
+	return erased, s.Save(report)
+}
+
+func (s *Service) filePath() string {
+	return filepath.Join(s.config.StorageDirectory, reportFileName)
+}
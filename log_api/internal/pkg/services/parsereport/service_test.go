@@ -0,0 +1,59 @@
+package parsereport_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/dto"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/services/parsereport"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestService_GetLast(t *testing.T) {
+	t.Parallel()
+	startedAt := time.Date(2025, 3, 15, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		reports []*dto.ParseReport
+		assert  func(t *testing.T, report *dto.ParseReport, err error)
+	}{
+		{
+			name: "error: nothing parsed yet",
+			assert: func(t *testing.T, report *dto.ParseReport, err error) {
+				assert.ErrorIs(t, err, parsereport.ErrNoReport)
+				assert.Nil(t, report)
+			},
+		},
+		{
+			name: "success: the latest report wins",
+			reports: []*dto.ParseReport{
+				{StartedAt: startedAt, LinesCount: 10},
+				{
+					StartedAt:        startedAt.Add(time.Hour),
+					LinesCount:       20,
+					DiagnosticsCount: 1,
+					Diagnostics:      []dto.ParseDiagnostic{{File: "a.log", Line: 3, Reason: "bad line", Raw: "???"}},
+				},
+			},
+			assert: func(t *testing.T, report *dto.ParseReport, err error) {
+				require.NoError(t, err)
+				assert.Equal(t, startedAt.Add(time.Hour), report.StartedAt)
+				assert.Equal(t, 20, report.LinesCount)
+				assert.Equal(t, []dto.ParseDiagnostic{{File: "a.log", Line: 3, Reason: "bad line", Raw: "???"}}, report.Diagnostics)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			service := parsereport.NewService(*parsereport.NewConfig(t.TempDir()))
+			for _, report := range tt.reports {
+				require.NoError(t, service.Save(report))
+			}
+			report, err := service.GetLast()
+			tt.assert(t, report, err)
+		})
+	}
+}
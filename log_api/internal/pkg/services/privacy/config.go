@@ -0,0 +1,17 @@
+package privacy
+
+import "github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/enums"
+
+type config struct {
+	IPMode enums.IPMode
+	// HashKey keys the IP hashes and pseudonyms, so they can not be reversed by hashing every address
+	HashKey []byte
+}
+
+//nolint:revive // no sense in export here
+func NewConfig(ipMode enums.IPMode, hashKey string) *config {
+	return &config{
+		IPMode:  ipMode,
+		HashKey: []byte(hashKey),
+	}
+}
@@ -0,0 +1,89 @@
+package privacy
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/netip"
+	"strings"
+
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/enums"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/tools"
+)
+
+const (
+	hashedIPPrefix  = "h:"
+	pseudonymPrefix = "erased-"
+	// hashLength in bytes keeps hashes short but unique enough for a game server's players
+	hashLength = 12
+	ipv4Prefix = 24
+	ipv6Prefix = 48
+)
+
+// ErrNoHashKey is returned for pseudonyms without PRIVACY_HASH_KEY: an unkeyed hash of a SteamID
+// is reversed by hashing every candidate SteamID.
+var ErrNoHashKey = errors.New("pseudonyms need PRIVACY_HASH_KEY to be set")
+
+// Service anonymises IP addresses as configured, both before they are stored and before they leave the API.
+// In the plain mode everything is kept as it is.
+type Service struct {
+	config config
+}
+
+func NewService(config config) *Service {
+	return &Service{config: config}
+}
+
+// AnonymiseIP returns the keyed hash or the truncated network of the address, depending on the mode.
+// An address anonymised already is returned as it is, so stored and fresh addresses can go through it alike.
+func (s *Service) AnonymiseIP(ip string) string {
+	if ip == "" {
+		return ""
+	}
+	switch s.config.IPMode {
+	case enums.IPModes.Hash():
+		if strings.HasPrefix(ip, hashedIPPrefix) {
+			return ip
+		}
+		return hashedIPPrefix + s.hash(ip)
+	case enums.IPModes.Truncate():
+		addr, err := netip.ParseAddr(ip)
+		if err != nil {
+			return ip
+		}
+		bits := ipv6Prefix
+		if addr.Is4() {
+			bits = ipv4Prefix
+		}
+		prefix, err := addr.Prefix(bits)
+		if err != nil {
+			return ip
+		}
+		return prefix.Addr().String()
+	default:
+		return ip
+	}
+}
+
+// AnonymiseText anonymises every IPv4 address in a free text, such as a raw log line or RCON output.
+func (s *Service) AnonymiseText(text string) string {
+	if s.config.IPMode == enums.IPModes.Plain() {
+		return text
+	}
+	return tools.IPRegex.ReplaceAllStringFunc(text, s.AnonymiseIP)
+}
+
+// Pseudonym replaces a nickname or SteamID with a stable name that does not reveal it. It needs the hash key.
+func (s *Service) Pseudonym(value string) (string, error) {
+	if len(s.config.HashKey) == 0 {
+		return "", ErrNoHashKey
+	}
+	return pseudonymPrefix + s.hash(value), nil
+}
+
+func (s *Service) hash(value string) string {
+	mac := hmac.New(sha256.New, s.config.HashKey)
+	mac.Write([]byte(value))
+	return hex.EncodeToString(mac.Sum(nil)[:hashLength])
+}
@@ -0,0 +1,102 @@
+package privacy_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/enums"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/services/privacy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestService_AnonymiseIP(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name   string
+		ipMode enums.IPMode
+		ip     string
+		assert func(t *testing.T, anonymised string)
+	}{
+		{
+			name:   "success: plain keeps the address",
+			ipMode: enums.IPModes.Plain(),
+			ip:     "1.2.3.4",
+			assert: func(t *testing.T, anonymised string) { assert.Equal(t, "1.2.3.4", anonymised) },
+		},
+		{
+			name:   "success: truncate keeps the /24",
+			ipMode: enums.IPModes.Truncate(),
+			ip:     "1.2.3.4",
+			assert: func(t *testing.T, anonymised string) { assert.Equal(t, "1.2.3.0", anonymised) },
+		},
+		{
+			name:   "success: truncate keeps the /48 of IPv6",
+			ipMode: enums.IPModes.Truncate(),
+			ip:     "2001:db8:1:2::1",
+			assert: func(t *testing.T, anonymised string) { assert.Equal(t, "2001:db8:1::", anonymised) },
+		},
+		{
+			name:   "success: hash replaces the address",
+			ipMode: enums.IPModes.Hash(),
+			ip:     "1.2.3.4",
+			assert: func(t *testing.T, anonymised string) {
+				assert.True(t, strings.HasPrefix(anonymised, "h:"))
+				assert.NotContains(t, anonymised, "1.2.3.4")
+			},
+		},
+		{
+			name:   "success: empty stays empty",
+			ipMode: enums.IPModes.Hash(),
+			ip:     "",
+			assert: func(t *testing.T, anonymised string) { assert.Empty(t, anonymised) },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			service := privacy.NewService(*privacy.NewConfig(tt.ipMode, "key"))
+			anonymised := service.AnonymiseIP(tt.ip)
+			tt.assert(t, anonymised)
+			// stored addresses go through it again on the way out
+			assert.Equal(t, anonymised, service.AnonymiseIP(anonymised))
+		})
+	}
+}
+
+func TestService_AnonymiseText(t *testing.T) {
+	t.Parallel()
+	line := `L 03/15/2025 - 16:05:12: "Zeeb<69><[U:1:42]><>" connected, address "123.190.1.1:27005"`
+
+	plain := privacy.NewService(*privacy.NewConfig(enums.IPModes.Plain(), ""))
+	assert.Equal(t, line, plain.AnonymiseText(line))
+
+	truncate := privacy.NewService(*privacy.NewConfig(enums.IPModes.Truncate(), ""))
+	assert.Equal(
+		t,
+		`L 03/15/2025 - 16:05:12: "Zeeb<69><[U:1:42]><>" connected, address "123.190.1.0:27005"`,
+		truncate.AnonymiseText(line),
+	)
+}
+
+func TestService_Pseudonym(t *testing.T) {
+	t.Parallel()
+	service := privacy.NewService(*privacy.NewConfig(enums.IPModes.Plain(), "key"))
+	otherKey := privacy.NewService(*privacy.NewConfig(enums.IPModes.Plain(), "other"))
+	pseudonym := func(service *privacy.Service, value string) string {
+		t.Helper()
+		p, err := service.Pseudonym(value)
+		require.NoError(t, err)
+		return p
+	}
+
+	assert.Equal(t, pseudonym(service, "[U:1:42]"), pseudonym(service, "[U:1:42]"))
+	assert.NotEqual(t, pseudonym(service, "[U:1:42]"), pseudonym(service, "[U:1:43]"))
+	assert.NotEqual(t, pseudonym(service, "[U:1:42]"), pseudonym(otherKey, "[U:1:42]"))
+	assert.True(t, strings.HasPrefix(pseudonym(service, "[U:1:42]"), "erased-"))
+
+	// without a key anyone could hash SteamIDs until one matches
+	_, err := privacy.NewService(*privacy.NewConfig(enums.IPModes.Plain(), "")).Pseudonym("[U:1:42]")
+	assert.ErrorIs(t, err, privacy.ErrNoHashKey)
+}
@@ -0,0 +1,18 @@
+package ratelimit
+
+import "time"
+
+type config struct {
+	// StoreTimeout bounds a Redis round trip, after which the request is limited in memory
+	StoreTimeout time.Duration
+	// MaxMemoryBuckets bounds the fallback buckets, the full ones are dropped beyond it
+	MaxMemoryBuckets int
+}
+
+//nolint:revive // no sense in export here
+func NewConfig(storeTimeout time.Duration, maxMemoryBuckets int) *config {
+	return &config{
+		StoreTimeout:     storeTimeout,
+		MaxMemoryBuckets: maxMemoryBuckets,
+	}
+}
@@ -0,0 +1,16 @@
+package ratelimit
+
+import (
+	"context"
+	"time"
+)
+
+type bucketStore interface {
+	TakeToken(
+		ctx context.Context,
+		key string,
+		capacity int,
+		refillInterval time.Duration,
+		now time.Time,
+	) (bool, time.Duration, error)
+}
@@ -0,0 +1,128 @@
+package ratelimit
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/dto"
+)
+
+const keyPrefix = "rate_limit:"
+
+// Service limits requests with token buckets per policy and client IP. The buckets live in the shared store,
+// and in memory while the store is unreachable, so an outage neither blocks nor unthrottles the API.
+type Service struct {
+	config config
+	store  bucketStore
+
+	mu            sync.Mutex
+	memoryBuckets map[string]*bucket
+	storeDown     bool
+}
+
+type bucket struct {
+	tokens         float64
+	updatedAt      time.Time
+	capacity       int
+	refillInterval time.Duration
+}
+
+// NewService limits in memory only when the store is nil.
+func NewService(config config, store bucketStore) *Service {
+	return &Service{
+		config:        config,
+		store:         store,
+		memoryBuckets: make(map[string]*bucket),
+	}
+}
+
+// Allow takes a token for the client. When there is none, it returns how long until the next one.
+func (s *Service) Allow(
+	ctx context.Context,
+	policy dto.RateLimitPolicy,
+	clientIP string,
+	now time.Time,
+) (bool, time.Duration) {
+	if !policy.Enabled() {
+		return true, 0
+	}
+
+	key := keyPrefix + policy.Name + ":" + clientIP
+	refillInterval := max(policy.RefillInterval(), time.Millisecond)
+
+	if s.store != nil {
+		storeCtx, cancel := context.WithTimeout(ctx, s.config.StoreTimeout)
+		defer cancel()
+
+		allowed, retryAfter, err := s.store.TakeToken(storeCtx, key, policy.Burst, refillInterval, now)
+		s.setStoreDown(err)
+		if err == nil {
+			return allowed, retryAfter
+		}
+	}
+
+	return s.takeMemoryToken(key, policy.Burst, refillInterval, now)
+}
+
+func (s *Service) setStoreDown(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	down := err != nil
+	if down == s.storeDown {
+		return
+	}
+	s.storeDown = down
+	if down {
+		slog.Warn("Rate limit store unreachable, limiting in memory", "error", err)
+		return
+	}
+	slog.Info("Rate limit store reachable again")
+}
+
+func (s *Service) takeMemoryToken(
+	key string,
+	capacity int,
+	refillInterval time.Duration,
+	now time.Time,
+) (bool, time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, ok := s.memoryBuckets[key]
+	if !ok {
+		if len(s.memoryBuckets) >= s.config.MaxMemoryBuckets {
+			s.dropFullBuckets(now)
+		}
+		b = &bucket{tokens: float64(capacity), updatedAt: now, capacity: capacity, refillInterval: refillInterval}
+		s.memoryBuckets[key] = b
+	}
+
+	b.refill(now)
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	// Millisecond resolution, as the store has
+	return false, time.Duration((1 - b.tokens) * float64(refillInterval)).Round(time.Millisecond)
+}
+
+// dropFullBuckets forgets the clients that would get a full bucket anyway.
+func (s *Service) dropFullBuckets(now time.Time) {
+	for key, b := range s.memoryBuckets {
+		b.refill(now)
+		if b.tokens >= float64(b.capacity) {
+			delete(s.memoryBuckets, key)
+		}
+	}
+}
+
+func (b *bucket) refill(now time.Time) {
+	if elapsed := now.Sub(b.updatedAt); elapsed > 0 {
+		b.tokens = math.Min(float64(b.capacity), b.tokens+float64(elapsed)/float64(b.refillInterval))
+	}
+	b.updatedAt = now
+}
@@ -0,0 +1,116 @@
+package ratelimit_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/dto"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/services/ratelimit"
+	"github.com/stretchr/testify/assert"
+)
+
+type storeStub struct {
+	allowed    bool
+	retryAfter time.Duration
+	err        error
+	keys       []string
+}
+
+func (s *storeStub) TakeToken(
+	_ context.Context,
+	key string,
+	_ int,
+	_ time.Duration,
+	_ time.Time,
+) (bool, time.Duration, error) {
+	s.keys = append(s.keys, key)
+	return s.allowed, s.retryAfter, s.err
+}
+
+type request struct {
+	clientIP    string
+	after       time.Duration
+	wantAllowed bool
+	wantRetry   time.Duration
+}
+
+func TestService_Allow(t *testing.T) {
+	t.Parallel()
+	base := time.Date(2025, 3, 15, 12, 0, 0, 0, time.UTC)
+	// A token every 10 seconds, 2 at once
+	policy := dto.RateLimitPolicy{Name: "public", PerMinute: 6, Burst: 2}
+	tests := []struct {
+		name     string
+		store    *storeStub
+		policy   dto.RateLimitPolicy
+		requests []request
+		assert   func(t *testing.T, store *storeStub)
+	}{
+		{
+			name:   "success: memory bucket empties and refills",
+			policy: policy,
+			requests: []request{
+				{clientIP: "1.1.1.1", wantAllowed: true},
+				{clientIP: "1.1.1.1", wantAllowed: true},
+				{clientIP: "1.1.1.1", after: 4 * time.Second, wantAllowed: false, wantRetry: 6 * time.Second},
+				{clientIP: "2.2.2.2", after: 4 * time.Second, wantAllowed: true},
+				// 1.8 tokens by then
+				{clientIP: "1.1.1.1", after: 10 * time.Second, wantAllowed: true},
+				{clientIP: "1.1.1.1", wantAllowed: false, wantRetry: 2 * time.Second},
+			},
+		},
+		{
+			name:   "success: disabled policy lets everything through",
+			store:  &storeStub{allowed: false},
+			policy: dto.RateLimitPolicy{Name: "public"},
+			requests: []request{
+				{clientIP: "1.1.1.1", wantAllowed: true},
+			},
+			assert: func(t *testing.T, store *storeStub) { assert.Empty(t, store.keys) },
+		},
+		{
+			name:   "success: store decides",
+			store:  &storeStub{allowed: false, retryAfter: 3 * time.Second},
+			policy: policy,
+			requests: []request{
+				{clientIP: "1.1.1.1", wantAllowed: false, wantRetry: 3 * time.Second},
+			},
+			assert: func(t *testing.T, store *storeStub) {
+				assert.Equal(t, []string{"rate_limit:public:1.1.1.1"}, store.keys)
+			},
+		},
+		{
+			name:   "success: unreachable store falls back to memory",
+			store:  &storeStub{err: errors.New("connection refused")},
+			policy: policy,
+			requests: []request{
+				{clientIP: "1.1.1.1", wantAllowed: true},
+				{clientIP: "1.1.1.1", wantAllowed: true},
+				{clientIP: "1.1.1.1", wantAllowed: false, wantRetry: 10 * time.Second},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			service := ratelimit.NewService(*ratelimit.NewConfig(time.Second, 100), nil)
+			if tt.store != nil {
+				service = ratelimit.NewService(*ratelimit.NewConfig(time.Second, 100), tt.store)
+			}
+
+			now := base
+			for i, r := range tt.requests {
+				now = now.Add(r.after)
+				allowed, retryAfter := service.Allow(context.Background(), tt.policy, r.clientIP, now)
+				assert.Equal(t, r.wantAllowed, allowed, "request %d", i)
+				assert.Equal(t, r.wantRetry, retryAfter, "request %d", i)
+			}
+			if tt.assert != nil {
+				tt.assert(t, tt.store)
+			}
+		})
+	}
+}
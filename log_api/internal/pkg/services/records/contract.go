@@ -0,0 +1,9 @@
+package records
+
+import "github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/dto"
+
+type graphService interface {
+	Sessions(logs []*dto.LogData) []dto.Session
+	ConcurrencySteps(sessions []dto.Session) []dto.ConcurrencyStep
+	AllTimePeak(steps []dto.ConcurrencyStep) *dto.ConcurrencyPeak
+}
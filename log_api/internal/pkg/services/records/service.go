@@ -0,0 +1,214 @@
+package records
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/dto"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/enums"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/tools"
+)
+
+const recordsFileName = "records.json"
+
+// state is what gets persisted between parses: the records themselves,
+// the sessions still open at the end of the last parsed batch and the time of its last entry.
+type state struct {
+	Records       dto.ServerRecords    `json:"records"`
+	OpenSessions  map[string]time.Time `json:"open_sessions"`
+	LastTimeStamp time.Time            `json:"last_time_stamp"`
+}
+
+type Service struct {
+	config       config
+	graphService graphService
+	mu           sync.Mutex
+}
+
+func NewService(config config, graphService graphService) *Service {
+	return &Service{
+		config:       config,
+		graphService: graphService,
+	}
+}
+
+func (s *Service) Get() (*dto.ServerRecords, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	st, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	return &st.Records, nil
+}
+
+// Index folds a freshly parsed batch of logs into the persisted records.
+// Sessions left open by the previous batch are carried over, so players online across parses are counted exactly.
+// A batch ending before the last one, as backfilled from an earlier period, is folded on its own instead.
+func (s *Service) Index(_ context.Context, batch *dto.ParseBatch) error {
+	logs := batch.Logs
+	if len(logs) == 0 {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	st, err := s.load()
+	if err != nil {
+		return err
+	}
+
+	batchEnd := logs[0].TimeStamp
+	for _, logEntry := range logs {
+		if logEntry.TimeStamp.After(batchEnd) {
+			batchEnd = logEntry.TimeStamp
+		}
+	}
+	backfill := !st.LastTimeStamp.IsZero() && !batchEnd.After(st.LastTimeStamp)
+
+	replay := make([]*dto.LogData, 0, len(st.OpenSessions)+len(logs))
+	if !backfill {
+		for nickName, start := range st.OpenSessions {
+			replay = append(replay, &dto.LogData{
+				TimeStamp: start,
+				NickName:  nickName,
+				Action:    enums.Actions.Connected(),
+			})
+		}
+	}
+	for i := range logs {
+		replay = append(replay, &logs[i])
+	}
+
+	sessions := s.graphService.Sessions(replay)
+	openSessions := s.getOpenSessions(replay)
+	if !backfill {
+		// The sessionizer ends open sessions at their last activity, a carried over one without any at its start,
+		// but the players are still online: they count alongside everyone online until the end of the batch.
+		// A backfilled period is long over, its sessions end where the logs lose track of them.
+		for i := range sessions {
+			if start, open := openSessions[sessions[i].NickName]; open && start.Equal(sessions[i].Start) {
+				sessions[i].End = batchEnd
+			}
+		}
+	}
+
+	if peak := s.graphService.AllTimePeak(s.graphService.ConcurrencySteps(sessions)); peak != nil {
+		if st.Records.PeakConcurrency == nil || peak.Count > st.Records.PeakConcurrency.Count {
+			st.Records.PeakConcurrency = peak
+		}
+	}
+
+	for _, session := range sessions {
+		if start, open := openSessions[session.NickName]; open && start.Equal(session.Start) {
+			// Still running, it is not final yet
+			continue
+		}
+		current := st.Records.LongestSession
+		if current == nil || session.End.Sub(session.Start) > current.End.Sub(current.Start) {
+			st.Records.LongestSession = &session
+		}
+	}
+
+	st.Records.UpdatedAt = time.Now()
+	if !backfill {
+		st.OpenSessions = openSessions
+		st.LastTimeStamp = batchEnd
+	}
+
+	return s.save(st)
+}
+
+// getOpenSessions replays connections of time-ordered logs and returns sessions without a disconnect.
+func (s *Service) getOpenSessions(logs []*dto.LogData) map[string]time.Time {
+	sort.SliceStable(logs, func(i, j int) bool {
+		return logs[i].TimeStamp.Before(logs[j].TimeStamp)
+	})
+
+	openSessions := make(map[string]time.Time)
+	for _, logEntry := range logs {
+		switch logEntry.Action {
+		case enums.Actions.Connected():
+			openSessions[logEntry.NickName] = logEntry.TimeStamp
+		case enums.Actions.Disconnected():
+			delete(openSessions, logEntry.NickName)
+		}
+	}
+	return openSessions
+}
+
+// Erase replaces the player's nickname in the records with the pseudonym. The records stay as they are,
+// as they can not be recomputed once their events are gone. It returns how many nicknames were replaced.
+func (s *Service) Erase(_ context.Context, target dto.ErasureTarget) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	st, err := s.load()
+	if err != nil {
+		return 0, err
+	}
+
+	var erased int
+	if peak := st.Records.PeakConcurrency; peak != nil {
+		erased += target.PseudonymiseNickNames(peak.Players)
+	}
+	if session := st.Records.LongestSession; session != nil && target.HasNickName(session.NickName) {
+		session.NickName = target.Pseudonym
+		erased++
+	}
+	for nickName, start := range st.OpenSessions {
+		if target.HasNickName(nickName) {
+			delete(st.OpenSessions, nickName)
+			st.OpenSessions[target.Pseudonym] = start
+			erased++
+		}
+	}
+	if erased == 0 {
+		return 0, nil
+	}
+
+	return erased, s.save(st)
+}
+
+func (s *Service) load() (*state, error) {
+	st := &state{OpenSessions: make(map[string]time.Time)}
+
+	content, err := os.ReadFile(s.filePath())
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return st, nil
+		}
+		return nil, fmt.Errorf("failed to read records: %w", err)
+	}
+	if err := json.Unmarshal(content, st); err != nil {
+		return nil, fmt.Errorf("failed to decode records: %w", err)
+	}
+	if st.OpenSessions == nil {
+		st.OpenSessions = make(map[string]time.Time)
+	}
+	return st, nil
+}
+
+func (s *Service) save(st *state) error {
+	content, err := json.Marshal(st)
+	if err != nil {
+		return fmt.Errorf("failed to encode records: %w", err)
+	}
+	if err := tools.WriteFileAtomic(s.filePath(), content, 0o600); err != nil {
+		return fmt.Errorf("failed to save records: %w", err)
+	}
+	return nil
+}
+
+func (s *Service) filePath() string {
+	return filepath.Join(s.config.StorageDirectory, recordsFileName)
+}
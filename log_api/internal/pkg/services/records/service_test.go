@@ -0,0 +1,129 @@
+package records_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/dto"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/enums"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/services/graph"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/services/records"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestService_Index(t *testing.T) {
+	t.Parallel()
+	base := time.Date(2025, 3, 15, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		batches [][]dto.LogData
+		assert  func(t *testing.T, serverRecords *dto.ServerRecords, err error)
+	}{
+		{
+			name: "success: peak and longest session from a single batch",
+			batches: [][]dto.LogData{
+				{
+					{TimeStamp: base, NickName: "a", Action: enums.Actions.Connected()},
+					{TimeStamp: base.Add(time.Minute), NickName: "b", Action: enums.Actions.Connected()},
+					{TimeStamp: base.Add(time.Hour), NickName: "b", Action: enums.Actions.Disconnected()},
+					{TimeStamp: base.Add(2 * time.Hour), NickName: "a", Action: enums.Actions.Disconnected()},
+				},
+			},
+			assert: func(t *testing.T, serverRecords *dto.ServerRecords, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, 2, serverRecords.PeakConcurrency.Count)
+				assert.Equal(t, base.Add(time.Minute), serverRecords.PeakConcurrency.TimeStamp)
+				assert.Equal(t, "a", serverRecords.LongestSession.NickName)
+			},
+		},
+		{
+			name: "success: session open across batches is carried over",
+			batches: [][]dto.LogData{
+				{
+					{TimeStamp: base, NickName: "a", Action: enums.Actions.Connected()},
+					{TimeStamp: base.Add(time.Minute), NickName: "a", Action: enums.Actions.Entered()},
+				},
+				{
+					{TimeStamp: base.Add(time.Hour), NickName: "b", Action: enums.Actions.Connected()},
+					{TimeStamp: base.Add(2 * time.Hour), NickName: "b", Action: enums.Actions.Disconnected()},
+					{TimeStamp: base.Add(3 * time.Hour), NickName: "a", Action: enums.Actions.Disconnected()},
+				},
+			},
+			assert: func(t *testing.T, serverRecords *dto.ServerRecords, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, 2, serverRecords.PeakConcurrency.Count)
+				assert.Equal(t, []string{"a", "b"}, serverRecords.PeakConcurrency.Players)
+				assert.Equal(t, "a", serverRecords.LongestSession.NickName)
+				assert.Equal(t, base, serverRecords.LongestSession.Start)
+				assert.Equal(t, base.Add(3*time.Hour), serverRecords.LongestSession.End)
+			},
+		},
+		{
+			name: "success: player online since an earlier batch counts towards the peak",
+			batches: [][]dto.LogData{
+				{
+					{TimeStamp: base, NickName: "a", Action: enums.Actions.Connected()},
+				},
+				{
+					{TimeStamp: base.Add(time.Hour), NickName: "b", Action: enums.Actions.Connected()},
+					{TimeStamp: base.Add(2 * time.Hour), NickName: "b", Action: enums.Actions.Entered()},
+				},
+			},
+			assert: func(t *testing.T, serverRecords *dto.ServerRecords, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, 2, serverRecords.PeakConcurrency.Count)
+				assert.Equal(t, []string{"a", "b"}, serverRecords.PeakConcurrency.Players)
+				assert.Equal(t, base.Add(time.Hour), serverRecords.PeakConcurrency.TimeStamp)
+				assert.Nil(t, serverRecords.LongestSession)
+			},
+		},
+		{
+			name: "success: backfilled earlier period is folded on its own",
+			batches: [][]dto.LogData{
+				{
+					{TimeStamp: base.Add(5 * time.Hour), NickName: "a", Action: enums.Actions.Connected()},
+				},
+				{
+					{TimeStamp: base, NickName: "c", Action: enums.Actions.Connected()},
+					{TimeStamp: base.Add(time.Minute), NickName: "d", Action: enums.Actions.Connected()},
+					{TimeStamp: base.Add(time.Hour), NickName: "d", Action: enums.Actions.Disconnected()},
+					{TimeStamp: base.Add(3 * time.Hour), NickName: "c", Action: enums.Actions.Disconnected()},
+				},
+				{
+					{TimeStamp: base.Add(9 * time.Hour), NickName: "a", Action: enums.Actions.Disconnected()},
+				},
+			},
+			assert: func(t *testing.T, serverRecords *dto.ServerRecords, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, 2, serverRecords.PeakConcurrency.Count)
+				assert.Equal(t, []string{"c", "d"}, serverRecords.PeakConcurrency.Players)
+				assert.Equal(t, base.Add(time.Minute), serverRecords.PeakConcurrency.TimeStamp)
+				// a stayed open across the backfill
+				assert.Equal(t, "a", serverRecords.LongestSession.NickName)
+				assert.Equal(t, base.Add(5*time.Hour), serverRecords.LongestSession.Start)
+			},
+		},
+		{
+			name:    "success: no records yet",
+			batches: nil,
+			assert: func(t *testing.T, serverRecords *dto.ServerRecords, err error) {
+				assert.NoError(t, err)
+				assert.Nil(t, serverRecords.PeakConcurrency)
+				assert.Nil(t, serverRecords.LongestSession)
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			service := records.NewService(*records.NewConfig(t.TempDir()), graph.NewService(nil))
+			for _, batch := range test.batches {
+				assert.NoError(t, service.Index(context.Background(), &dto.ParseBatch{Logs: batch}))
+			}
+			serverRecords, err := service.Get()
+			test.assert(t, serverRecords, err)
+		})
+	}
+}
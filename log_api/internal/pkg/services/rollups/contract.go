@@ -0,0 +1,21 @@
+package rollups
+
+import (
+	"context"
+	"io"
+	"iter"
+
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/dto"
+)
+
+type graphService interface {
+	AddOnlineSeconds(hourlyOnlineSeconds []float64, session dto.Session)
+}
+
+type csvRepository interface {
+	CSVFiles(ctx context.Context, timeRange dto.TimeRange) iter.Seq2[io.Reader, error]
+}
+
+type csvParser interface {
+	Entries(readers iter.Seq2[io.Reader, error]) iter.Seq2[*dto.LogData, error]
+}
@@ -0,0 +1,401 @@
+package rollups
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/dto"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/enums"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/tools"
+)
+
+const (
+	rollupsFileName = "rollups.json"
+	// dailyDirectoryName holds the daily active players, one file per month, so a batch rewrites only its months
+	dailyDirectoryName = "rollups_daily"
+	dailyFileSuffix    = ".json"
+	monthFormat        = "2006-01"
+	// version goes up with every change of the rollup logic, the rollups of an older one are rebuilt
+	version = 3
+	// hoursInDay sizes the hourly online seconds
+	hoursInDay = 24
+)
+
+// state is what gets persisted: the rollups and the version of the logic they were built with.
+type state struct {
+	Version int         `json:"version"`
+	Rollups dto.Rollups `json:"rollups"`
+}
+
+// Service keeps the graph rollups up to date with every parsed batch, and rebuilds them from the CSV store.
+type Service struct {
+	config        config
+	graphService  graphService
+	csvRepository csvRepository
+	csvParser     csvParser
+	mu            sync.Mutex
+	// st is the state as last saved or loaded, nil while the rollups are not built. It is never changed in place:
+	// a batch folds into a copy, so the rollups handed out by Get stay as they were
+	st *state
+	// modTime is the one of the rollups file st was read from or saved to, a change means another process wrote it
+	modTime time.Time
+}
+
+func NewService(config config, graphService graphService, csvRepository csvRepository, csvParser csvParser) *Service {
+	return &Service{
+		config:        config,
+		graphService:  graphService,
+		csvRepository: csvRepository,
+		csvParser:     csvParser,
+	}
+}
+
+// Get returns the rollups, or nil when they were never built or were built by an older version and need a rebuild.
+func (s *Service) Get() (*dto.Rollups, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	st, err := s.current()
+	if err != nil || st == nil {
+		return nil, err
+	}
+	return &st.Rollups, nil
+}
+
+// Index folds the entries of a freshly parsed batch newer than the rollups checkpoint into the rollups.
+// Without built rollups it does nothing, the next rebuild reads the batch from the CSV store.
+func (s *Service) Index(ctx context.Context, batch *dto.ParseBatch) error {
+	if len(batch.Logs) == 0 {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current, err := s.current()
+	if err != nil {
+		return err
+	}
+	if current == nil {
+		slog.DebugContext(ctx, "Rollups are not built, skipping the batch")
+		return nil
+	}
+
+	logs := make([]*dto.LogData, 0, len(batch.Logs))
+	for i := range batch.Logs {
+		// A rebuild running while the batch was saved may have read it already
+		if batch.Logs[i].TimeStamp.After(current.Rollups.Checkpoint) {
+			logs = append(logs, &batch.Logs[i])
+		}
+	}
+	if len(logs) == 0 {
+		return nil
+	}
+	sort.SliceStable(logs, func(i, j int) bool {
+		return logs[i].TimeStamp.Before(logs[j].TimeStamp)
+	})
+
+	st := &state{Version: version, Rollups: cloneRollups(current.Rollups)}
+	touchedDays := make(map[string]struct{})
+	for _, logEntry := range logs {
+		s.fold(&st.Rollups, logEntry, touchedDays)
+	}
+	st.Rollups.UpdatedAt = time.Now()
+
+	return s.save(st, touchedDays)
+}
+
+// Rebuild replaces the rollups with ones folded from every entry of the CSV store, and returns how many it folded.
+func (s *Service) Rebuild(ctx context.Context) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	st := &state{Version: version, Rollups: newRollups()}
+	touchedDays := make(map[string]struct{})
+	var count int
+	for logEntry, err := range s.csvParser.Entries(s.csvRepository.CSVFiles(ctx, dto.TimeRange{})) {
+		if err != nil {
+			return 0, fmt.Errorf("failed to read CSV store: %w", err)
+		}
+		s.fold(&st.Rollups, logEntry, touchedDays)
+		count++
+	}
+	st.Rollups.UpdatedAt = time.Now()
+
+	if err := s.removeDailyFiles(); err != nil {
+		return 0, err
+	}
+	if err := s.save(st, touchedDays); err != nil {
+		return 0, err
+	}
+	slog.InfoContext(ctx, "Rebuilt the rollups", "entries", count)
+	return count, nil
+}
+
+// Erase rebuilds the rollups once the player was erased from the CSV store, as they hold per-player totals.
+// Rollups that were never built are left for the next rebuild.
+func (s *Service) Erase(ctx context.Context, _ dto.ErasureTarget) (int, error) {
+	rollups, err := s.Get()
+	if err != nil || rollups == nil {
+		return 0, err
+	}
+	_, err = s.Rebuild(ctx)
+	return 0, err
+}
+
+// RebuildIfOutdated rebuilds the rollups if they were never built or were built by an older version.
+func (s *Service) RebuildIfOutdated(ctx context.Context) error {
+	rollups, err := s.Get()
+	if err != nil || rollups != nil {
+		return err
+	}
+	_, err = s.Rebuild(ctx)
+	return err
+}
+
+// fold adds an entry to the rollups. Entries have to come in chronological order, sessions follow the graph rules:
+// a reconnect without a disconnect ends the previous session at the last activity before it.
+// The days it adds players to are put in touchedDays; their player sets are copied before the first change,
+// as the ones of a cloned state are shared with the original.
+func (s *Service) fold(rollups *dto.Rollups, logEntry *dto.LogData, touchedDays map[string]struct{}) {
+	nickName := logEntry.NickName
+	player := rollups.Players[nickName]
+	if logEntry.TimeStamp.After(player.LastSeen) {
+		player.LastSeen = logEntry.TimeStamp
+	}
+
+	day := logEntry.TimeStamp.Format(time.DateOnly)
+	if _, touched := touchedDays[day]; !touched {
+		players := make(map[string]struct{}, len(rollups.DailyActivePlayers[day])+1)
+		maps.Copy(players, rollups.DailyActivePlayers[day])
+		rollups.DailyActivePlayers[day] = players
+		touchedDays[day] = struct{}{}
+	}
+	rollups.DailyActivePlayers[day][nickName] = struct{}{}
+
+	openSession, online := rollups.OpenSessions[nickName]
+	switch logEntry.Action {
+	case enums.Actions.Connected():
+		rollups.ConnectionsCount++
+		rollups.CountryConnections[logEntry.Country]++
+		player.ConnectionsCount++
+		s.markFirstDay(rollups, logEntry.TimeStamp)
+		reconnected := false
+		if online {
+			// The time before the reconnect is played, but it has no disconnect to count as online time,
+			// and neither has the session the reconnect opens, unless the one it ends was left out already
+			s.closeSession(&player, nil, dto.Session{
+				NickName: nickName,
+				Start:    openSession.Start,
+				End:      openSession.LastActivity,
+			})
+			reconnected = !openSession.Reconnected
+		}
+		rollups.OpenSessions[nickName] = dto.OpenSession{
+			Start:        logEntry.TimeStamp,
+			LastActivity: logEntry.TimeStamp,
+			Reconnected:  reconnected,
+		}
+	case enums.Actions.Disconnected():
+		s.markFirstDay(rollups, logEntry.TimeStamp)
+		if online {
+			hourlyOnlineSeconds := rollups.HourlyOnlineSeconds
+			if openSession.Reconnected {
+				hourlyOnlineSeconds = nil
+			}
+			s.closeSession(&player, hourlyOnlineSeconds, dto.Session{
+				NickName: nickName,
+				Start:    openSession.Start,
+				End:      logEntry.TimeStamp,
+			})
+			delete(rollups.OpenSessions, nickName)
+		}
+	default:
+		if online {
+			openSession.LastActivity = logEntry.TimeStamp
+			rollups.OpenSessions[nickName] = openSession
+		}
+	}
+
+	rollups.Players[nickName] = player
+	if logEntry.TimeStamp.After(rollups.Checkpoint) {
+		rollups.Checkpoint = logEntry.TimeStamp
+	}
+}
+
+// closeSession counts a finished session, into the online time as well when hourlyOnlineSeconds is given.
+func (s *Service) closeSession(player *dto.PlayerRollup, hourlyOnlineSeconds []float64, session dto.Session) {
+	player.TimeSpent += session.End.Sub(session.Start)
+	player.SessionsCount++
+	if hourlyOnlineSeconds != nil {
+		s.graphService.AddOnlineSeconds(hourlyOnlineSeconds, session)
+	}
+}
+
+func (s *Service) markFirstDay(rollups *dto.Rollups, timeStamp time.Time) {
+	day := time.Date(timeStamp.Year(), timeStamp.Month(), timeStamp.Day(), 0, 0, 0, 0, time.UTC)
+	if rollups.FirstDay == nil || day.Before(*rollups.FirstDay) {
+		rollups.FirstDay = &day
+	}
+}
+
+func newRollups() dto.Rollups {
+	return dto.Rollups{
+		Players:             make(map[string]dto.PlayerRollup),
+		OpenSessions:        make(map[string]dto.OpenSession),
+		HourlyOnlineSeconds: make([]float64, hoursInDay),
+		CountryConnections:  make(map[string]int),
+		DailyActivePlayers:  make(map[string]map[string]struct{}),
+	}
+}
+
+// cloneRollups copies the rollups for a batch to fold into. The daily player sets are left shared, fold copies
+// the ones it changes.
+func cloneRollups(rollups dto.Rollups) dto.Rollups {
+	rollups.Players = maps.Clone(rollups.Players)
+	rollups.OpenSessions = maps.Clone(rollups.OpenSessions)
+	rollups.HourlyOnlineSeconds = slices.Clone(rollups.HourlyOnlineSeconds)
+	rollups.CountryConnections = maps.Clone(rollups.CountryConnections)
+	rollups.DailyActivePlayers = maps.Clone(rollups.DailyActivePlayers)
+	return rollups
+}
+
+// current returns the state, read from the files on first use and again whenever another process,
+// such as nmrihctl, rewrote them since.
+func (s *Service) current() (*state, error) {
+	info, err := os.Stat(s.filePath())
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			s.st, s.modTime = nil, time.Time{}
+			return nil, nil //nolint:nilnil // not built yet
+		}
+		return nil, fmt.Errorf("failed to read rollups: %w", err)
+	}
+	if !info.ModTime().Equal(s.modTime) {
+		if s.st, err = s.load(); err != nil {
+			return nil, err
+		}
+		s.modTime = info.ModTime()
+	}
+	return s.st, nil
+}
+
+// load returns nil for rollups that were never built or were built by an older version.
+func (s *Service) load() (*state, error) {
+	content, err := os.ReadFile(s.filePath())
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil //nolint:nilnil // not built yet
+		}
+		return nil, fmt.Errorf("failed to read rollups: %w", err)
+	}
+
+	st := &state{Rollups: newRollups()}
+	if err := json.Unmarshal(content, st); err != nil {
+		return nil, fmt.Errorf("failed to decode rollups: %w", err)
+	}
+	if st.Version != version {
+		return nil, nil //nolint:nilnil // built by another version
+	}
+
+	entries, err := os.ReadDir(s.dailyDirectory())
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read daily active players: %w", err)
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), dailyFileSuffix) {
+			continue
+		}
+		content, err := os.ReadFile(filepath.Join(s.dailyDirectory(), entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read daily active players: %w", err)
+		}
+		var days map[string][]string
+		if err := json.Unmarshal(content, &days); err != nil {
+			return nil, fmt.Errorf("failed to decode daily active players of %s: %w", entry.Name(), err)
+		}
+		for day, nickNames := range days {
+			players := make(map[string]struct{}, len(nickNames))
+			for _, nickName := range nickNames {
+				players[nickName] = struct{}{}
+			}
+			st.Rollups.DailyActivePlayers[day] = players
+		}
+	}
+	return st, nil
+}
+
+// save writes the months of the touched days, then the rollups, and makes st the current state.
+// Daily players saved ahead of a failed rollups write are folded in again with the batch, which changes nothing.
+func (s *Service) save(st *state, touchedDays map[string]struct{}) error {
+	months := make(map[string]map[string][]string)
+	for day := range touchedDays {
+		months[day[:len(monthFormat)]] = nil
+	}
+	for day, players := range st.Rollups.DailyActivePlayers {
+		month := day[:len(monthFormat)]
+		if days, touched := months[month]; touched {
+			if days == nil {
+				days = make(map[string][]string)
+				months[month] = days
+			}
+			days[day] = slices.Sorted(maps.Keys(players))
+		}
+	}
+	if len(months) > 0 {
+		if err := os.MkdirAll(s.dailyDirectory(), os.ModePerm); err != nil {
+			return fmt.Errorf("failed to create daily active players directory: %w", err)
+		}
+	}
+	for month, days := range months {
+		content, err := json.Marshal(days)
+		if err != nil {
+			return fmt.Errorf("failed to encode daily active players: %w", err)
+		}
+		path := filepath.Join(s.dailyDirectory(), month+dailyFileSuffix)
+		if err := tools.WriteFileAtomic(path, content, 0o600); err != nil {
+			return fmt.Errorf("failed to save daily active players: %w", err)
+		}
+	}
+
+	content, err := json.Marshal(st)
+	if err != nil {
+		return fmt.Errorf("failed to encode rollups: %w", err)
+	}
+	if err := tools.WriteFileAtomic(s.filePath(), content, 0o600); err != nil {
+		return fmt.Errorf("failed to save rollups: %w", err)
+	}
+	info, err := os.Stat(s.filePath())
+	if err != nil {
+		return fmt.Errorf("failed to read rollups: %w", err)
+	}
+	s.st, s.modTime = st, info.ModTime()
+	return nil
+}
+
+// removeDailyFiles drops the daily active players ahead of a rebuild, which saves every month it folds.
+func (s *Service) removeDailyFiles() error {
+	if err := os.RemoveAll(s.dailyDirectory()); err != nil {
+		return fmt.Errorf("failed to remove daily active players: %w", err)
+	}
+	return nil
+}
+
+func (s *Service) dailyDirectory() string {
+	return filepath.Join(s.config.StorageDirectory, dailyDirectoryName)
+}
+
+func (s *Service) filePath() string {
+	return filepath.Join(s.config.StorageDirectory, rollupsFileName)
+}
@@ -0,0 +1,231 @@
+package rollups_test
+
+import (
+	"context"
+	"io"
+	"iter"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/dto"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/enums"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/services/csvparser"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/services/graph"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/services/rollups"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/tools"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const csvContent = "#schema_version:2\nTimeStamp,NickName,SteamID,Action,IPAddress,Country\n" +
+	"2025-03-17 10:00:00,a,,connected,1.1.1.1,LV\n" +
+	"2025-03-17 10:01:00,b,,connected,2.2.2.2,\n" +
+	"2025-03-17 10:30:00,b,,entered,,\n" +
+	"2025-03-17 11:00:00,a,,disconnected,,\n" +
+	"2025-03-18 09:00:00,b,,disconnected,,\n"
+
+type fakeCSVRepository struct {
+	content string
+}
+
+func (r fakeCSVRepository) CSVFiles(_ context.Context, _ dto.TimeRange) iter.Seq2[io.Reader, error] {
+	if r.content == "" {
+		return tools.SeqOf[io.Reader](nil)
+	}
+	return tools.SeqOf([]io.Reader{strings.NewReader(r.content)})
+}
+
+func TestService_Index(t *testing.T) {
+	t.Parallel()
+	base := time.Date(2025, 3, 17, 10, 0, 0, 0, time.UTC)
+	until := base.AddDate(0, 0, 2)
+	logs := []dto.LogData{
+		{TimeStamp: base, NickName: "a", Action: enums.Actions.Connected(), Country: "LV"},
+		{TimeStamp: base.Add(time.Minute), NickName: "b", Action: enums.Actions.Connected(), Country: "DE"},
+		{TimeStamp: base.Add(11 * time.Minute), NickName: "b", Action: enums.Actions.Entered()},
+		{TimeStamp: base.Add(30 * time.Minute), NickName: "c", Action: enums.Actions.Connected()},
+		// the session opened by a reconnect counts as time spent, but not as online time
+		{TimeStamp: base.Add(40 * time.Minute), NickName: "c", Action: enums.Actions.Connected()},
+		{TimeStamp: base.Add(time.Hour), NickName: "a", Action: enums.Actions.Disconnected()},
+		// a reconnect without a disconnect ends the session at the last activity before it
+		{TimeStamp: base.Add(2 * time.Hour), NickName: "b", Action: enums.Actions.Connected(), Country: "DE"},
+		{TimeStamp: base.Add(3 * time.Hour), NickName: "c", Action: enums.Actions.Disconnected()},
+		{TimeStamp: base.AddDate(0, 0, 1), NickName: "a", Action: enums.Actions.Connected()},
+		{TimeStamp: base.AddDate(0, 0, 1).Add(3 * time.Hour), NickName: "a", Action: enums.Actions.Disconnected()},
+		// still open, counts up to the last activity
+		{TimeStamp: base.AddDate(0, 0, 1).Add(4 * time.Hour), NickName: "b", Action: enums.Actions.Entered()},
+	}
+	tests := []struct {
+		name    string
+		batches [][]dto.LogData
+	}{
+		{
+			name:    "success: a single batch",
+			batches: [][]dto.LogData{logs},
+		},
+		{
+			name:    "success: sessions open across batches are carried over",
+			batches: [][]dto.LogData{logs[:3], logs[3:6], logs[6:]},
+		},
+		{
+			name:    "success: entries already folded are skipped",
+			batches: [][]dto.LogData{logs[:5], logs[3:]},
+		},
+	}
+
+	graphService := graph.NewService(nil)
+	streamed := func() iter.Seq2[*dto.LogData, error] {
+		pointers := make([]*dto.LogData, 0, len(logs))
+		for i := range logs {
+			pointers = append(pointers, &logs[i])
+		}
+		return tools.SeqOf(pointers)
+	}
+	topTimeSpent, err := graphService.TopTimeSpent(streamed())
+	require.NoError(t, err)
+	topCountries, err := graphService.TopCountries(streamed())
+	require.NoError(t, err)
+	onlineStatistics, err := graphService.OnlineStatistics(streamed(), until)
+	require.NoError(t, err)
+	dailyActives, err := graphService.DailyActives(streamed(), until)
+	require.NoError(t, err)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			service := rollups.NewService(
+				*rollups.NewConfig(t.TempDir()),
+				graphService,
+				fakeCSVRepository{},
+				csvparser.NewService(),
+			)
+			_, err := service.Rebuild(context.Background())
+			require.NoError(t, err)
+			for _, batch := range tt.batches {
+				require.NoError(t, service.Index(context.Background(), &dto.ParseBatch{Logs: batch}))
+			}
+
+			r, err := service.Get()
+			require.NoError(t, err)
+			require.NotNil(t, r)
+			assert.Equal(t, topTimeSpent, graphService.TopTimeSpentFromRollups(r))
+			// countries with as many connections come in no particular order
+			assert.ElementsMatch(t, topCountries, graphService.TopCountriesFromRollups(r))
+			assert.Equal(t, onlineStatistics, graphService.OnlineStatisticsFromRollups(r, until))
+			assert.Equal(t, dailyActives, graphService.DailyActivesFromRollups(r, until))
+			assert.Equal(t, base.AddDate(0, 0, 1).Add(4*time.Hour), r.Checkpoint)
+		})
+	}
+}
+
+func TestService_Rebuild(t *testing.T) {
+	t.Parallel()
+	graphService := graph.NewService(nil)
+	service := rollups.NewService(
+		*rollups.NewConfig(t.TempDir()),
+		graphService,
+		fakeCSVRepository{content: csvContent},
+		csvparser.NewService(),
+	)
+
+	count, err := service.Rebuild(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 5, count)
+
+	r, err := service.Get()
+	require.NoError(t, err)
+	require.NotNil(t, r)
+	assert.Equal(t, dto.TopTimeSpentList{
+		{NickName: "b", TimeSpent: 22*time.Hour + 59*time.Minute},
+		{NickName: "a", TimeSpent: time.Hour},
+	}, graphService.TopTimeSpentFromRollups(r))
+	assert.Equal(t, 2, r.ConnectionsCount)
+	assert.Equal(t, map[string]int{"LV": 1, "": 1}, r.CountryConnections)
+	assert.Equal(t, map[string]struct{}{"a": {}, "b": {}}, r.DailyActivePlayers["2025-03-17"])
+	assert.Equal(t, map[string]struct{}{"b": {}}, r.DailyActivePlayers["2025-03-18"])
+	assert.Empty(t, r.OpenSessions)
+}
+
+func TestService_Get(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name  string
+		state string
+	}{
+		{
+			name: "success: never built",
+		},
+		{
+			name:  "success: built by an older version",
+			state: `{"version":0,"rollups":{}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			directory := t.TempDir()
+			if tt.state != "" {
+				require.NoError(t, os.WriteFile(filepath.Join(directory, "rollups.json"), []byte(tt.state), 0o600))
+			}
+			service := rollups.NewService(
+				*rollups.NewConfig(directory),
+				graph.NewService(nil),
+				fakeCSVRepository{},
+				csvparser.NewService(),
+			)
+
+			// a batch is not folded into rollups that have to be rebuilt anyway
+			require.NoError(t, service.Index(context.Background(), &dto.ParseBatch{Logs: []dto.LogData{
+				{TimeStamp: time.Now(), NickName: "a", Action: enums.Actions.Connected()},
+			}}))
+			r, err := service.Get()
+			require.NoError(t, err)
+			assert.Nil(t, r)
+		})
+	}
+}
+
+func TestService_IndexSavesTouchedMonthsOnly(t *testing.T) {
+	t.Parallel()
+	directory := t.TempDir()
+	newService := func() *rollups.Service {
+		return rollups.NewService(
+			*rollups.NewConfig(directory),
+			graph.NewService(nil),
+			fakeCSVRepository{content: csvContent},
+			csvparser.NewService(),
+		)
+	}
+	service := newService()
+	_, err := service.Rebuild(context.Background())
+	require.NoError(t, err)
+	march := filepath.Join(directory, "rollups_daily", "2025-03.json")
+	marchInfo, err := os.Stat(march)
+	require.NoError(t, err)
+
+	before, err := service.Get()
+	require.NoError(t, err)
+	require.NoError(t, service.Index(context.Background(), &dto.ParseBatch{Logs: []dto.LogData{
+		{TimeStamp: time.Date(2025, 4, 1, 10, 0, 0, 0, time.UTC), NickName: "c", Action: enums.Actions.Connected()},
+	}}))
+
+	// The rollups handed out before the batch stay as they were
+	assert.NotContains(t, before.DailyActivePlayers, "2025-04-01")
+	assert.Equal(t, 2, before.ConnectionsCount)
+
+	afterInfo, err := os.Stat(march)
+	require.NoError(t, err)
+	assert.Equal(t, marchInfo.ModTime(), afterInfo.ModTime())
+
+	// Another instance, as after a restart, reads every month back
+	r, err := newService().Get()
+	require.NoError(t, err)
+	require.NotNil(t, r)
+	assert.Equal(t, 3, r.ConnectionsCount)
+	assert.Equal(t, map[string]struct{}{"a": {}, "b": {}}, r.DailyActivePlayers["2025-03-17"])
+	assert.Equal(t, map[string]struct{}{"c": {}}, r.DailyActivePlayers["2025-04-01"])
+}
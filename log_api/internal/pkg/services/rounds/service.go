@@ -0,0 +1,298 @@
+package rounds
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/dto"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/enums"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/tools"
+)
+
+const roundsFileName = "rounds.json"
+
+// state is the rounds table plus what is needed to continue it with the next batch:
+// the round still running, the current map and the players online.
+type state struct {
+	Rounds             []dto.Round          `json:"rounds"`
+	OpenRound          *openRound           `json:"open_round"`
+	CurrentMap         string               `json:"current_map"`
+	Online             map[string]time.Time `json:"online"`
+	LastEventTimeStamp time.Time            `json:"last_event_time_stamp"`
+}
+
+type openRound struct {
+	Round        dto.Round           `json:"round"`
+	Participants map[string]struct{} `json:"participants"`
+	Extracted    map[string]struct{} `json:"extracted"`
+}
+
+// timelineItem is either a player log entry or a round event.
+type timelineItem struct {
+	timeStamp  time.Time
+	logEntry   *dto.LogData
+	roundEvent *dto.RoundEvent
+}
+
+type Service struct {
+	config config
+	mu     sync.Mutex
+}
+
+func NewService(config config) *Service {
+	return &Service{config: config}
+}
+
+func (s *Service) GetRounds() ([]dto.Round, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	st, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	return st.Rounds, nil
+}
+
+// Index replays round events together with player connections and appends finished rounds to the rounds table.
+// Events up to the last replayed one come from a backfill of an earlier period or a re-parse of the same logs,
+// they are replayed on their own and only add the rounds missing from the table.
+func (s *Service) Index(_ context.Context, batch *dto.ParseBatch) error {
+	if len(batch.RoundEvents) == 0 && len(batch.Logs) == 0 {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	st, err := s.load()
+	if err != nil {
+		return err
+	}
+
+	var (
+		earlierLogs, laterLogs               []dto.LogData
+		earlierRoundEvents, laterRoundEvents []dto.RoundEvent
+	)
+	for _, logEntry := range batch.Logs {
+		if logEntry.TimeStamp.After(st.LastEventTimeStamp) {
+			laterLogs = append(laterLogs, logEntry)
+		} else {
+			earlierLogs = append(earlierLogs, logEntry)
+		}
+	}
+	for _, roundEvent := range batch.RoundEvents {
+		if roundEvent.TimeStamp.After(st.LastEventTimeStamp) {
+			laterRoundEvents = append(laterRoundEvents, roundEvent)
+		} else {
+			earlierRoundEvents = append(earlierRoundEvents, roundEvent)
+		}
+	}
+
+	if len(earlierRoundEvents) > 0 {
+		s.backfill(st, earlierLogs, earlierRoundEvents)
+	}
+	s.replay(st, laterLogs, laterRoundEvents)
+
+	return s.save(st)
+}
+
+// backfill replays the events of an earlier period from a clean state and adds the rounds starting
+// at a time no round of the table does, keeping the table ordered by start.
+func (s *Service) backfill(st *state, logs []dto.LogData, roundEvents []dto.RoundEvent) {
+	replayed := &state{Online: make(map[string]time.Time)}
+	s.replay(replayed, logs, roundEvents)
+
+	starts := make(map[int64]struct{}, len(st.Rounds))
+	for _, round := range st.Rounds {
+		starts[round.Start.UnixNano()] = struct{}{}
+	}
+	for _, round := range replayed.Rounds {
+		if _, ok := starts[round.Start.UnixNano()]; !ok {
+			st.Rounds = append(st.Rounds, round)
+		}
+	}
+	sort.SliceStable(st.Rounds, func(i, j int) bool {
+		return st.Rounds[i].Start.Before(st.Rounds[j].Start)
+	})
+}
+
+// replay applies the entries and round events to the state in time order.
+func (s *Service) replay(st *state, logs []dto.LogData, roundEvents []dto.RoundEvent) {
+	lastEventTimeStamp := st.LastEventTimeStamp
+	for _, item := range s.timeline(logs, roundEvents) {
+		if item.logEntry != nil {
+			s.applyLogEntry(st, item.logEntry)
+			continue
+		}
+		s.applyRoundEvent(st, item.roundEvent)
+		lastEventTimeStamp = item.roundEvent.TimeStamp
+	}
+	st.LastEventTimeStamp = lastEventTimeStamp
+}
+
+func (s *Service) timeline(logs []dto.LogData, roundEvents []dto.RoundEvent) []timelineItem {
+	items := make([]timelineItem, 0, len(logs)+len(roundEvents))
+	for i := range logs {
+		items = append(items, timelineItem{timeStamp: logs[i].TimeStamp, logEntry: &logs[i]})
+	}
+	for i := range roundEvents {
+		items = append(items, timelineItem{timeStamp: roundEvents[i].TimeStamp, roundEvent: &roundEvents[i]})
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].timeStamp.Before(items[j].timeStamp)
+	})
+	return items
+}
+
+func (s *Service) applyLogEntry(st *state, logEntry *dto.LogData) {
+	switch logEntry.Action {
+	case enums.Actions.Connected(), enums.Actions.Entered():
+		if _, ok := st.Online[logEntry.NickName]; !ok {
+			st.Online[logEntry.NickName] = logEntry.TimeStamp
+		}
+	case enums.Actions.Disconnected():
+		delete(st.Online, logEntry.NickName)
+		return
+	}
+	if st.OpenRound != nil {
+		st.OpenRound.Participants[logEntry.NickName] = struct{}{}
+	}
+}
+
+func (s *Service) applyRoundEvent(st *state, roundEvent *dto.RoundEvent) {
+	switch roundEvent.Type {
+	case enums.RoundEventTypes.MapStarted():
+		s.closeRound(st, roundEvent.TimeStamp, enums.RoundOutcomes.Aborted())
+		st.CurrentMap = roundEvent.Map
+	case enums.RoundEventTypes.RoundStarted():
+		s.closeRound(st, roundEvent.TimeStamp, enums.RoundOutcomes.Aborted())
+		st.OpenRound = &openRound{
+			Round: dto.Round{
+				Map:   st.CurrentMap,
+				Mode:  enums.GameModes.ByMap(st.CurrentMap),
+				Start: roundEvent.TimeStamp,
+			},
+			Participants: make(map[string]struct{}, len(st.Online)),
+			Extracted:    make(map[string]struct{}),
+		}
+		for nickName := range st.Online {
+			st.OpenRound.Participants[nickName] = struct{}{}
+		}
+	case enums.RoundEventTypes.PlayerExtracted():
+		if st.OpenRound != nil {
+			st.OpenRound.Participants[roundEvent.NickName] = struct{}{}
+			st.OpenRound.Extracted[roundEvent.NickName] = struct{}{}
+		}
+	case enums.RoundEventTypes.RoundWon():
+		s.closeRound(st, roundEvent.TimeStamp, enums.RoundOutcomes.Success())
+	case enums.RoundEventTypes.RoundLost():
+		s.closeRound(st, roundEvent.TimeStamp, enums.RoundOutcomes.Failure())
+	}
+}
+
+func (s *Service) closeRound(st *state, end time.Time, outcome enums.RoundOutcome) {
+	if st.OpenRound == nil {
+		return
+	}
+
+	round := st.OpenRound.Round
+	round.End = end
+	round.Outcome = outcome
+	round.Participants = sortedKeys(st.OpenRound.Participants)
+	round.Extracted = sortedKeys(st.OpenRound.Extracted)
+
+	st.Rounds = append(st.Rounds, round)
+	st.OpenRound = nil
+}
+
+func sortedKeys(set map[string]struct{}) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Erase replaces the player's nickname among the round participants with the pseudonym,
+// and returns how many nicknames were replaced.
+func (s *Service) Erase(_ context.Context, target dto.ErasureTarget) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	st, err := s.load()
+	if err != nil {
+		return 0, err
+	}
+
+	var erased int
+	for i := range st.Rounds {
+		erased += target.PseudonymiseNickNames(st.Rounds[i].Participants)
+		erased += target.PseudonymiseNickNames(st.Rounds[i].Extracted)
+	}
+	if st.OpenRound != nil {
+		for _, set := range []map[string]struct{}{st.OpenRound.Participants, st.OpenRound.Extracted} {
+			for nickName := range set {
+				if target.HasNickName(nickName) {
+					delete(set, nickName)
+					set[target.Pseudonym] = struct{}{}
+					erased++
+				}
+			}
+		}
+	}
+	for nickName, since := range st.Online {
+		if target.HasNickName(nickName) {
+			delete(st.Online, nickName)
+			st.Online[target.Pseudonym] = since
+			erased++
+		}
+	}
+	if erased == 0 {
+		return 0, nil
+	}
+
+	return erased, s.save(st)
+}
+
+func (s *Service) load() (*state, error) {
+	st := &state{Online: make(map[string]time.Time)}
+
+	content, err := os.ReadFile(s.filePath())
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return st, nil
+		}
+		return nil, fmt.Errorf("failed to read rounds: %w", err)
+	}
+	if err := json.Unmarshal(content, st); err != nil {
+		return nil, fmt.Errorf("failed to decode rounds: %w", err)
+	}
+	if st.Online == nil {
+		st.Online = make(map[string]time.Time)
+	}
+	return st, nil
+}
+
+func (s *Service) save(st *state) error {
+	content, err := json.Marshal(st)
+	if err != nil {
+		return fmt.Errorf("failed to encode rounds: %w", err)
+	}
+	if err := tools.WriteFileAtomic(s.filePath(), content, 0o600); err != nil {
+		return fmt.Errorf("failed to save rounds: %w", err)
+	}
+	return nil
+}
+
+func (s *Service) filePath() string {
+	return filepath.Join(s.config.StorageDirectory, roundsFileName)
+}
@@ -0,0 +1,145 @@
+package rounds_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/dto"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/enums"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/services/rounds"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestService_Index(t *testing.T) {
+	t.Parallel()
+	base := time.Date(2025, 3, 15, 12, 0, 0, 0, time.UTC)
+	backfill := &dto.ParseBatch{
+		Logs: []dto.LogData{
+			{TimeStamp: base, NickName: "b", Action: enums.Actions.Connected()},
+		},
+		RoundEvents: []dto.RoundEvent{
+			{TimeStamp: base, Type: enums.RoundEventTypes.MapStarted(), Map: "nms_midway"},
+			{TimeStamp: base.Add(time.Minute), Type: enums.RoundEventTypes.RoundStarted()},
+			{TimeStamp: base.Add(20 * time.Minute), Type: enums.RoundEventTypes.RoundLost()},
+		},
+	}
+	tests := []struct {
+		name    string
+		batches []*dto.ParseBatch
+		assert  func(t *testing.T, roundsTable []dto.Round, err error)
+	}{
+		{
+			name: "success: won round with extraction",
+			batches: []*dto.ParseBatch{
+				{
+					Logs: []dto.LogData{
+						{TimeStamp: base, NickName: "a", Action: enums.Actions.Connected()},
+						{TimeStamp: base.Add(time.Minute), NickName: "b", Action: enums.Actions.Connected()},
+						{TimeStamp: base.Add(5 * time.Minute), NickName: "c", Action: enums.Actions.Connected()},
+					},
+					RoundEvents: []dto.RoundEvent{
+						{TimeStamp: base, Type: enums.RoundEventTypes.MapStarted(), Map: "nmo_broadway"},
+						{TimeStamp: base.Add(2 * time.Minute), Type: enums.RoundEventTypes.RoundStarted()},
+						{TimeStamp: base.Add(20 * time.Minute), Type: enums.RoundEventTypes.PlayerExtracted(), NickName: "a"},
+						{TimeStamp: base.Add(22 * time.Minute), Type: enums.RoundEventTypes.RoundWon()},
+					},
+				},
+			},
+			assert: func(t *testing.T, roundsTable []dto.Round, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, []dto.Round{
+					{
+						Map:          "nmo_broadway",
+						Mode:         enums.GameModes.Objective(),
+						Start:        base.Add(2 * time.Minute),
+						End:          base.Add(22 * time.Minute),
+						Outcome:      enums.RoundOutcomes.Success(),
+						Participants: []string{"a", "b", "c"},
+						Extracted:    []string{"a"},
+					},
+				}, roundsTable)
+			},
+		},
+		{
+			name: "success: round spanning two batches, map change aborts the next one",
+			batches: []*dto.ParseBatch{
+				{
+					Logs: []dto.LogData{
+						{TimeStamp: base, NickName: "a", Action: enums.Actions.Connected()},
+					},
+					RoundEvents: []dto.RoundEvent{
+						{TimeStamp: base, Type: enums.RoundEventTypes.MapStarted(), Map: "nms_midway"},
+						{TimeStamp: base.Add(time.Minute), Type: enums.RoundEventTypes.RoundStarted()},
+					},
+				},
+				{
+					Logs: []dto.LogData{
+						{TimeStamp: base.Add(2 * time.Minute), NickName: "a", Action: enums.Actions.Disconnected()},
+					},
+					RoundEvents: []dto.RoundEvent{
+						// Already replayed with the previous batch
+						{TimeStamp: base.Add(time.Minute), Type: enums.RoundEventTypes.RoundStarted()},
+						{TimeStamp: base.Add(10 * time.Minute), Type: enums.RoundEventTypes.RoundLost()},
+						{TimeStamp: base.Add(11 * time.Minute), Type: enums.RoundEventTypes.RoundStarted()},
+						{TimeStamp: base.Add(12 * time.Minute), Type: enums.RoundEventTypes.MapStarted(), Map: "nmo_x"},
+					},
+				},
+			},
+			assert: func(t *testing.T, roundsTable []dto.Round, err error) {
+				assert.NoError(t, err)
+				assert.Len(t, roundsTable, 2)
+				assert.Equal(t, enums.RoundOutcomes.Failure(), roundsTable[0].Outcome)
+				assert.Equal(t, enums.GameModes.Survival(), roundsTable[0].Mode)
+				assert.Equal(t, []string{"a"}, roundsTable[0].Participants)
+				assert.Equal(t, base.Add(time.Minute), roundsTable[0].Start)
+				assert.Equal(t, enums.RoundOutcomes.Aborted(), roundsTable[1].Outcome)
+				assert.Empty(t, roundsTable[1].Participants)
+			},
+		},
+		{
+			name: "success: backfilled earlier period adds its rounds once, without touching the running one",
+			batches: []*dto.ParseBatch{
+				{
+					Logs: []dto.LogData{
+						{TimeStamp: base.Add(time.Hour), NickName: "a", Action: enums.Actions.Connected()},
+					},
+					RoundEvents: []dto.RoundEvent{
+						{TimeStamp: base.Add(time.Hour), Type: enums.RoundEventTypes.MapStarted(), Map: "nmo_broadway"},
+						{TimeStamp: base.Add(61 * time.Minute), Type: enums.RoundEventTypes.RoundStarted()},
+					},
+				},
+				backfill,
+				backfill,
+				{
+					RoundEvents: []dto.RoundEvent{
+						{TimeStamp: base.Add(90 * time.Minute), Type: enums.RoundEventTypes.RoundWon()},
+					},
+				},
+			},
+			assert: func(t *testing.T, roundsTable []dto.Round, err error) {
+				assert.NoError(t, err)
+				assert.Len(t, roundsTable, 2)
+				assert.Equal(t, enums.RoundOutcomes.Failure(), roundsTable[0].Outcome)
+				assert.Equal(t, enums.GameModes.Survival(), roundsTable[0].Mode)
+				assert.Equal(t, []string{"b"}, roundsTable[0].Participants)
+				assert.Equal(t, base.Add(time.Minute), roundsTable[0].Start)
+				assert.Equal(t, enums.RoundOutcomes.Success(), roundsTable[1].Outcome)
+				// b never disconnected in the backfilled logs, yet was not online then
+				assert.Equal(t, []string{"a"}, roundsTable[1].Participants)
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			service := rounds.NewService(*rounds.NewConfig(t.TempDir()))
+			for _, batch := range test.batches {
+				assert.NoError(t, service.Index(context.Background(), batch))
+			}
+			roundsTable, err := service.GetRounds()
+			test.assert(t, roundsTable, err)
+		})
+	}
+}
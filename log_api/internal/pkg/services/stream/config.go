@@ -0,0 +1,18 @@
+package stream
+
+import "time"
+
+type config struct {
+	// PlayersPollInterval is how often the shared poller snapshots the players online
+	PlayersPollInterval time.Duration
+	// SubscriberBuffer is how many events a slow subscriber may lag behind before events are dropped for it
+	SubscriberBuffer int
+}
+
+//nolint:revive // no sense in export here
+func NewConfig(playersPollInterval time.Duration, subscriberBuffer int) *config {
+	return &config{
+		PlayersPollInterval: playersPollInterval,
+		SubscriberBuffer:    subscriberBuffer,
+	}
+}
@@ -0,0 +1,11 @@
+package stream
+
+import (
+	"context"
+
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/dto"
+)
+
+type playersInfoProvider interface {
+	PlayersInfo(ctx context.Context) (*dto.PlayersInfo, error)
+}
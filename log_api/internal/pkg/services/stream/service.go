@@ -0,0 +1,211 @@
+package stream
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/dto"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/enums"
+)
+
+// Service fans live server activity out to the stream subscribers.
+// Ingested events come from the parser; player snapshots come from one A2S poller shared by all subscribers,
+// which only runs while somebody is subscribed.
+type Service struct {
+	config              config
+	playersInfoProvider playersInfoProvider
+
+	mu           sync.Mutex
+	subscribers  map[chan dto.StreamEvent]struct{}
+	stopPoller   context.CancelFunc
+	lastSnapshot *dto.StreamEvent
+	closed       bool
+}
+
+func NewService(config config, playersInfoProvider playersInfoProvider) *Service {
+	return &Service{
+		config:              config,
+		playersInfoProvider: playersInfoProvider,
+		subscribers:         make(map[chan dto.StreamEvent]struct{}),
+	}
+}
+
+// Subscribe registers a subscriber and returns its events with the function to unsubscribe.
+// The latest player snapshot, if any, is delivered right away.
+func (s *Service) Subscribe() (<-chan dto.StreamEvent, func()) {
+	// Room for at least the latest snapshot
+	events := make(chan dto.StreamEvent, max(s.config.SubscriberBuffer, 1))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		close(events)
+		return events, func() {}
+	}
+	s.subscribers[events] = struct{}{}
+	if s.lastSnapshot != nil {
+		events <- *s.lastSnapshot
+	}
+	if s.stopPoller == nil {
+		ctx, cancel := context.WithCancel(context.Background())
+		s.stopPoller = cancel
+		go s.pollPlayers(ctx)
+	}
+
+	var once sync.Once
+	return events, func() {
+		once.Do(func() { s.unsubscribe(events) })
+	}
+}
+
+func (s *Service) unsubscribe(events chan dto.StreamEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Close may have ended the subscription already
+	if _, ok := s.subscribers[events]; !ok {
+		return
+	}
+	delete(s.subscribers, events)
+	close(events)
+
+	if len(s.subscribers) == 0 && s.stopPoller != nil {
+		s.stopPoller()
+		s.stopPoller = nil
+		s.lastSnapshot = nil
+	}
+}
+
+// Close ends every subscription and stops the poller, so the streams let the server shut down.
+// Later subscriptions end right away.
+func (s *Service) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.closed = true
+	for events := range s.subscribers {
+		delete(s.subscribers, events)
+		close(events)
+	}
+	if s.stopPoller != nil {
+		s.stopPoller()
+		s.stopPoller = nil
+		s.lastSnapshot = nil
+	}
+}
+
+// Index publishes the joins, leaves, kills and map changes of a freshly parsed batch, in time order.
+func (s *Service) Index(_ context.Context, batch *dto.ParseBatch) error {
+	events := make([]dto.StreamEvent, 0, len(batch.Logs)+len(batch.Kills))
+
+	for _, logEntry := range batch.Logs {
+		event := dto.StreamEvent{
+			TimeStamp: logEntry.TimeStamp,
+			NickName:  logEntry.NickName,
+			SteamID:   logEntry.SteamID,
+		}
+		switch logEntry.Action {
+		case enums.Actions.Connected():
+			event.Type = enums.StreamEventTypes.Join()
+			event.Country = logEntry.Country
+		case enums.Actions.Disconnected():
+			event.Type = enums.StreamEventTypes.Leave()
+		case enums.Actions.CommittedSuicide():
+			event.Type = enums.StreamEventTypes.Kill()
+			event.Kill = &dto.Kill{
+				TimeStamp:     logEntry.TimeStamp,
+				Killer:        logEntry.NickName,
+				KillerSteamID: logEntry.SteamID,
+				Victim:        logEntry.NickName,
+				VictimSteamID: logEntry.SteamID,
+			}
+		case enums.Actions.Entered():
+			continue
+		}
+		events = append(events, event)
+	}
+	for _, kill := range batch.Kills {
+		events = append(events, dto.StreamEvent{
+			Type:      enums.StreamEventTypes.Kill(),
+			TimeStamp: kill.TimeStamp,
+			Kill:      &kill,
+		})
+	}
+	for _, roundEvent := range batch.RoundEvents {
+		if roundEvent.Type != enums.RoundEventTypes.MapStarted() {
+			continue
+		}
+		events = append(events, dto.StreamEvent{
+			Type:      enums.StreamEventTypes.MapChange(),
+			TimeStamp: roundEvent.TimeStamp,
+			Map:       roundEvent.Map,
+		})
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].TimeStamp.Before(events[j].TimeStamp)
+	})
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, event := range events {
+		s.publish(event)
+	}
+	return nil
+}
+
+func (s *Service) pollPlayers(ctx context.Context) {
+	ticker := time.NewTicker(s.config.PlayersPollInterval)
+	defer ticker.Stop()
+
+	for {
+		s.snapshotPlayers(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Service) snapshotPlayers(ctx context.Context) {
+	playersInfo, err := s.playersInfoProvider.PlayersInfo(ctx)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to query players", "error", err)
+		return
+	}
+
+	snapshot := dto.StreamEvent{
+		Type:      enums.StreamEventTypes.Players(),
+		TimeStamp: time.Now(),
+		Players:   playersInfo,
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// The last subscriber may have left while the query was running
+	if ctx.Err() != nil {
+		return
+	}
+	s.lastSnapshot = &snapshot
+	s.publish(snapshot)
+}
+
+// publish sends the event to every subscriber without blocking: a subscriber too slow to keep up misses events.
+// The caller holds the lock.
+func (s *Service) publish(event dto.StreamEvent) {
+	for events := range s.subscribers {
+		select {
+		case events <- event:
+		default:
+			slog.Debug("Dropped an event for a slow subscriber", "type", event.Type)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package stream_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/dto"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/enums"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/services/stream"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	pollInterval   = 10 * time.Millisecond
+	receiveTimeout = 5 * time.Second
+)
+
+type playersInfoProviderStub struct {
+	queries atomic.Int64
+}
+
+func (p *playersInfoProviderStub) PlayersInfo(_ context.Context) (*dto.PlayersInfo, error) {
+	p.queries.Add(1)
+	return &dto.PlayersInfo{Count: 1, PlayerInfo: []*dto.PlayerInfo{{Name: "Alice"}}}, nil
+}
+
+func TestService_Subscribe(t *testing.T) {
+	t.Parallel()
+	provider := &playersInfoProviderStub{}
+	service := stream.NewService(*stream.NewConfig(pollInterval, 16), provider)
+
+	first, unsubscribeFirst := service.Subscribe()
+	second, unsubscribeSecond := service.Subscribe()
+
+	for _, events := range []<-chan dto.StreamEvent{first, second} {
+		event := receive(t, events, enums.StreamEventTypes.Players())
+		assert.Equal(t, 1, event.Players.Count)
+	}
+
+	unsubscribeFirst()
+	unsubscribeFirst()
+	_, opened := <-first
+	assert.False(t, opened)
+	receive(t, second, enums.StreamEventTypes.Players())
+
+	unsubscribeSecond()
+	// Let a query in flight finish
+	time.Sleep(5 * pollInterval)
+	queries := provider.queries.Load()
+	time.Sleep(5 * pollInterval)
+	assert.Equal(t, queries, provider.queries.Load(), "poller must stop without subscribers")
+
+	third, unsubscribeThird := service.Subscribe()
+	defer unsubscribeThird()
+	receive(t, third, enums.StreamEventTypes.Players())
+}
+
+func TestService_Index(t *testing.T) {
+	t.Parallel()
+	base := time.Date(2025, 3, 15, 12, 0, 0, 0, time.UTC)
+	// A long poll interval keeps snapshots out of the way
+	service := stream.NewService(*stream.NewConfig(time.Hour, 16), &playersInfoProviderStub{})
+	events, unsubscribe := service.Subscribe()
+	defer unsubscribe()
+	receive(t, events, enums.StreamEventTypes.Players())
+
+	err := service.Index(context.Background(), &dto.ParseBatch{
+		Logs: []dto.LogData{
+			{TimeStamp: base.Add(3 * time.Second), NickName: "Alice", Action: enums.Actions.Disconnected()},
+			{TimeStamp: base.Add(time.Second), NickName: "Alice", Action: enums.Actions.Entered()},
+			{TimeStamp: base, NickName: "Alice", Action: enums.Actions.Connected(), Country: "Germany"},
+		},
+		Kills: []dto.Kill{
+			{TimeStamp: base.Add(2 * time.Second), Killer: "Alice", Victim: "Bob", Weapon: "fa_glock17"},
+		},
+		RoundEvents: []dto.RoundEvent{
+			{TimeStamp: base.Add(4 * time.Second), Type: enums.RoundEventTypes.MapStarted(), Map: "nmo_broadway"},
+			{TimeStamp: base.Add(5 * time.Second), Type: enums.RoundEventTypes.RoundStarted()},
+		},
+	})
+	require.NoError(t, err)
+
+	join := receive(t, events, enums.StreamEventTypes.Join())
+	assert.Equal(t, "Germany", join.Country)
+	kill := receive(t, events, enums.StreamEventTypes.Kill())
+	assert.Equal(t, "Bob", kill.Kill.Victim)
+	receive(t, events, enums.StreamEventTypes.Leave())
+	mapChange := receive(t, events, enums.StreamEventTypes.MapChange())
+	assert.Equal(t, "nmo_broadway", mapChange.Map)
+	assert.Empty(t, events)
+}
+
+func receive(t *testing.T, events <-chan dto.StreamEvent, eventType enums.StreamEventType) dto.StreamEvent {
+	t.Helper()
+	select {
+	case event := <-events:
+		require.Equal(t, eventType, event.Type)
+		return event
+	case <-time.After(receiveTimeout):
+		t.Fatalf("timed out waiting for a %s event", eventType)
+		return dto.StreamEvent{}
+	}
+}
+
+func TestService_Close(t *testing.T) {
+	t.Parallel()
+	service := stream.NewService(*stream.NewConfig(pollInterval, 16), &playersInfoProviderStub{})
+
+	events, unsubscribe := service.Subscribe()
+	receive(t, events, enums.StreamEventTypes.Players())
+
+	service.Close()
+	for range events {
+	}
+	// Unsubscribing after Close must not close the channel twice
+	unsubscribe()
+
+	late, unsubscribeLate := service.Subscribe()
+	defer unsubscribeLate()
+	_, opened := <-late
+	assert.False(t, opened)
+}
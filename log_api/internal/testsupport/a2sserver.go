@@ -0,0 +1,283 @@
+// Package testsupport holds in-process fakes of the game server and its logs for end-to-end tests.
+package testsupport
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+const (
+	a2sInfoRequest       = 0x54
+	a2sInfoResponse      = 0x49
+	a2sPlayerRequest     = 0x55
+	a2sPlayerResponse    = 0x44
+	a2sRulesRequest      = 0x56
+	a2sRulesResponse     = 0x45
+	a2sChallengeResponse = 0x41
+
+	a2sInfoPayload  = "Source Engine Query\x00"
+	a2sProtocol     = 17
+	a2sServerType   = 'd'
+	a2sServerOS     = 'l'
+	maxPacketLength = 1400
+)
+
+//nolint:gochecknoglobals // wire constant
+var simplePacketHeader = []byte{0xFF, 0xFF, 0xFF, 0xFF}
+
+// A2SInfo is what the fake server answers info queries with. The player count is the one of its players.
+type A2SInfo struct {
+	Name       string
+	Map        string
+	Folder     string
+	Game       string
+	AppID      uint16
+	MaxPlayers uint8
+	Bots       uint8
+	Version    string
+}
+
+type A2SPlayer struct {
+	Name     string
+	Score    uint32
+	Duration time.Duration
+}
+
+type A2SServerConfig struct {
+	Info    A2SInfo
+	Players []A2SPlayer
+	Rules   map[string]string
+	// Challenge makes the server answer queries without the current challenge number with one, as srcds does
+	Challenge bool
+	// Silent drops every query, standing in for a server that is down
+	Silent bool
+}
+
+// A2SQueries counts the queries the fake server answered with data, challenge replies aside.
+type A2SQueries struct {
+	Info       int
+	Player     int
+	Rules      int
+	Challenges int
+}
+
+// FakeA2SServer answers A2S info, player and rules queries over UDP on the loopback interface.
+type FakeA2SServer struct {
+	conn *net.UDPConn
+	done chan struct{}
+
+	mu        sync.Mutex
+	config    A2SServerConfig
+	challenge [4]byte
+	queries   A2SQueries
+}
+
+// NewFakeA2SServer starts the server on a free port. It is stopped once the test is over.
+func NewFakeA2SServer(t testing.TB, config A2SServerConfig) *FakeA2SServer {
+	t.Helper()
+
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("failed to listen for A2S queries: %s", err)
+	}
+
+	s := &FakeA2SServer{
+		conn:      conn,
+		done:      make(chan struct{}),
+		config:    config,
+		challenge: [4]byte{0x4B, 0xA1, 0xD5, 0x22},
+	}
+	go s.serve()
+	t.Cleanup(func() {
+		_ = s.conn.Close()
+		<-s.done
+	})
+
+	return s
+}
+
+func (s *FakeA2SServer) Host() string {
+	return s.addr().IP.String()
+}
+
+func (s *FakeA2SServer) Port() int {
+	return s.addr().Port
+}
+
+// Address is the host:port the clients query.
+func (s *FakeA2SServer) Address() string {
+	return s.addr().String()
+}
+
+func (s *FakeA2SServer) SetInfo(info A2SInfo) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.config.Info = info
+}
+
+func (s *FakeA2SServer) SetPlayers(players []A2SPlayer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.config.Players = players
+}
+
+func (s *FakeA2SServer) SetRules(rules map[string]string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.config.Rules = rules
+}
+
+func (s *FakeA2SServer) SetSilent(silent bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.config.Silent = silent
+}
+
+func (s *FakeA2SServer) Queries() A2SQueries {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.queries
+}
+
+func (s *FakeA2SServer) addr() *net.UDPAddr {
+	addr, _ := s.conn.LocalAddr().(*net.UDPAddr)
+	return addr
+}
+
+func (s *FakeA2SServer) serve() {
+	defer close(s.done)
+
+	buffer := make([]byte, maxPacketLength)
+	for {
+		n, client, err := s.conn.ReadFromUDP(buffer)
+		if errors.Is(err, net.ErrClosed) {
+			return
+		}
+		if err != nil {
+			continue
+		}
+
+		reply := s.reply(buffer[:n])
+		if reply == nil {
+			continue
+		}
+		_, _ = s.conn.WriteToUDP(reply, client)
+	}
+}
+
+// reply builds the answer to a request, nil when the server keeps silent or the request is malformed.
+func (s *FakeA2SServer) reply(request []byte) []byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.config.Silent || !bytes.HasPrefix(request, simplePacketHeader) || len(request) <= len(simplePacketHeader) {
+		return nil
+	}
+	header, payload := request[len(simplePacketHeader)], request[len(simplePacketHeader)+1:]
+
+	switch header {
+	case a2sInfoRequest:
+		query, ok := bytes.CutPrefix(payload, []byte(a2sInfoPayload))
+		if !ok {
+			return nil
+		}
+		// Info requests carry no challenge until the server asks for one
+		if s.config.Challenge && !s.validChallenge(query) {
+			return s.challengeReply()
+		}
+		s.queries.Info++
+		return s.infoReply()
+	case a2sPlayerRequest, a2sRulesRequest:
+		if len(payload) != len(s.challenge) {
+			return nil
+		}
+		// Without challenges the server answers even the -1 asking for one straight away, as go-a2s allows
+		if s.config.Challenge && !s.validChallenge(payload) {
+			return s.challengeReply()
+		}
+		if header == a2sPlayerRequest {
+			s.queries.Player++
+			return s.playerReply()
+		}
+		s.queries.Rules++
+		return s.rulesReply()
+	default:
+		return nil
+	}
+}
+
+func (s *FakeA2SServer) validChallenge(challenge []byte) bool {
+	return bytes.Equal(challenge, s.challenge[:])
+}
+
+func (s *FakeA2SServer) challengeReply() []byte {
+	s.queries.Challenges++
+
+	packet := newA2SPacket(a2sChallengeResponse)
+	packet.Write(s.challenge[:])
+	return packet.Bytes()
+}
+
+func (s *FakeA2SServer) infoReply() []byte {
+	info := s.config.Info
+
+	packet := newA2SPacket(a2sInfoResponse)
+	packet.WriteByte(a2sProtocol)
+	writeCString(packet, info.Name)
+	writeCString(packet, info.Map)
+	writeCString(packet, info.Folder)
+	writeCString(packet, info.Game)
+	_ = binary.Write(packet, binary.LittleEndian, info.AppID)
+	packet.WriteByte(uint8(min(len(s.config.Players), math.MaxUint8)))
+	packet.WriteByte(info.MaxPlayers)
+	packet.WriteByte(info.Bots)
+	packet.WriteByte(a2sServerType)
+	packet.WriteByte(a2sServerOS)
+	// Public and VAC secured
+	packet.WriteByte(0)
+	packet.WriteByte(1)
+	writeCString(packet, info.Version)
+	return packet.Bytes()
+}
+
+func (s *FakeA2SServer) playerReply() []byte {
+	players := s.config.Players[:min(len(s.config.Players), math.MaxUint8)]
+
+	packet := newA2SPacket(a2sPlayerResponse)
+	packet.WriteByte(uint8(len(players)))
+	for _, player := range players {
+		// srcds leaves the index at zero
+		packet.WriteByte(0)
+		writeCString(packet, player.Name)
+		_ = binary.Write(packet, binary.LittleEndian, player.Score)
+		_ = binary.Write(packet, binary.LittleEndian, float32(player.Duration.Seconds()))
+	}
+	return packet.Bytes()
+}
+
+func (s *FakeA2SServer) rulesReply() []byte {
+	packet := newA2SPacket(a2sRulesResponse)
+	_ = binary.Write(packet, binary.LittleEndian, uint16(min(len(s.config.Rules), math.MaxUint16)))
+	for key, value := range s.config.Rules {
+		writeCString(packet, key)
+		writeCString(packet, value)
+	}
+	return packet.Bytes()
+}
+
+func newA2SPacket(header byte) *bytes.Buffer {
+	packet := bytes.NewBuffer(append([]byte{}, simplePacketHeader...))
+	packet.WriteByte(header)
+	return packet
+}
+
+func writeCString(packet *bytes.Buffer, value string) {
+	packet.WriteString(value)
+	packet.WriteByte(0)
+}
@@ -0,0 +1,49 @@
+package testsupport_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/testsupport"
+	"github.com/rumblefrog/go-a2s"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFakeA2SServer_Rules(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name      string
+		challenge bool
+	}{
+		{
+			name: "success: rules are answered straight away",
+		},
+		{
+			name:      "success: rules are answered after the challenge",
+			challenge: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			rules := map[string]string{"sv_difficulty": "classic", "mp_friendlyfire": "1"}
+			server := testsupport.NewFakeA2SServer(t, testsupport.A2SServerConfig{Rules: rules, Challenge: tt.challenge})
+
+			client, err := a2s.NewClient(server.Address(), a2s.TimeoutOption(time.Second))
+			require.NoError(t, err)
+			t.Cleanup(func() { _ = client.Close() })
+
+			info, err := client.QueryRules()
+			require.NoError(t, err)
+			assert.Equal(t, rules, info.Rules)
+			assert.Equal(t, 1, server.Queries().Rules)
+
+			server.SetRules(map[string]string{"sv_difficulty": "nightmare"})
+			info, err = client.QueryRules()
+			require.NoError(t, err)
+			assert.Equal(t, map[string]string{"sv_difficulty": "nightmare"}, info.Rules)
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package testsupport
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryCache stands in for Redis as the response cache. The rate limiter keeps its buckets
+// in memory itself when it is given no store.
+type MemoryCache struct {
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+	ttl     time.Duration
+}
+
+type cacheEntry struct {
+	value     string
+	expiresAt time.Time
+}
+
+func NewMemoryCache(ttl time.Duration) *MemoryCache {
+	return &MemoryCache{entries: make(map[string]cacheEntry), ttl: ttl}
+}
+
+func (c *MemoryCache) GetWithTimeout(_ context.Context, key string, _ time.Duration) (*string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok || time.Now().After(entry.expiresAt) {
+		return nil, nil
+	}
+	return &entry.value, nil
+}
+
+func (c *MemoryCache) SetWithTimeout(
+	_ context.Context,
+	key, value string,
+	ttlOverride *time.Duration,
+	_ time.Duration,
+) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ttl := c.ttl
+	if ttlOverride != nil {
+		ttl = *ttlOverride
+	}
+	c.entries[key] = cacheEntry{value: value, expiresAt: time.Now().Add(ttl)}
+	return nil
+}
+
+func (c *MemoryCache) Del(_ context.Context, keys ...string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, key := range keys {
+		delete(c.entries, key)
+	}
+	return nil
+}
+
+func (c *MemoryCache) Ping(_ context.Context) error {
+	return nil
+}
+
+// Len counts the entries, expired ones included.
+func (c *MemoryCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.entries)
+}
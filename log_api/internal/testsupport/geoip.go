@@ -0,0 +1,34 @@
+package testsupport
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/dto"
+)
+
+// FakeIPAPIClient answers country lookups from a table instead of ipinfo.
+type FakeIPAPIClient struct {
+	countries map[string]string
+	lookups   atomic.Int64
+}
+
+// NewFakeIPAPIClient looks up the addresses in countries, keyed by address. Unknown ones fail.
+func NewFakeIPAPIClient(countries map[string]string) *FakeIPAPIClient {
+	return &FakeIPAPIClient{countries: countries}
+}
+
+func (c *FakeIPAPIClient) GetCountryByIP(_ context.Context, ip string) (*dto.IPInfo, error) {
+	c.lookups.Add(1)
+
+	countryCode, ok := c.countries[ip]
+	if !ok {
+		return nil, fmt.Errorf("no country for %s", ip)
+	}
+	return &dto.IPInfo{Country: countryCode, CountryCode: countryCode}, nil
+}
+
+func (c *FakeIPAPIClient) Lookups() int {
+	return int(c.lookups.Load())
+}
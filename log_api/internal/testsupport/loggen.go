@@ -0,0 +1,239 @@
+package testsupport
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand/v2"
+	"sort"
+	"time"
+
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/dto"
+)
+
+const (
+	simulatedTimeFormat = "01/02/2006 - 15:04:05"
+	// The players join from the 198.51.100.0/24 documentation range, one address each
+	maxSimulatedPlayers = 254
+
+	simulatedDayStart   = 10 * time.Minute
+	simulatedDayEnd     = 23*time.Hour + 30*time.Minute
+	minSimulatedSession = 5 * time.Minute
+	simulatedActivities = 6
+	steamAccountBase    = 100000000
+	steamAccountStride  = 7919
+	simulatedClientPort = 27005
+)
+
+//nolint:gochecknoglobals // lookup tables
+var (
+	simulatedNickNames = []string{
+		"Survivor", "Shambler", "Bateman", "Wally", "Molotov", "Medic", "Runner", "Jive", "Roje", "Badass",
+	}
+	simulatedCountries = []string{"RU", "DE", "US", "FI", "PL", "UA", "KZ"}
+	simulatedMaps      = []string{"nmo_broadway", "nmo_chinatown", "nmo_fema", "nms_midwest", "nmo_toxteth"}
+	simulatedWeapons   = []string{"me_machete", "me_fubar", "fa_glock17", "fa_870", "fa_sks", "tool_barricade"}
+	simulatedZombies   = []string{"npc_nmrih_shamblerzombie", "npc_nmrih_runnerzombie", "npc_nmrih_kidzombie"}
+	// A message like the last one must not be taken for a disconnect
+	simulatedMessages = []string{
+		"gg", "need ammo", "anyone got a medkit?", "extraction is open", "wait for me", "I got disconnected earlier",
+	}
+)
+
+type LogSimulationConfig struct {
+	Seed uint64
+	// Start is the first simulated day. The logs cover whole days, from its midnight in UTC
+	Start time.Time
+	Days  int
+	// Players is capped at 254
+	Players int
+	// SessionsPerDay is the most sessions a player plays a day
+	SessionsPerDay int
+}
+
+type SimulatedPlayer struct {
+	NickName string
+	SteamID  string
+	IP       string
+	Country  string
+}
+
+// SimulatedLogs are srcds log files of NMRiH with the ground truth they were written from.
+type SimulatedLogs struct {
+	// Files are keyed by file name, one per day
+	Files   map[string][]byte
+	Players []SimulatedPlayer
+	// Sessions are sorted by start, then nickname. A player never plays two at once and every one ends the day it starts
+	Sessions     []dto.Session
+	Kills        int
+	Suicides     int
+	ChatMessages int
+	Extractions  int
+	Rounds       int
+}
+
+type simulatedLine struct {
+	at   time.Time
+	text string
+}
+
+// SimulateLogs writes the logs of the given days. The same config always gives the same logs.
+func SimulateLogs(config LogSimulationConfig) *SimulatedLogs {
+	rng := rand.New(rand.NewPCG(config.Seed, config.Seed))
+	logs := &SimulatedLogs{Files: make(map[string][]byte)}
+
+	for i := range min(config.Players, maxSimulatedPlayers) {
+		logs.Players = append(logs.Players, SimulatedPlayer{
+			NickName: fmt.Sprintf("%s_%02d", simulatedNickNames[i%len(simulatedNickNames)], i),
+			SteamID:  fmt.Sprintf("[U:1:%d]", steamAccountBase+i*steamAccountStride+rng.IntN(steamAccountStride)),
+			IP:       fmt.Sprintf("198.51.100.%d", i+1),
+			Country:  simulatedCountries[rng.IntN(len(simulatedCountries))],
+		})
+	}
+
+	firstDay := time.Date(config.Start.Year(), config.Start.Month(), config.Start.Day(), 0, 0, 0, 0, time.UTC)
+	for day := range config.Days {
+		logs.simulateDay(rng, config, firstDay.AddDate(0, 0, day))
+	}
+
+	sort.Slice(logs.Sessions, func(i, j int) bool {
+		if !logs.Sessions[i].Start.Equal(logs.Sessions[j].Start) {
+			return logs.Sessions[i].Start.Before(logs.Sessions[j].Start)
+		}
+		return logs.Sessions[i].NickName < logs.Sessions[j].NickName
+	})
+
+	return logs
+}
+
+// TimeSpent sums up the session durations per player.
+func (l *SimulatedLogs) TimeSpent() map[string]time.Duration {
+	timeSpent := make(map[string]time.Duration)
+	for _, session := range l.Sessions {
+		timeSpent[session.NickName] += session.End.Sub(session.Start)
+	}
+	return timeSpent
+}
+
+// Countries maps the player addresses to their country codes, for GeoIP stubs.
+func (l *SimulatedLogs) Countries() map[string]string {
+	countries := make(map[string]string, len(l.Players))
+	for _, player := range l.Players {
+		countries[player.IP] = player.Country
+	}
+	return countries
+}
+
+func (l *SimulatedLogs) simulateDay(rng *rand.Rand, config LogSimulationConfig, day time.Time) {
+	fileName := fmt.Sprintf("l%s000.log", day.Format("0102"))
+	mapName := simulatedMaps[rng.IntN(len(simulatedMaps))]
+
+	lines := []simulatedLine{
+		{day.Add(5 * time.Second), fmt.Sprintf(
+			`Log file started (file "logs/%s") (game "/home/steam/nmrih/nmrih") (version "9095")`, fileName,
+		)},
+		{day.Add(5 * time.Second), fmt.Sprintf(`Loading map "%s"`, mapName)},
+		{day.Add(8 * time.Second), fmt.Sprintf(`Started map "%s" (CRC "%d")`, mapName, rng.Int32())},
+		{day.Add(9 * time.Second), "[META] Loaded 0 plugins (1 already loaded)"},
+		{day.Add(30 * time.Second), `World triggered "Round_Start"`},
+	}
+	l.Rounds++
+
+	userID := 2
+	sessionsPerDay := max(config.SessionsPerDay, 1)
+	slotLength := (simulatedDayEnd - simulatedDayStart) / time.Duration(sessionsPerDay)
+	for _, player := range l.Players {
+		for slot := range sessionsPerDay {
+			// A third of the slots stay empty
+			if rng.IntN(3) == 0 {
+				continue
+			}
+			slotStart := day.Add(simulatedDayStart + time.Duration(slot)*slotLength)
+			start := slotStart.Add(randomSeconds(rng, slotLength/4))
+			end := start.Add(minSimulatedSession + randomSeconds(rng, slotLength/2))
+			lines = append(lines, l.simulateSession(rng, player, userID, start, end)...)
+			userID++
+		}
+	}
+
+	roundEnd := `World triggered "Extraction_Success"`
+	if rng.IntN(2) == 0 {
+		roundEnd = `World triggered "Round_Lose"`
+	}
+	lines = append(lines,
+		simulatedLine{day.Add(23*time.Hour + 55*time.Minute), roundEnd},
+		simulatedLine{day.Add(24*time.Hour - time.Second), "Log file closed."},
+	)
+
+	// Stable, so the lines of the same second keep the order they happen in
+	sort.SliceStable(lines, func(i, j int) bool {
+		return lines[i].at.Before(lines[j].at)
+	})
+
+	var file bytes.Buffer
+	for _, line := range lines {
+		fmt.Fprintf(&file, "L %s: %s\n", line.at.Format(simulatedTimeFormat), line.text)
+	}
+	l.Files[fileName] = file.Bytes()
+}
+
+// simulateSession writes a session from the connect to the disconnect, with some play in between.
+func (l *SimulatedLogs) simulateSession(
+	rng *rand.Rand,
+	player SimulatedPlayer,
+	userID int,
+	start time.Time,
+	end time.Time,
+) []simulatedLine {
+	unassigned := fmt.Sprintf("%s<%d><%s><>", player.NickName, userID, player.SteamID)
+	survivor := fmt.Sprintf("%s<%d><%s><Survivors>", player.NickName, userID, player.SteamID)
+
+	lines := []simulatedLine{
+		{start, fmt.Sprintf(`"%s" connected, address "%s:%d"`, unassigned, player.IP, simulatedClientPort)},
+		{start, fmt.Sprintf(`"%s" STEAM USERID validated`, unassigned)},
+		{start.Add(time.Second + randomSeconds(rng, 30*time.Second)), fmt.Sprintf(`"%s" entered the game`, unassigned)},
+	}
+
+	// The play stays a minute clear of either end of the session
+	playStart, playLength := start.Add(time.Minute), end.Sub(start)-2*time.Minute
+	for range rng.IntN(simulatedActivities + 1) {
+		at := playStart.Add(randomSeconds(rng, playLength))
+		switch rng.IntN(4) {
+		case 0:
+			say := "say"
+			if rng.IntN(4) == 0 {
+				say = "say_team"
+			}
+			message := simulatedMessages[rng.IntN(len(simulatedMessages))]
+			lines = append(lines, simulatedLine{at, fmt.Sprintf(`"%s" %s "%s"`, survivor, say, message)})
+			l.ChatMessages++
+		case 1:
+			lines = append(lines, simulatedLine{at, fmt.Sprintf(`"%s" committed suicide with "world"`, survivor)})
+			l.Suicides++
+		default:
+			lines = append(lines, simulatedLine{at, fmt.Sprintf(
+				`"%s" killed "%s<-1><><>" with "%s"`,
+				survivor,
+				simulatedZombies[rng.IntN(len(simulatedZombies))],
+				simulatedWeapons[rng.IntN(len(simulatedWeapons))],
+			)})
+			l.Kills++
+		}
+	}
+	if rng.IntN(3) == 0 {
+		lines = append(lines, simulatedLine{end.Add(-time.Minute), fmt.Sprintf(`"%s" triggered "extracted"`, survivor)})
+		l.Extractions++
+	}
+
+	lines = append(lines, simulatedLine{end, fmt.Sprintf(
+		`"%s<%d><%s><#SDK_Team_Unassigned>" disconnected (reason "Disconnect by user.")`,
+		player.NickName, userID, player.SteamID,
+	)})
+	l.Sessions = append(l.Sessions, dto.Session{NickName: player.NickName, Start: start, End: end})
+
+	return lines
+}
+
+// randomSeconds is a whole number of seconds below limit.
+func randomSeconds(rng *rand.Rand, limit time.Duration) time.Duration {
+	return time.Duration(rng.Int64N(max(int64(limit/time.Second), 1))) * time.Second
+}
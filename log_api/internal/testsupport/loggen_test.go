@@ -0,0 +1,45 @@
+package testsupport_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/testsupport"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSimulateLogs(t *testing.T) {
+	t.Parallel()
+	config := testsupport.LogSimulationConfig{
+		Seed:           7,
+		Start:          time.Date(2025, time.April, 1, 0, 0, 0, 0, time.UTC),
+		Days:           3,
+		Players:        12,
+		SessionsPerDay: 3,
+	}
+	logs := testsupport.SimulateLogs(config)
+
+	assert.Equal(t, logs, testsupport.SimulateLogs(config), "the same config gives the same logs")
+	require.Len(t, logs.Files, config.Days)
+	require.Len(t, logs.Players, config.Players)
+	require.NotEmpty(t, logs.Sessions)
+
+	for name, file := range logs.Files {
+		lines := bytes.Split(bytes.TrimSuffix(file, []byte("\n")), []byte("\n"))
+		assert.Contains(t, string(lines[0]), "Log file started", name)
+		assert.Contains(t, string(lines[len(lines)-1]), "Log file closed.", name)
+	}
+
+	lastEnd := make(map[string]time.Time)
+	for i, session := range logs.Sessions {
+		assert.True(t, session.End.After(session.Start), "session %d ends after its start", i)
+		assert.Equal(t, session.Start.YearDay(), session.End.YearDay(), "session %d ends the day it starts", i)
+		assert.True(t, session.Start.After(lastEnd[session.NickName]), "sessions of %s do not overlap", session.NickName)
+		lastEnd[session.NickName] = session.End
+		if i > 0 {
+			assert.False(t, session.Start.Before(logs.Sessions[i-1].Start), "sessions are sorted by start")
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package tools_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/tools"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "nested", "file.json")
+
+	assert.NoError(t, tools.WriteFileAtomic(path, []byte("first"), 0o600))
+	assert.NoError(t, tools.WriteFileAtomic(path, []byte("second"), 0o600))
+
+	content, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "second", string(content))
+
+	entries, err := os.ReadDir(filepath.Dir(path))
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1, "temp files should not be left behind")
+}
+
+func TestCheckWritable(t *testing.T) {
+	dir := t.TempDir()
+
+	assert.NoError(t, tools.CheckWritable(dir))
+	entries, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Empty(t, entries, "the probe file should not be left behind")
+
+	assert.Error(t, tools.CheckWritable(filepath.Join(dir, "missing")))
+	assert.Error(t, tools.CheckWritable(""))
+}
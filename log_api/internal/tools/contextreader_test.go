@@ -0,0 +1,28 @@
+package tools_test
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/tools"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewContextReader(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	r := tools.NewContextReader(ctx, strings.NewReader("first second"))
+
+	buf := make([]byte, len("first"))
+	n, err := r.Read(buf)
+	require.NoError(t, err)
+	assert.Equal(t, "first", string(buf[:n]))
+
+	cancel()
+	rest, err := io.ReadAll(r)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Empty(t, rest)
+}
@@ -9,4 +9,24 @@ var (
 	DateTimeRegex = regexp.MustCompile(
 		`^L\s+(\d{2}\/\d{2}\/\d{4}\s-\s\d{2}:\d{2}:\d{2}):`,
 	)
+	// ChatRegex captures nickname, player id, channel and message of a `say` / `say_team` line
+	ChatRegex = regexp.MustCompile(
+		`:\s"(.*?)<\d+><([^>]*)><[^>]*>" (say_team|say) "(.*)"\s*$`,
+	)
+	// MapStartedRegex captures the map name of a `Started map "nmo_broadway"` line
+	MapStartedRegex = regexp.MustCompile(
+		`:\sStarted map "([^"]+)"`,
+	)
+	// WorldTriggeredRegex captures the event of a `World triggered "Round_Start"` line
+	WorldTriggeredRegex = regexp.MustCompile(
+		`:\sWorld triggered "([^"]+)"`,
+	)
+	// PlayerTriggeredRegex captures nickname, player id and event of a `"Nick<2><[U:1:1]><>" triggered "..."` line
+	PlayerTriggeredRegex = regexp.MustCompile(
+		`:\s"(.*?)<\d+><([^>]*)><[^>]*>" triggered "([^"]+)"`,
+	)
+	// KillRegex captures killer nickname and id, victim nickname and id, and weapon of a `killed` line
+	KillRegex = regexp.MustCompile(
+		`:\s"(.*?)<-?\d+><([^>]*)><[^>]*>" killed "(.*?)<-?\d+><([^>]*)><[^>]*>" with "([^"]*)"`,
+	)
 )
@@ -42,3 +42,42 @@ func TestDateTimeRegex(t *testing.T) {
 		`L 03/23/2025 08:05:10: "XXXXX<101><[U:1:xxxxxxxxxx]><>" committed suicide with "world"`,
 	))
 }
+
+func TestChatRegex(t *testing.T) {
+	matches := tools.ChatRegex.FindStringSubmatch(
+		`L 03/15/2025 - 16:05:12: "Big Zeeb<69><[U:1:123]><#Team_Survivor>" say_team "I got disconnected "lol""`,
+	)
+	assert.Equal(t, []string{"Big Zeeb", "[U:1:123]", "say_team", `I got disconnected "lol"`}, matches[1:])
+
+	matches = tools.ChatRegex.FindStringSubmatch(`L 03/15/2025 - 16:05:12: "Zeeb<69><[U:1:123]><>" say "hi"`)
+	assert.Equal(t, []string{"Zeeb", "[U:1:123]", "say", "hi"}, matches[1:])
+
+	assert.False(t, tools.ChatRegex.MatchString(
+		`L 03/15/2025 - 16:05:12: "BigZeeb<69><[U:1:123]><>" connected, address "123.190.1.1:27005"`,
+	))
+}
+
+func TestRoundRegexes(t *testing.T) {
+	matches := tools.MapStartedRegex.FindStringSubmatch(`L 03/15/2025 - 15:14:03: Started map "nmo_broadway" (CRC "-1")`)
+	assert.Equal(t, "nmo_broadway", matches[1])
+
+	matches = tools.WorldTriggeredRegex.FindStringSubmatch(`L 03/15/2025 - 15:20:00: World triggered "Round_Start"`)
+	assert.Equal(t, "Round_Start", matches[1])
+
+	matches = tools.PlayerTriggeredRegex.FindStringSubmatch(
+		`L 03/15/2025 - 15:40:00: "Zeeb<2><[U:1:123]><>" triggered "player_extracted"`,
+	)
+	assert.Equal(t, []string{"Zeeb", "[U:1:123]", "player_extracted"}, matches[1:])
+}
+
+func TestKillRegex(t *testing.T) {
+	matches := tools.KillRegex.FindStringSubmatch(
+		`L 03/15/2025 - 16:05:12: "Big Zeeb<69><[U:1:123]><>" killed "Alice<70><[U:1:456]><>" with "fa_glock17"`,
+	)
+	assert.Equal(t, []string{"Big Zeeb", "[U:1:123]", "Alice", "[U:1:456]", "fa_glock17"}, matches[1:])
+
+	matches = tools.KillRegex.FindStringSubmatch(
+		`L 03/15/2025 - 16:05:12: "Big Zeeb<69><[U:1:123]><>" killed "npc_nmrih_shamblerzombie<-1><><>" with "me_machete"`,
+	)
+	assert.Equal(t, []string{"Big Zeeb", "[U:1:123]", "npc_nmrih_shamblerzombie", "", "me_machete"}, matches[1:])
+}
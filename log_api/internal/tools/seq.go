@@ -0,0 +1,14 @@
+package tools
+
+import "iter"
+
+// SeqOf streams the values of a slice in the iter.Seq2 form of readers that can fail.
+func SeqOf[T any](values []T) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		for _, value := range values {
+			if !yield(value, nil) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,21 @@
+package tools_test
+
+import (
+	"testing"
+
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/tools"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSeqOf(t *testing.T) {
+	t.Parallel()
+	var values []int
+	for value, err := range tools.SeqOf([]int{1, 2, 3}) {
+		assert.NoError(t, err)
+		if value == 3 {
+			break
+		}
+		values = append(values, value)
+	}
+	assert.Equal(t, []int{1, 2}, values)
+}
@@ -0,0 +1,48 @@
+package tools
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const steamID64Base = 76561197960265728
+
+var ErrInvalidSteamID = errors.New("invalid steam id")
+
+// SteamIDRegex extracts the SteamID from a player token like "Nick<15><[U:1:123456]><>".
+var SteamIDRegex = regexp.MustCompile(`<(\[U:\d:\d+\]|STEAM_\d:[01]:\d+)>`)
+
+var (
+	steamID3Regex = regexp.MustCompile(`^(?:\[U:(\d):(\d+)\]|U:(\d):(\d+))$`)
+	steamID2Regex = regexp.MustCompile(`^STEAM_\d:([01]):(\d+)$`)
+)
+
+// NormalizeSteamID converts SteamID2 ("STEAM_0:1:123"), SteamID3 ("[U:1:247]", "U:1:247")
+// and SteamID64 ("76561197960265975") notations into the SteamID3 form used in srcds logs.
+func NormalizeSteamID(id string) (string, error) {
+	id = strings.TrimSpace(id)
+
+	if matches := steamID3Regex.FindStringSubmatch(id); matches != nil {
+		// Either the bracketed groups or the bare ones matched
+		universe, accountID := matches[1]+matches[3], matches[2]+matches[4]
+		return fmt.Sprintf("[U:%s:%s]", universe, accountID), nil
+	}
+
+	if matches := steamID2Regex.FindStringSubmatch(id); matches != nil {
+		authServer, _ := strconv.ParseUint(matches[1], 10, 64)
+		accountNumber, err := strconv.ParseUint(matches[2], 10, 32)
+		if err != nil {
+			return "", fmt.Errorf("%w: %s", ErrInvalidSteamID, id)
+		}
+		return fmt.Sprintf("[U:1:%d]", accountNumber*2+authServer), nil //nolint:mnd // SteamID2 -> account id
+	}
+
+	steamID64, err := strconv.ParseUint(id, 10, 64)
+	if err != nil || steamID64 <= steamID64Base {
+		return "", fmt.Errorf("%w: %s", ErrInvalidSteamID, id)
+	}
+	return fmt.Sprintf("[U:1:%d]", steamID64-steamID64Base), nil
+}
@@ -0,0 +1,37 @@
+package tools_test
+
+import (
+	"testing"
+
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/tools"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSteamIDRegex(t *testing.T) {
+	matches := tools.SteamIDRegex.FindStringSubmatch(
+		`L 03/15/2025 - 16:05:12: "BigZeeb<69><[U:1:123456]><>" connected, address "123.190.1.1:27005"`,
+	)
+	assert.Len(t, matches, 2)
+	assert.Equal(t, "[U:1:123456]", matches[1])
+
+	assert.False(t, tools.SteamIDRegex.MatchString(`L 03/15/2025 - 16:05:12: "Bot<3><BOT><>" entered the game`))
+}
+
+func TestNormalizeSteamID(t *testing.T) {
+	for input, expected := range map[string]string{
+		"[U:1:247]":         "[U:1:247]",
+		"U:1:247":           "[U:1:247]",
+		"STEAM_0:1:123":     "[U:1:247]",
+		"STEAM_1:1:123":     "[U:1:247]",
+		"76561197960265975": "[U:1:247]",
+	} {
+		actual, err := tools.NormalizeSteamID(input)
+		assert.NoError(t, err, input)
+		assert.Equal(t, expected, actual, input)
+	}
+
+	for _, input := range []string{"", "abc", "STEAM_0:2:1", "123", "[U:1:2", "U:1:2]"} {
+		_, err := tools.NormalizeSteamID(input)
+		assert.ErrorIs(t, err, tools.ErrInvalidSteamID, input)
+	}
+}
@@ -0,0 +1,65 @@
+package tools
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+const dateQueryFormat = "2006-01-02"
+
+// ParseTimeQuery parses a time query parameter given either as RFC 3339 or as a plain date,
+// which stands for its midnight in UTC.
+func ParseTimeQuery(value string) (time.Time, error) {
+	parsed, _, err := parseTimeQuery(value)
+	return parsed, err
+}
+
+// ParseTimeQueryEnd parses the inclusive end of a time range like ParseTimeQuery, except that a plain date
+// stands for the last instant of that day, so the day itself is in the range.
+func ParseTimeQueryEnd(value string) (time.Time, error) {
+	parsed, dateOnly, err := parseTimeQuery(value)
+	if err != nil || !dateOnly {
+		return parsed, err
+	}
+	return parsed.AddDate(0, 0, 1).Add(-time.Nanosecond), nil
+}
+
+func parseTimeQuery(value string) (time.Time, bool, error) {
+	if parsed, err := time.Parse(time.RFC3339, value); err == nil {
+		return parsed, false, nil
+	}
+	parsed, err := time.Parse(dateQueryFormat, value)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("invalid time %q, expected RFC 3339 or YYYY-MM-DD", value)
+	}
+	return parsed, true, nil
+}
+
+// ParseTimeRangeQuery parses the optional from and to query parameters, leaving the empty ones nil.
+// A plain date as to takes in the whole day.
+func ParseTimeRangeQuery(fromValue string, toValue string) (*time.Time, *time.Time, error) {
+	parse := func(value string, parseTime func(value string) (time.Time, error)) (*time.Time, error) {
+		if value == "" {
+			return nil, nil //nolint:nilnil // an empty parameter is an open bound
+		}
+		parsed, err := parseTime(value)
+		if err != nil {
+			return nil, err
+		}
+		return &parsed, nil
+	}
+
+	from, err := parse(fromValue, ParseTimeQuery)
+	if err != nil {
+		return nil, nil, err
+	}
+	to, err := parse(toValue, ParseTimeQueryEnd)
+	if err != nil {
+		return nil, nil, err
+	}
+	if from != nil && to != nil && to.Before(*from) {
+		return nil, nil, errors.New("to must not be before from")
+	}
+	return from, to, nil
+}
@@ -0,0 +1,66 @@
+package tools_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/tools"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTimeRangeQuery(t *testing.T) {
+	t.Parallel()
+	may := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		from     string
+		to       string
+		wantFrom *time.Time
+		wantTo   *time.Time
+		wantErr  bool
+	}{
+		{
+			name: "success: open range",
+		},
+		{
+			name:     "success: a date as to takes in the whole day",
+			from:     "2024-05-01",
+			to:       "2024-05-01",
+			wantFrom: tools.ToPtr(may),
+			wantTo:   tools.ToPtr(may.AddDate(0, 0, 1).Add(-time.Nanosecond)),
+		},
+		{
+			name:     "success: times are taken as they are",
+			from:     "2024-05-01T10:00:00Z",
+			to:       "2024-05-01T12:00:00Z",
+			wantFrom: tools.ToPtr(may.Add(10 * time.Hour)),
+			wantTo:   tools.ToPtr(may.Add(12 * time.Hour)),
+		},
+		{
+			name:    "failure: to before from",
+			from:    "2024-05-02",
+			to:      "2024-05-01",
+			wantErr: true,
+		},
+		{
+			name:    "failure: invalid time",
+			to:      "May 1st",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			from, to, err := tools.ParseTimeRangeQuery(tt.from, tt.to)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantFrom, from)
+			assert.Equal(t, tt.wantTo, to)
+		})
+	}
+}
@@ -1,32 +1,105 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"log"
+	"io"
+	"log/slog"
+	"net"
 	"net/http"
 	"os"
+	"os/signal"
+	"runtime"
 	"strconv"
+	"strings"
+	"syscall"
 	"time"
 
 	"github.com/dmitriitimoshenko/nmrih/log_api/internal/app/a2sclient"
 	a2sclientconfig "github.com/dmitriitimoshenko/nmrih/log_api/internal/app/a2sclient/config"
 	"github.com/dmitriitimoshenko/nmrih/log_api/internal/app/cache"
 	redisclientconfig "github.com/dmitriitimoshenko/nmrih/log_api/internal/app/cache/config"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/app/handlers/adminhandler"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/app/handlers/chathandler"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/app/handlers/erasurehandler"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/app/handlers/exporthandler"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/app/handlers/healthhandler"
 	"github.com/dmitriitimoshenko/nmrih/log_api/internal/app/handlers/loggraphhandler"
 	"github.com/dmitriitimoshenko/nmrih/log_api/internal/app/handlers/logparserhandler"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/app/handlers/playershandler"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/app/handlers/ratelimithandler"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/app/handlers/recordshandler"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/app/handlers/requestloghandler"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/app/handlers/streamhandler"
 	"github.com/dmitriitimoshenko/nmrih/log_api/internal/app/ipapiclient"
 	ipapiclientconfig "github.com/dmitriitimoshenko/nmrih/log_api/internal/app/ipapiclient/config"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/app/logging"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/app/rconclient"
+	rconclientconfig "github.com/dmitriitimoshenko/nmrih/log_api/internal/app/rconclient/config"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/app/router"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/dto"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/enums"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/services/admin"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/services/aliases"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/services/audit"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/services/chat"
 	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/services/csvgenerator"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/services/csvmaintenance"
 	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/services/csvparser"
 	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/services/csvrepository"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/services/erasure"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/services/export"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/services/generation"
 	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/services/graph"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/services/health"
 	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/services/logparser"
 	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/services/logrepository"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/services/logtail"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/services/notifier"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/services/parsereport"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/services/privacy"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/services/ratelimit"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/services/records"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/services/rollups"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/services/rounds"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/pkg/services/stream"
+	"github.com/dmitriitimoshenko/nmrih/log_api/internal/tools"
 
 	"github.com/gin-gonic/gin"
 )
 
-const defaultRedisTTL = 5 * time.Minute
+const (
+	defaultRedisTTL         = 5 * time.Minute
+	defaultLogWatchDebounce = 2 * time.Second
+	defaultLogWatchMaxDelay = 15 * time.Second
+	streamPlayersPollPeriod = 10 * time.Second
+	streamSubscriberBuffer  = 64
+	notifierPollInterval    = 30 * time.Second
+	notifierRetryBackoff    = 2 * time.Second
+	defaultRCONTimeout      = 5 * time.Second
+	defaultA2STimeout       = 3 * time.Second
+	defaultIPInfoTimeout    = 3 * time.Second
+	// ipinfo rate-limits bursts, a few lookups in flight are enough once each address is looked up once per parse
+	defaultIPInfoConcurrency = 4
+	healthCheckTimeout       = 2 * time.Second
+
+	serverReadHeaderTimeout = 10 * time.Second
+	serverReadTimeout       = 30 * time.Second
+	// Streams, exports and parses lift the write timeout for themselves
+	serverWriteTimeout     = 2 * time.Minute
+	serverIdleTimeout      = 2 * time.Minute
+	defaultShutdownTimeout = 30 * time.Second
+
+	rateLimitStoreTimeout       = 200 * time.Millisecond
+	rateLimitMaxMemoryBuckets   = 10000
+	defaultPublicPerMinute      = 120
+	defaultPublicBurst          = 60
+	defaultPlayersInfoPerMinute = 12
+	defaultPlayersInfoBurst     = 4
+	defaultAdminPerMinute       = 30
+	defaultAdminBurst           = 10
+)
 
 func CORSMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -43,14 +116,34 @@ func CORSMiddleware() gin.HandlerFunc {
 }
 
 func main() {
-	server := gin.Default()
-	server.Use(gin.Logger())
-	server.Use(gin.Recovery())
-	server.Use(CORSMiddleware())
+	// SIGTERM is how docker stops the container
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, os.Interrupt)
+	defer stop()
+
+	logLevel, err := logging.ParseLevel(os.Getenv("LOG_LEVEL"))
+	if err != nil {
+		fatal("Invalid LOG_LEVEL", "error", err)
+	}
+	// The standard log package, used by libraries, goes through it too
+	logger := logging.NewLogger(os.Stdout, logLevel)
+	slog.SetDefault(logger)
 
 	ginMode := os.Getenv("GIN_MODE")
-	log.Println("GIN Mode set to: ", ginMode)
 	gin.SetMode(ginMode)
+	slog.Info("Starting", "gin_mode", ginMode, "log_level", logLevel.String())
+
+	requestLogHandler := requestloghandler.NewRequestLogHandler()
+	server := gin.New()
+	// Services get the gin context, the request ID is in the request one
+	server.ContextWithFallback = true
+	server.Use(requestLogHandler.Log)
+	server.Use(gin.CustomRecoveryWithWriter(io.Discard, requestLogHandler.Recover))
+	server.Use(CORSMiddleware())
+
+	// Only the reverse proxy may name the client in X-Forwarded-For, rate limits and audit logs go by it
+	if err := server.SetTrustedProxies(trustedProxies()); err != nil {
+		fatal("Invalid TRUSTED_PROXIES", "error", err)
+	}
 
 	redisConfig := &redisclientconfig.RedisConfig{
 		Addr:       os.Getenv("REDIS_ADDR"),
@@ -62,19 +155,28 @@ func main() {
 
 	serverPort, err := strconv.Atoi(os.Getenv("SERVER_PORT"))
 	if err != nil {
-		log.Fatalln(err)
+		fatal("Invalid SERVER_PORT", "error", err)
 	}
 
-	ipAPIClientConfig := ipapiclientconfig.NewIPAPIClientConfig(os.Getenv("IP_INFO_API_TOKEN"))
+	ipAPIClientConfig := ipapiclientconfig.NewIPAPIClientConfig(
+		os.Getenv("IP_INFO_API_TOKEN"),
+		envSecondsOr("IP_INFO_TIMEOUT_SECONDS", defaultIPInfoTimeout),
+	)
 	ipAPIClient := ipapiclient.NewIPAPIClient(ipAPIClientConfig)
 	a2sClientConfig := a2sclientconfig.NewA2SClientConfig(
 		os.Getenv("SERVER_ADDR"),
 		serverPort,
+		envSecondsOr("A2S_TIMEOUT_SECONDS", defaultA2STimeout),
 	)
-	a2sClient, err := a2sclient.NewA2SClient(a2sClientConfig)
-	if err != nil {
-		log.Fatalln(err)
-	}
+	a2sClient := a2sclient.NewA2SClient(a2sClientConfig)
+
+	rconClientConfig := rconclientconfig.NewRCONClientConfig(
+		os.Getenv("SERVER_ADDR"),
+		serverPort,
+		os.Getenv("RCON_PASSWORD"),
+		envSecondsOr("RCON_TIMEOUT_SECONDS", defaultRCONTimeout),
+	)
+	rconClient := rconclient.NewRCONClient(rconClientConfig)
 
 	logRepositoryConfig := logrepository.NewConfig(os.Getenv("LOGS_STORAGE_DIRECTORY"), os.Getenv("LOGS_FILE_PATTERN"))
 	logRepositoryService := logrepository.NewService(*logRepositoryConfig)
@@ -82,39 +184,341 @@ func main() {
 	csvRepositoryConfig := csvrepository.NewConfig(os.Getenv("CSV_STORAGE_DIRECTORY"))
 	csvRepositoryService := csvrepository.NewService(*csvRepositoryConfig)
 	csvParserService := csvparser.NewService()
+	privacyService := newPrivacyService()
 	graphService := graph.NewService(a2sClient)
+	recordsConfig := records.NewConfig(os.Getenv("STATE_STORAGE_DIRECTORY"))
+	recordsService := records.NewService(*recordsConfig, graphService)
+	aliasesConfig := aliases.NewConfig(os.Getenv("STATE_STORAGE_DIRECTORY"))
+	aliasService := aliases.NewService(*aliasesConfig)
+	chatConfig := chat.NewConfig(os.Getenv("STATE_STORAGE_DIRECTORY"))
+	chatService := chat.NewService(*chatConfig)
+	roundsConfig := rounds.NewConfig(os.Getenv("STATE_STORAGE_DIRECTORY"))
+	roundsService := rounds.NewService(*roundsConfig)
+	rollupsConfig := rollups.NewConfig(os.Getenv("STATE_STORAGE_DIRECTORY"))
+	rollupsService := rollups.NewService(*rollupsConfig, graphService, csvRepositoryService, csvParserService)
+	// Until the rollups are built the graphs are computed from the CSV store
+	go func() {
+		if err := rollupsService.RebuildIfOutdated(ctx); err != nil {
+			slog.Error("Failed to rebuild the rollups", "error", err)
+		}
+	}()
+	generationConfig := generation.NewConfig(os.Getenv("STATE_STORAGE_DIRECTORY"))
+	generationService := generation.NewService(*generationConfig)
+	streamConfig := stream.NewConfig(streamPlayersPollPeriod, streamSubscriberBuffer)
+	streamService := stream.NewService(*streamConfig, graphService)
+
+	parseReportConfig := parsereport.NewConfig(os.Getenv("STATE_STORAGE_DIRECTORY"))
+	parseReportService := parsereport.NewService(*parseReportConfig)
 
+	bestEffort := false
+	if value := os.Getenv("LOG_PARSER_BEST_EFFORT"); value != "" {
+		bestEffort, err = strconv.ParseBool(value)
+		if err != nil {
+			fatal("Invalid LOG_PARSER_BEST_EFFORT", "error", err)
+		}
+	}
+	// Without a settings file the notifier has no rules and stays idle
+	notifierSettings := &dto.NotifierSettings{}
+	if notifierSettingsFile := os.Getenv("NOTIFIER_CONFIG_FILE"); notifierSettingsFile != "" {
+		notifierSettings, err = notifier.LoadSettings(notifierSettingsFile)
+		if err != nil {
+			fatal("Failed to load the notifier settings", "error", err)
+		}
+	}
+	notifierConfig := notifier.NewConfig(
+		*notifierSettings,
+		os.Getenv("STATE_STORAGE_DIRECTORY"),
+		notifierPollInterval,
+		notifierRetryBackoff,
+	)
+	notifierService := notifier.NewService(*notifierConfig, recordsService, graphService)
+	go notifierService.Run(ctx)
+
+	logParserConfig := logparser.NewConfig(
+		bestEffort,
+		time.Duration(envInt("LOG_PARSER_TIMEOUT_SECONDS"))*time.Second,
+		envIntOr("LOG_PARSER_WORKERS", runtime.NumCPU()),
+		envIntOr("IP_INFO_CONCURRENCY", defaultIPInfoConcurrency),
+	)
 	logParserService := logparser.NewService(
+		*logParserConfig,
 		logRepositoryService,
 		csvGeneratorService,
 		csvRepositoryService,
 		ipAPIClient,
+		privacyService,
+		parseReportService,
+		recordsService,
+		aliasService,
+		chatService,
+		roundsService,
+		rollupsService,
+		// After every index the graphs are built from
+		generationService,
+		streamService,
+		// After records: it compares against the updated all-time peak
+		notifierService,
 	)
 
+	if watchEnabled, _ := strconv.ParseBool(os.Getenv("LOG_WATCH_ENABLED")); watchEnabled {
+		logWatchConfig := logrepository.NewWatchConfig(
+			os.Getenv("STATE_STORAGE_DIRECTORY"),
+			defaultLogWatchDebounce,
+			defaultLogWatchMaxDelay,
+		)
+		logWatcher := logrepository.NewWatcher(*logRepositoryConfig, *logWatchConfig)
+		logTailService := logtail.NewService(logWatcher, logParserService, redisClient)
+		go func() {
+			if err := logTailService.Run(ctx); err != nil {
+				slog.Error("Log watcher stopped", "error", err)
+			}
+		}()
+	}
+
 	logParserHandler := logparserhandler.NewLogParserHandler(
 		redisClient,
 		logParserService,
+		parseReportService,
 	)
 	logGraphHandler := loggraphhandler.NewLogGraphHandler(
 		redisClient,
 		csvRepositoryService,
 		csvParserService,
 		graphService,
+		roundsService,
+		rollupsService,
+		generationService,
+	)
+	recordsHandler := recordshandler.NewRecordsHandler(recordsService)
+	playersHandler := playershandler.NewPlayersHandler(aliasService)
+	chatHandler := chathandler.NewChatHandler(chatService)
+	streamHandler := streamhandler.NewStreamHandler(streamService)
+	auditConfig := audit.NewConfig(os.Getenv("STATE_STORAGE_DIRECTORY"))
+	auditService := audit.NewService(*auditConfig)
+	adminService := admin.NewService(rconClient, auditService, privacyService)
+	adminHandler := adminhandler.NewAdminHandler(adminService, os.Getenv("ADMIN_API_TOKEN"))
+	csvMaintenanceService := newCSVMaintenanceService(csvRepositoryService, csvParserService, csvGeneratorService)
+	if intervalHours := envInt("CSV_COMPACTION_INTERVAL_HOURS"); intervalHours > 0 {
+		go csvMaintenanceService.Run(
+			ctx,
+			time.Duration(intervalHours)*time.Hour,
+			time.Duration(envInt("CSV_COMPACTION_TIMEOUT_MINUTES"))*time.Minute,
+			rollupsService,
+			generationService,
+		)
+	}
+
+	exportService := export.NewService(
+		csvRepositoryService,
+		csvParserService,
+		csvGeneratorService,
+		graphService,
+		privacyService,
+	)
+	exportHandler := exporthandler.NewExportHandler(
+		exportService,
+		net.JoinHostPort(os.Getenv("SERVER_ADDR"), strconv.Itoa(serverPort)),
+	)
+
+	erasureService := erasure.NewService(
+		csvMaintenanceService,
+		aliasService,
+		privacyService,
+		auditService,
+		redisClient,
+		chatService,
+		recordsService,
+		roundsService,
+		parseReportService,
+		// After the CSV store was rewritten
+		rollupsService,
+		generationService,
+		aliasService,
 	)
+	erasureHandler := erasurehandler.NewErasureHandler(erasureService)
 
-	server.GET("/health-check", func(c *gin.Context) {
-		c.JSON(http.StatusOK, gin.H{
-			"message": "OK",
-		})
+	rateLimitService := ratelimit.NewService(
+		*ratelimit.NewConfig(rateLimitStoreTimeout, rateLimitMaxMemoryBuckets),
+		redisClient,
+	)
+	rateLimitHandler := ratelimithandler.NewRateLimitHandler(
+		*ratelimithandler.NewConfig(
+			rateLimitPolicy("public", "RATE_LIMIT_PUBLIC", defaultPublicPerMinute, defaultPublicBurst),
+			rateLimitPolicy("players-info", "RATE_LIMIT_PLAYERS_INFO", defaultPlayersInfoPerMinute, defaultPlayersInfoBurst),
+			rateLimitPolicy("admin", "RATE_LIMIT_ADMIN", defaultAdminPerMinute, defaultAdminBurst),
+		),
+		rateLimitService,
+	)
+
+	healthService := health.NewService(
+		*health.NewConfig(healthCheckTimeout),
+		health.Check{Name: "csv-storage", Critical: true, Probe: writableProbe(os.Getenv("CSV_STORAGE_DIRECTORY"))},
+		health.Check{Name: "state-storage", Critical: true, Probe: writableProbe(os.Getenv("STATE_STORAGE_DIRECTORY"))},
+		health.Check{Name: "redis", Critical: true, Probe: redisClient.Ping},
+		// Without the game server only the live data is missing
+		health.Check{Name: "a2s", Probe: a2sClient.Ping},
+	)
+	healthHandler := healthhandler.NewHealthHandler(healthService)
+
+	router.Register(server, router.Handlers{
+		Health:    healthHandler,
+		LogParser: logParserHandler,
+		LogGraph:  logGraphHandler,
+		Records:   recordsHandler,
+		Players:   playersHandler,
+		Chat:      chatHandler,
+		Stream:    streamHandler,
+		Admin:     adminHandler,
+		Erasure:   erasureHandler,
+		Export:    exportHandler,
+		RateLimit: rateLimitHandler,
 	})
 
-	apiv1 := server.Group("/api/v1")
-	apiv1.GET("/parse", logParserHandler.Parse)
-	apiv1.GET("/graph", logGraphHandler.Graph)
+	httpServer := &http.Server{
+		Addr:              fmt.Sprintf(":%s", os.Getenv("PORT")),
+		Handler:           server.Handler(),
+		ReadHeaderTimeout: serverReadHeaderTimeout,
+		ReadTimeout:       serverReadTimeout,
+		WriteTimeout:      serverWriteTimeout,
+		IdleTimeout:       serverIdleTimeout,
+		ErrorLog:          slog.NewLogLogger(logger.Handler(), slog.LevelWarn),
+	}
+	// Shutdown does not wait for hijacked or streaming connections, the streams end on their own
+	httpServer.RegisterOnShutdown(streamService.Close)
 
-	ports := fmt.Sprintf(":%s", os.Getenv("PORT"))
-	err = server.Run(ports)
-	if err != nil {
-		log.Fatalf("couldn't run server: %v", err)
+	serverErr := make(chan error, 1)
+	go func() {
+		serverErr <- httpServer.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serverErr:
+		fatal("Couldn't run the server", "error", err)
+	case <-ctx.Done():
+	}
+	stop()
+
+	shutdownTimeout := defaultShutdownTimeout
+	if seconds := envInt("SHUTDOWN_TIMEOUT_SECONDS"); seconds > 0 {
+		shutdownTimeout = time.Duration(seconds) * time.Second
+	}
+	slog.Info("Shutting down, waiting for requests and the running parse", "timeout", shutdownTimeout.String())
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	if err := httpServer.Shutdown(shutdownCtx); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		slog.Error("Failed to drain requests", "error", err)
+	}
+	// The log watcher may still be parsing after the requests are drained
+	if err := logParserService.Shutdown(shutdownCtx); err != nil {
+		slog.Error("Failed to shut down the log parser", "error", err)
+	}
+	slog.Info("Server stopped")
+}
+
+// writableProbe checks that files can be written in dir. An unset dir is not ready.
+func writableProbe(dir string) func(ctx context.Context) error {
+	return func(_ context.Context) error {
+		return tools.CheckWritable(dir)
+	}
+}
+
+// newCSVMaintenanceService applies the CSV_*_RETENTION_DAYS policy, archiving to CSV_ARCHIVE_DIRECTORY if it is set.
+func newCSVMaintenanceService(
+	csvRepositoryService *csvrepository.Service,
+	csvParserService *csvparser.Service,
+	csvGeneratorService *csvgenerator.CSVGenerator,
+) *csvmaintenance.Service {
+	csvMaintenanceConfig := csvmaintenance.NewConfig(envInt("CSV_IP_RETENTION_DAYS"), envInt("CSV_EVENT_RETENTION_DAYS"))
+	if archiveDirectory := os.Getenv("CSV_ARCHIVE_DIRECTORY"); archiveDirectory != "" {
+		archiveRepositoryService := csvrepository.NewService(*csvrepository.NewConfig(archiveDirectory))
+		return csvmaintenance.NewService(
+			*csvMaintenanceConfig,
+			csvRepositoryService,
+			archiveRepositoryService,
+			csvParserService,
+			csvGeneratorService,
+		)
+	}
+	return csvmaintenance.NewService(
+		*csvMaintenanceConfig,
+		csvRepositoryService,
+		nil,
+		csvParserService,
+		csvGeneratorService,
+	)
+}
+
+// newPrivacyService applies PRIVACY_IP_MODE (plain, hash or truncate). PRIVACY_HASH_KEY keys the IP hashes
+// and the pseudonyms of erased players, and is required to hash. Without it, erasures are refused.
+func newPrivacyService() *privacy.Service {
+	ipMode := enums.IPModes.Plain()
+	if value := os.Getenv("PRIVACY_IP_MODE"); value != "" {
+		ipMode = enums.IPMode(value)
 	}
+	if !ipMode.IsValid() {
+		fatal("PRIVACY_IP_MODE must be one of plain, hash or truncate", "value", ipMode)
+	}
+	hashKey := os.Getenv("PRIVACY_HASH_KEY")
+	if ipMode == enums.IPModes.Hash() && hashKey == "" {
+		fatal("PRIVACY_HASH_KEY is required with PRIVACY_IP_MODE=hash")
+	}
+	if hashKey == "" {
+		slog.Warn("PRIVACY_HASH_KEY is not set, player erasures are refused")
+	}
+	return privacy.NewService(*privacy.NewConfig(ipMode, hashKey))
+}
+
+// rateLimitPolicy reads <prefix>_PER_MINUTE and <prefix>_BURST, a zero rate turns the limit off.
+func rateLimitPolicy(name, prefix string, defaultPerMinute, defaultBurst int) dto.RateLimitPolicy {
+	return dto.RateLimitPolicy{
+		Name:      name,
+		PerMinute: envIntOr(prefix+"_PER_MINUTE", defaultPerMinute),
+		Burst:     envIntOr(prefix+"_BURST", defaultBurst),
+	}
+}
+
+// trustedProxies reads TRUSTED_PROXIES, a comma separated list of IPs and CIDRs. Without it no proxy is trusted.
+func trustedProxies() []string {
+	var proxies []string
+	for _, proxy := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			proxies = append(proxies, proxy)
+		}
+	}
+	return proxies
+}
+
+func envIntOr(name string, fallback int) int {
+	if os.Getenv(name) == "" {
+		return fallback
+	}
+	return envInt(name)
+}
+
+// envSecondsOr reads an optional number of seconds, fallback when it is not set or zero.
+func envSecondsOr(name string, fallback time.Duration) time.Duration {
+	if seconds := envInt(name); seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	return fallback
+}
+
+// envInt reads an optional non-negative integer setting, 0 when it is not set.
+func envInt(name string) int {
+	value := os.Getenv(name)
+	if value == "" {
+		return 0
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil || parsed < 0 {
+		fatal("Setting must be a non-negative integer", "name", name, "value", value)
+	}
+	return parsed
+}
+
+// fatal logs the error and exits, as slog has no Fatal.
+func fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
 }